@@ -9,11 +9,13 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/NYTimes/logrotate"
@@ -33,6 +35,7 @@ import (
 	"github.com/pterodactyl/wings/loggers/cli"
 	"github.com/pterodactyl/wings/remote"
 	"github.com/pterodactyl/wings/router"
+	"github.com/pterodactyl/wings/router/tokens"
 	"github.com/pterodactyl/wings/server"
 	"github.com/pterodactyl/wings/sftp"
 	"github.com/pterodactyl/wings/system"
@@ -158,6 +161,21 @@ func rootCmdRun(cmd *cobra.Command, _ []string) {
 		log.WithField("error", err).Error("failed to retrieve locally cached server states from disk, assuming all servers in offline state")
 	}
 
+	persisted, err := manager.ReadServerState()
+	if err != nil {
+		log.WithField("error", err).Error("failed to retrieve persisted server state from disk, crash history of servers has been reset")
+	}
+
+	autoStart, err := manager.ReadAutoStart()
+	if err != nil {
+		log.WithField("error", err).Error("failed to retrieve server auto start settings from disk, returning all servers to their previous state")
+	}
+
+	// Cancelled once Wings receives a signal telling it to shut down, at which point
+	// the configured shutdown behavior is applied to all the servers.
+	shutdownCtx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	ticker := time.NewTicker(time.Minute)
 	// Every minute, write the current server states to the disk to allow for a more
 	// seamless hard-reboot process in which wings will re-sync server states based
@@ -169,13 +187,25 @@ func rootCmdRun(cmd *cobra.Command, _ []string) {
 				if err := manager.PersistStates(); err != nil {
 					log.WithField("error", err).Warn("failed to persist server states to disk")
 				}
-			case <-cmd.Context().Done():
+				if err := manager.PersistServerState(); err != nil {
+					log.WithField("error", err).Warn("failed to persist server crash history to disk")
+				}
+			case <-shutdownCtx.Done():
 				ticker.Stop()
 				return
 			}
 		}
 	}()
 
+	if i := config.Get().System.Websocket.Introspection; i.URL != "" {
+		log.WithField("url", i.URL).Info("validating websocket tokens using introspection endpoint")
+		tokens.SetWebsocketValidator(tokens.NewIntrospectionValidator(i.URL, i.Token, time.Duration(i.Timeout)*time.Second))
+	}
+
+	// Keep an eye on the Docker daemon so that connected clients can be told when
+	// it goes away, rather than every action failing with an unhelpful error.
+	go environment.WatchDocker(shutdownCtx, 5*time.Second, manager.SetBackendAvailable)
+
 	// Create a new workerpool that limits us to 4 servers being bootstrapped at a time
 	// on Wings. This allows us to ensure the environment exists, write configurations,
 	// and reboot processes without causing a slow-down due to sequential booting.
@@ -215,13 +245,20 @@ func rootCmdRun(cmd *cobra.Command, _ []string) {
 				s.Log().WithField("error", err).Error("error checking server environment status")
 			}
 
+			// Servers return to the state they were previously in unless they have
+			// been set to always or never start when Wings boots.
+			start := st == environment.ProcessRunningState || st == environment.ProcessStartingState
+			if a, ok := autoStart[s.ID()]; ok {
+				start = a.ShouldStart(start)
+			}
+
 			// Check if the server was previously running. If so, attempt to start the server now so that Wings
 			// can pick up where it left off. If the environment does not exist at all, just create it and then allow
 			// the normal flow to execute.
 			//
 			// This does mean that booting wings after a catastrophic machine crash and wiping out the Docker images
 			// as a result will result in a slow boot.
-			if !r && (st == environment.ProcessRunningState || st == environment.ProcessStartingState) {
+			if !r && start {
 				if err := s.HandlePowerAction(server.PowerActionStart); err != nil {
 					s.Log().WithField("error", err).Warn("failed to return server to running state")
 				}
@@ -245,6 +282,10 @@ func rootCmdRun(cmd *cobra.Command, _ []string) {
 				s.Environment.SetState(environment.ProcessOfflineState)
 			}
 
+			if p, ok := persisted[s.ID()]; ok {
+				s.RestoreState(p, r)
+			}
+
 			if state := s.Environment.State(); state == environment.ProcessStartingState || state == environment.ProcessRunningState {
 				s.Log().Debug("re-syncing server configuration for already running server")
 				if err := s.Sync(); err != nil {
@@ -321,6 +362,32 @@ func rootCmdRun(cmd *cobra.Command, _ []string) {
 		TLSConfig: config.DefaultTLSConfig,
 	}
 
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-shutdownCtx.Done()
+		// Restore the default signal handling so that a second signal will kill
+		// Wings immediately if stopping the servers is taking too long.
+		stop()
+
+		log.Info("received shutdown signal, stopping wings")
+		// Persist the states before anything is stopped so that servers which were
+		// running are started back up when Wings boots.
+		if err := manager.PersistStates(); err != nil {
+			log.WithField("error", err).Warn("failed to persist server states to disk")
+		}
+		if err := manager.PersistServerState(); err != nil {
+			log.WithField("error", err).Warn("failed to persist server crash history to disk")
+		}
+		manager.Shutdown()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			log.WithField("error", err).Warn("failed to cleanly shutdown HTTP server")
+		}
+	}()
+
 	profile, _ := cmd.Flags().GetBool("pprof")
 	if profile {
 		if r, _ := cmd.Flags().GetInt("pprof-block-rate"); r > 0 {
@@ -356,24 +423,27 @@ func rootCmdRun(cmd *cobra.Command, _ []string) {
 			}
 		}()
 		// Start the main http server with TLS using autocert.
-		if err := s.ListenAndServeTLS("", ""); err != nil {
+		if err := s.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.WithFields(log.Fields{"auto_tls": true, "tls_hostname": tlshostname, "error": err}).Fatal("failed to configure HTTP server using auto-tls")
 		}
+		<-done
 		return
 	}
 
 	// Check if main http server should run with TLS. Otherwise, reset the TLS
 	// config on the server and then serve it over normal HTTP.
 	if api.Ssl.Enabled {
-		if err := s.ListenAndServeTLS(api.Ssl.CertificateFile, api.Ssl.KeyFile); err != nil {
+		if err := s.ListenAndServeTLS(api.Ssl.CertificateFile, api.Ssl.KeyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.WithFields(log.Fields{"auto_tls": false, "error": err}).Fatal("failed to configure HTTPS server")
 		}
+		<-done
 		return
 	}
 	s.TLSConfig = nil
-	if err := s.ListenAndServe(); err != nil {
+	if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.WithField("error", err).Fatal("failed to configure HTTP server")
 	}
+	<-done
 }
 
 // Reads the configuration from the disk and then sets up the global singleton
@@ -202,13 +202,83 @@ type SystemConfiguration struct {
 	// The number of lines to send when a server connects to the websocket.
 	WebsocketLogCount int `default:"150" yaml:"websocket_log_count"`
 
+	LogCompression LogCompression `yaml:"log_compression"`
+
 	Sftp SftpConfiguration `yaml:"sftp"`
 
 	CrashDetection CrashDetection `yaml:"crash_detection"`
 
+	// PersistState writes the crash history of each server to the disk, such as
+	// the details of its last crash and how many times in a row it has been
+	// restarted after crashing, so that it is kept when Wings restarts. Without
+	// this a server in a crash loop has its counter reset by restarting Wings.
+	PersistState bool `default:"false" yaml:"persist_state"`
+
+	// DuplicatePowerActionWindow is the number of seconds after a power action is
+	// started during which the same action sent to the server again is ignored
+	// while the first one is still being processed, such as when a user presses
+	// the restart button twice. Set to 0 to disable this.
+	DuplicatePowerActionWindow int `default:"10" yaml:"duplicate_power_action_window"`
+
+	// RestartDelay is the number of seconds to wait between a server stopping and
+	// being started again when it is restarted. Some games take a moment to let go
+	// of their port once the process exits, and starting again straight away fails
+	// because the port is still in use. Set to 0 to start again straight away.
+	RestartDelay int `default:"2" yaml:"restart_delay"`
+
+	// DebugLoggingDuration is the longest time in seconds debug logging can be
+	// turned on for a single server before it turns itself off again. This is also
+	// how long it stays on for when no duration is given.
+	DebugLoggingDuration int `default:"900" yaml:"debug_logging_duration"`
+
+	// StatusDebounce is the number of milliseconds over which rapid changes to the
+	// status of a server are collapsed into a single status event, such as when a
+	// server is stuck in a crash loop. The first change is always sent straight
+	// away, any changes after it within the window are sent once the window ends
+	// as the final status along with a summary of the states that were skipped.
+	// Set to 0 to send every change as it happens.
+	StatusDebounce int `default:"0" yaml:"status_debounce"`
+
+	// ShutdownBehavior determines what happens to running servers when Wings is
+	// shut down cleanly. The following values are supported:
+	//
+	//   keep-running  - Servers are left running in their containers and Wings will
+	//                   re-attach to them when it next boots. Console output sent
+	//                   while Wings is offline will only be available from the
+	//                   container logs.
+	//   graceful-stop - Every running server is sent its stop command and given the
+	//                   same amount of time to stop as a normal stop power action
+	//                   before being killed. This can delay Wings shutting down for
+	//                   as long as the slowest server takes to stop.
+	//   kill          - Every running server is killed immediately. This is the
+	//                   fastest option but servers will not have a chance to save
+	//                   any data.
+	//
+	// Server states are persisted before any servers are stopped, so a server that
+	// was running before Wings shut down is started again when Wings next boots.
+	ShutdownBehavior string `default:"keep-running" yaml:"shutdown_behavior"`
+
+	ZombieDetection ZombieDetection `yaml:"zombie_detection"`
+
+	// VerifyRunningState inspects the container of a running server each time its
+	// resource usage is collected, and closes the attach stream if the process in
+	// the container has exited without Wings noticing yet. The server is then
+	// handled the same as any other exit, including crash detection.
+	VerifyRunningState bool `default:"false" yaml:"verify_running_state"`
+
+	StartupTimeout StartupTimeout `yaml:"startup_timeout"`
+
+	ConsoleBacklog ConsoleBacklog `yaml:"console_backlog"`
+
+	CommandAudit CommandAudit `yaml:"command_audit"`
+
+	SecretMasking SecretMasking `yaml:"secret_masking"`
+
 	Backups Backups `yaml:"backups"`
 
 	Transfers Transfers `yaml:"transfers"`
+
+	Websocket Websocket `yaml:"websocket"`
 }
 
 type CrashDetection struct {
@@ -224,6 +294,217 @@ type CrashDetection struct {
 	// to be automatically restarted, this value is used to prevent servers from
 	// becoming stuck in a boot-loop after multiple consecutive crashes.
 	Timeout int `default:"60" json:"timeout"`
+
+	// ClearDetailsAfter is the amount of time in seconds a server must remain running
+	// after being started before the details of its last crash are cleared. Set to 0 to
+	// keep the last crash details until the next crash occurs.
+	ClearDetailsAfter int `default:"300" yaml:"clear_details_after"`
+}
+
+// StartupTimeout controls what happens to a server that remains in the starting
+// state for too long, usually because the startup detection configured for its
+// egg never matches any console output.
+type StartupTimeout struct {
+	// Timeout is the maximum amount of time in seconds a server can remain in the
+	// starting state. This is disabled by default, set to 0 to allow a server to
+	// remain starting forever.
+	Timeout int `default:"0" yaml:"timeout"`
+
+	// Action is what is done to a server once it reaches the timeout. This can be
+	// "running" to assume the server started and mark it as running, "stop" to
+	// stop the server, "kill" to kill the server's container straight away, or
+	// "warn" to only send a warning and leave the server starting.
+	//
+	// Both the timeout and the action can be overridden for each server.
+	Action string `default:"running" yaml:"action"`
+}
+
+// SecretMasking redacts secrets from the console output of servers before it is
+// sent to anyone watching the console or kept in the console backlog, such as
+// API keys or passwords printed by a game server when it boots. Output written
+// to the server's own log files is not changed.
+type SecretMasking struct {
+	// Patterns are regular expressions matching secrets in console output, the
+	// entire match is replaced. Patterns that cannot be compiled are ignored.
+	Patterns []string `yaml:"patterns"`
+
+	// Values are known secrets that are replaced wherever they appear in console
+	// output, without needing to be written as a regular expression.
+	Values []string `yaml:"values"`
+
+	// Replacement is the text secrets are replaced with.
+	Replacement string `default:"***" yaml:"replacement"`
+}
+
+// ConsoleBacklog controls the recent console output kept in memory for each
+// server, which is sent to clients when they ask for the server logs.
+//
+// The backlog is limited by both the number of lines and the total size of those
+// lines, and the oldest lines are removed once either limit is reached. This means
+// a server that outputs a small number of very long lines keeps fewer lines than
+// the line limit would otherwise allow. A single line larger than the byte limit
+// is truncated.
+type ConsoleBacklog struct {
+	// Lines is the maximum number of lines kept for each server. Set to 0 to
+	// disable the backlog, in which case the logs are always read from Docker.
+	Lines int `default:"500" yaml:"lines"`
+
+	// Bytes is the maximum total size in bytes of the lines kept for each server.
+	Bytes int `default:"262144" yaml:"bytes"`
+
+	// StartupLines and StartupBytes replace the limits above while a server is
+	// starting, since many servers write a large amount of output when they boot
+	// and then very little once they are running. Once the server is running the
+	// backlog is shrunk back down to the normal limits, keeping the most recent
+	// lines. Set to 0 to use the normal limits while starting.
+	StartupLines int `default:"0" yaml:"startup_lines"`
+	StartupBytes int `default:"0" yaml:"startup_bytes"`
+
+	// NodeBytes is the maximum total size in bytes of the backlogs of every server
+	// on the node combined. Once it is exceeded the oldest lines are removed from
+	// the servers that have gone the longest without any console output, and logs
+	// for those servers are read from Docker until they next start. Set to 0 to
+	// only limit the size of each server's backlog.
+	NodeBytes int64 `default:"0" yaml:"node_bytes"`
+}
+
+// CommandAudit controls the log of console commands sent to each server, which
+// records who sent each command and when.
+type CommandAudit struct {
+	// Entries is the number of recent commands kept in memory for each server.
+	Entries int `default:"100" yaml:"entries"`
+
+	// Persist writes every command to an append-only file for the server so the
+	// log survives Wings being restarted. A new file is started each day, and the
+	// most recent entries are loaded back into memory when the log is first used.
+	Persist bool `default:"false" yaml:"persist"`
+
+	// Directory is where the audit files are written, with a subdirectory for
+	// each server. Defaults to an "audit" directory in the log directory.
+	Directory string `yaml:"directory"`
+
+	// Retention is the number of days audit files are kept for before they are
+	// removed. Set to 0 to keep them forever.
+	Retention int `default:"90" yaml:"retention"`
+}
+
+// CommandRateLimit limits how quickly console commands can be sent to a server
+// over the websocket.
+type CommandRateLimit struct {
+	// Limit is the number of commands that can be sent within each period. Set to
+	// 0 to disable the limit.
+	Limit uint64 `default:"0" yaml:"limit"`
+
+	// Period is the length of each period in seconds.
+	Period int `default:"1" yaml:"period"`
+
+	// PerUser shares the limit between all the connections a user has open to a
+	// server, rather than each connection having its own limit. Without this a
+	// user can get around the limit by opening more connections.
+	PerUser bool `default:"false" yaml:"per_user"`
+
+	// Profiles replace the limit and period above during certain times of the
+	// day, such as using a much stricter limit overnight when only automation is
+	// expected to be sending commands. The first profile containing the current
+	// time is used, and the limit above is used outside of every profile.
+	//
+	// The start and end of each profile are a time of day in the timezone Wings
+	// is configured to use (see the "timezone" option), not UTC. A profile that
+	// ends before it starts runs over midnight, for example:
+	//
+	//   profiles:
+	//     - name: quiet-hours
+	//       start: "02:00"
+	//       end: "06:00"
+	//       limit: 5
+	//       period: 60
+	Profiles []RateLimitProfile `yaml:"profiles"`
+}
+
+// RateLimitProfile is a rate limit that is only used during part of the day.
+type RateLimitProfile struct {
+	Name string `yaml:"name"`
+
+	// Start and End are the time of day the profile is used between, formatted as
+	// "15:04". The profile is used from the start, up until but not including the
+	// end.
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+
+	// Limit and Period work the same as they do for the default limit, a limit of
+	// 0 removes the limit while the profile is in use.
+	Limit  uint64 `yaml:"limit"`
+	Period int    `default:"1" yaml:"period"`
+}
+
+// Contains returns true if the time of day of t is within the profile. Profiles
+// with a start or end that cannot be parsed never contain any time.
+func (p RateLimitProfile) Contains(t time.Time) bool {
+	start, err := time.Parse("15:04", p.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", p.End)
+	if err != nil {
+		return false
+	}
+	minutes := func(t time.Time) int { return t.Hour()*60 + t.Minute() }
+	now, from, to := minutes(t), minutes(start), minutes(end)
+	if from <= to {
+		return now >= from && now < to
+	}
+	// The profile runs over midnight.
+	return now >= from || now < to
+}
+
+// At returns the name of the profile in use at the given time, along with its
+// limit and period. The name is empty when the default limit is in use. The time
+// should already be in the timezone Wings is configured to use.
+func (l CommandRateLimit) At(t time.Time) (string, uint64, time.Duration) {
+	name, limit, period := "", l.Limit, l.Period
+	for _, p := range l.Profiles {
+		if p.Contains(t) {
+			name, limit, period = p.Name, p.Limit, p.Period
+			if name == "" {
+				name = p.Start + "-" + p.End
+			}
+			break
+		}
+	}
+	if period <= 0 {
+		period = 1
+	}
+	return name, limit, time.Duration(period) * time.Second
+}
+
+// The supported values for StartupTimeout.Action.
+const (
+	StartupTimeoutRunning = "running"
+	StartupTimeoutStop    = "stop"
+	StartupTimeoutKill    = "kill"
+	StartupTimeoutWarn    = "warn"
+)
+
+// The supported values for SystemConfiguration.ShutdownBehavior.
+const (
+	ShutdownKeepRunning  = "keep-running"
+	ShutdownGracefulStop = "graceful-stop"
+	ShutdownKill         = "kill"
+)
+
+type ZombieDetection struct {
+	// Enabled sets if the containers for running servers should be periodically inspected
+	// for zombie (defunct) processes. This is disabled by default since every inspection
+	// is an additional call to the Docker daemon for each running server.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Interval is the amount of time in seconds between each inspection of a running
+	// server container.
+	Interval int `default:"60" yaml:"interval"`
+
+	// Threshold is the number of zombie processes at which a warning will be sent to the
+	// server console. The current count is always reported in the server resource usage.
+	Threshold int `default:"10" yaml:"threshold"`
 }
 
 type Backups struct {
@@ -257,6 +538,355 @@ type Transfers struct {
 	DownloadLimit int `default:"0" yaml:"download_limit"`
 }
 
+type Websocket struct {
+	// MaxCommandLength is the maximum length, in bytes, of a single console command
+	// sent over the websocket. Commands longer than this are rejected rather than
+	// being passed along to the server process.
+	//
+	// Set to 0 to disable the length check entirely.
+	MaxCommandLength int `default:"4096" yaml:"max_command_length"`
+
+	CommandRateLimit CommandRateLimit `yaml:"command_rate_limit"`
+
+	// MaxListeners is the maximum number of listeners that can be registered for
+	// a single server's events, console output, or install output at once. Each
+	// websocket connection registers one of each, so this is effectively a cap on
+	// the number of connections to a single server. Hitting this limit almost
+	// always indicates that listeners are not being cleaned up correctly.
+	//
+	// Set to 0 to remove the limit.
+	MaxListeners int `default:"100" yaml:"max_listeners"`
+
+	// MaxLogCount is the largest number of lines a client can request when asking
+	// for the recent console output of a server. Requests for more lines than this
+	// are clamped to this value. The number of lines sent when a client does not
+	// ask for a specific amount is controlled by websocket_log_count.
+	MaxLogCount int `default:"1000" yaml:"max_log_count"`
+
+	// MaxCommandCaptures is the maximum number of commands that can have their
+	// output captured for a single server at the same time, including commands
+	// waiting on a response line. Each capture keeps the output it collects in
+	// memory until it is completed.
+	MaxCommandCaptures int `default:"5" yaml:"max_command_captures"`
+
+	// MaxConsoleServers is the number of other servers a single connection can
+	// receive the console output of at the same time, since each of them adds
+	// listeners to that server.
+	MaxConsoleServers int `default:"10" yaml:"max_console_servers"`
+
+	// StatsHistory is the number of recent stats samples kept for each server.
+	// These are sent to a client as soon as it connects so that graphs can be
+	// drawn immediately, rather than waiting for new samples to arrive. This is
+	// capped at 300 samples.
+	//
+	// Set to 0 to disable sending recent stats on connect.
+	StatsHistory int `default:"30" yaml:"stats_history"`
+
+	// TrendSensitivity is how quickly, as a percentage of the average per minute,
+	// memory or CPU usage must be changing across the recent stats samples for it
+	// to be reported as rising or falling rather than stable. Lower values report
+	// smaller changes, but are more likely to report normal fluctuations as a trend.
+	TrendSensitivity float64 `default:"1" yaml:"trend_sensitivity"`
+
+	// ReconnectWindow is the number of seconds after a websocket connection closes
+	// that the client can reconnect using the reconnection token it was given,
+	// rather than sending its full token again. Each reconnection token can only
+	// be used once.
+	//
+	// Set to 0 to disable reconnection tokens.
+	ReconnectWindow int `default:"30" yaml:"reconnect_window"`
+
+	// MaxSessionDuration is the longest, in seconds, that a single websocket
+	// connection can stay open for regardless of when its token expires. Once it
+	// is reached the connection is closed and the client has to connect again. A
+	// token can set a shorter limit for itself with the "max_session_duration"
+	// claim, whichever is shorter is used.
+	//
+	// Set to 0 to only limit connections that have the claim set.
+	MaxSessionDuration int `default:"0" yaml:"max_session_duration"`
+
+	// DrainWindow is the default number of seconds over which the open websocket
+	// connections are closed when the node is drained before maintenance. The
+	// connections are closed evenly across the window rather than all at once so
+	// that clients do not all try to reconnect at the same time.
+	DrainWindow int `default:"60" yaml:"drain_window"`
+
+	// EventPermissions lists additional permissions a token must have to send or
+	// receive a given websocket event, keyed by the event name. These are checked
+	// on top of the permissions Wings already requires for an event. For example,
+	// the following requires a custom permission to receive stats:
+	//
+	//   event_permissions:
+	//     stats: ["websocket.stats"]
+	EventPermissions map[string][]string `yaml:"event_permissions"`
+
+	// ReadBufferSize and WriteBufferSize are the sizes, in bytes, of the buffers
+	// allocated for each websocket connection. Every connection holds on to both
+	// buffers for as long as it is open, so on nodes with a large number of open
+	// connections lowering these can noticeably reduce memory usage. Messages larger
+	// than the buffer are still sent and received, they just take more than one
+	// read or write to do so.
+	//
+	// Messages sent by clients are small, so the read buffer rarely needs to be
+	// larger than the default. A larger write buffer can help with throughput for
+	// servers that produce a lot of console output.
+	ReadBufferSize  int `default:"1024" yaml:"read_buffer_size"`
+	WriteBufferSize int `default:"4096" yaml:"write_buffer_size"`
+
+	// MaxMessageSize is the largest message in bytes a client can send over the
+	// websocket, no matter how many frames it is split into. A connection sending
+	// a larger message is closed. This must leave room for the largest console
+	// command along with the rest of the message. Set to 0 for no limit.
+	MaxMessageSize int64 `default:"32768" yaml:"max_message_size"`
+
+	// ConsoleBuffer is the number of lines of console output that can be waiting
+	// to be sent to a single connection. Each connection has its own buffer, so a
+	// client that cannot keep up only loses its own oldest lines and never holds
+	// up the output sent to anyone else. A larger buffer allows a connection to
+	// get through short bursts of output without losing any lines.
+	ConsoleBuffer int `default:"256" yaml:"console_buffer"`
+
+	// WriteTimeout is the number of seconds a single message can take to be
+	// written to a websocket connection before the connection is dropped. Without
+	// this a client that stops reading causes writes to it to block forever. Set
+	// to 0 to disable the timeout.
+	WriteTimeout int `default:"10" yaml:"write_timeout"`
+
+	// ServerBandwidth is the most bytes per second that can be sent across all
+	// the websocket connections to a single server. Once this is nearly used up
+	// lines of console output are dropped, and the connections are told that
+	// their console is being throttled. Status and stats events are always sent.
+	// Set to 0 to not limit the bandwidth.
+	ServerBandwidth int64 `default:"0" yaml:"server_bandwidth"`
+
+	// LogLevelPattern is the regular expression used to find the level of a line
+	// of console output when a client asks to only receive lines at or above a
+	// given level. The first capture group, or the entire match if there is none,
+	// must be the name of the level such as "debug" or "warn". When empty a pattern
+	// matching most common log formats is used.
+	LogLevelPattern string `yaml:"log_level_pattern"`
+
+	// QueueOfflineCommands controls what happens to a command sent to a server
+	// that is not running. By default the command is rejected with an error, when
+	// enabled the command is instead queued and sent once the server has started.
+	// Queued commands are dropped if the server fails to start.
+	QueueOfflineCommands bool `default:"false" yaml:"queue_offline_commands"`
+
+	// CommandEchoPrefix is put in front of commands that are echoed back to a
+	// connection that has asked for the commands it sends to be shown in the
+	// console, marking them as user input rather than output from the server.
+	CommandEchoPrefix string `default:"> " yaml:"command_echo_prefix"`
+
+	Compression WebsocketCompression `yaml:"compression"`
+
+	Keepalive WebsocketKeepalive `yaml:"keepalive"`
+
+	Introspection WebsocketIntrospection `yaml:"introspection"`
+
+	Exec WebsocketExec `yaml:"exec"`
+
+	Processes WebsocketProcesses `yaml:"processes"`
+
+	ReconnectHints WebsocketReconnectHints `yaml:"reconnect_hints"`
+
+	// CloseCodes controls what is done when a client closes its connection with
+	// each websocket close code, such as 1012 for a service restart. Codes that
+	// are not listed are logged at the debug level if they are a normal way for a
+	// connection to close, and as a warning otherwise.
+	//
+	//   close_codes:
+	//     1012:
+	//       log: info
+	//       event: true
+	CloseCodes map[int]WebsocketCloseHandling `yaml:"close_codes"`
+
+	ConsoleTimestamps WebsocketConsoleTimestamps `yaml:"console_timestamps"`
+
+	// EventMetadata is attached to every event sent by a server over the websocket
+	// under the "metadata" field, such as an ID for the node, so that integrations
+	// can match events up with other systems. Nothing is attached when empty.
+	EventMetadata map[string]string `yaml:"event_metadata"`
+
+	// TraceHeader is the name of a header on the request opening a websocket
+	// connection, such as "X-Request-Id", whose value is attached to the metadata
+	// of every server event sent to that connection as "trace_id".
+	TraceHeader string `yaml:"trace_header"`
+
+	// DeniedIPs are IP addresses and CIDR ranges, such as "203.0.113.0/24", that
+	// cannot open a websocket connection to any server on the node. The address
+	// of the client is only taken from the X-Forwarded-For header when the request
+	// came from one of the api.trusted_proxies, otherwise the address of the
+	// connection itself is used so that the header cannot be spoofed to get
+	// around the list.
+	DeniedIPs []string `yaml:"denied_ips"`
+}
+
+// WebsocketCloseHandling is what is done when a client closes its connection
+// with a specific close code.
+type WebsocketCloseHandling struct {
+	// Log is the level the close is logged at, along with the reason the client
+	// gave. This can be "debug", "info", "warn", or "none" to not log it at all.
+	Log string `yaml:"log"`
+
+	// Event publishes a "websocket closed" event for the server with the close
+	// code and reason, so that anyone else watching the server can see why the
+	// connection closed.
+	Event bool `yaml:"event"`
+}
+
+// WebsocketConsoleTimestamps configures the timestamp sent with each line of
+// console output to clients that connect using the console streams protocol.
+// These are the defaults for every connection, each connection can change them
+// for itself using the "set console timestamps" event.
+type WebsocketConsoleTimestamps struct {
+	// Enabled adds the time each line was sent as a third argument of the console
+	// output event, after the stream it was written to.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Format is either a Go time layout, or one of "rfc3339", "rfc3339nano",
+	// "unix" or "unix_milli".
+	Format string `default:"rfc3339" yaml:"format"`
+
+	// Timezone is the name of the timezone the timestamps are in, such as
+	// "Europe/London". Unknown timezones fall back to UTC.
+	Timezone string `default:"UTC" yaml:"timezone"`
+}
+
+// WebsocketIntrospection configures Wings to validate websocket tokens using a
+// remote endpoint rather than checking that they were signed by the Panel. This
+// is used by deployments with their own authentication service.
+type WebsocketIntrospection struct {
+	// URL is the endpoint tokens are sent to, when empty tokens are validated as
+	// JWTs signed by the Panel.
+	URL string `yaml:"url"`
+
+	// Token is sent to the endpoint as a Bearer token, this is optional.
+	Token string `yaml:"token"`
+
+	// Timeout is the number of seconds to wait for the endpoint to respond before
+	// the token is rejected.
+	Timeout int `default:"5" yaml:"timeout"`
+}
+
+// WebsocketExec configures the exec event, which allows operators to run a one
+// off command inside a server's container and see its output. Commands are run
+// directly rather than through a shell, and the token used must have the
+// "admin.websocket.exec" permission.
+type WebsocketExec struct {
+	// Enabled controls if the exec event can be used at all.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Allowlist is the name of every binary that can be run. Binaries must be on
+	// the PATH of the container and are matched exactly, so a path such as
+	// "/bin/ls" is only allowed if it is listed.
+	Allowlist []string `default:"[\"ls\", \"df\", \"du\", \"ps\", \"free\", \"uptime\"]" yaml:"allowlist"`
+
+	// Timeout is the number of seconds to wait for a command to finish before
+	// giving up on it.
+	Timeout int `default:"30" yaml:"timeout"`
+
+	// MaxOutput is the number of bytes of output sent back for a single command,
+	// any output after this is discarded.
+	MaxOutput int `default:"65536" yaml:"max_output"`
+}
+
+// WebsocketProcesses configures the event used to list the processes running in
+// a server's container along with the CPU and memory each of them is using. The
+// token used must have the "admin.websocket.processes" permission.
+type WebsocketProcesses struct {
+	// Enabled controls if processes can be listed at all, this is off by default
+	// since each listing has Docker run ps for the container.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Timeout is the number of seconds to wait for the listing before giving up.
+	Timeout int `default:"10" yaml:"timeout"`
+
+	// MaxProcesses is the number of processes sent back, the processes using the
+	// most CPU are sent if there are more than this.
+	MaxProcesses int `default:"100" yaml:"max_processes"`
+}
+
+// WebsocketReconnectHints configures Wings to send a JSON object as the reason of
+// the close frames it sends, telling the client why it was disconnected and if
+// and when it should reconnect. The object has the following fields:
+//
+//	code         the reason the connection was closed, see below
+//	reconnect    true if the client should connect again
+//	retry_after  the number of seconds to wait before reconnecting
+//	message      a description of the reason, left out if it does not fit
+//
+// The codes sent are "shutdown" when Wings is stopping, "drain" when the node is
+// being drained for maintenance, "session_expired" when the session duration
+// limit is reached, "too_many_connections" when the server has too many
+// listeners, "permissions_revoked" when the user is no longer allowed to connect,
+// and "server_deleted" when the server has been deleted. Clients are told not to
+// reconnect after the last two.
+type WebsocketReconnectHints struct {
+	// Enabled sends the hints, otherwise the reason is only the message as it
+	// always has been.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// RestartDelay is the number of seconds clients are told to wait before
+	// reconnecting after Wings shuts down.
+	RestartDelay int `default:"15" yaml:"restart_delay"`
+
+	// MaintenanceDelay is the number of seconds clients are told to wait before
+	// reconnecting after the node is drained.
+	MaintenanceDelay int `default:"60" yaml:"maintenance_delay"`
+
+	// BusyDelay is the number of seconds clients are told to wait before
+	// reconnecting when a server has too many connections.
+	BusyDelay int `default:"30" yaml:"busy_delay"`
+}
+
+// LogCompression configures compressing responses containing a large amount of
+// past log output, such as the server and installation logs. Log output tends to
+// compress extremely well. HTTP responses are compressed with gzip for clients
+// that accept it, and websocket messages are compressed with per-message deflate
+// for clients that support it, even if compression is otherwise disabled for the
+// websocket. Clients that do not support either are sent the logs as-is.
+type LogCompression struct {
+	Enabled bool `default:"true" yaml:"enabled"`
+
+	// Threshold is the minimum size in bytes of a response before it is compressed.
+	Threshold int `default:"4096" yaml:"threshold"`
+}
+
+// WebsocketKeepalive configures TCP keepalive on the connection underneath each
+// websocket, so that the operating system notices peers that disappeared without
+// closing the connection, and NAT mappings are not dropped while a console is
+// quiet. This is separate from the pings sent over the websocket itself.
+type WebsocketKeepalive struct {
+	// Enabled turns on TCP keepalive for websocket connections.
+	Enabled bool `default:"true" yaml:"enabled"`
+
+	// Idle is the number of seconds a connection must be idle before the first
+	// keepalive probe is sent.
+	Idle int `default:"60" yaml:"idle"`
+
+	// Interval is the number of seconds between each keepalive probe once they
+	// have started. This is only supported on Linux, elsewhere the idle time is
+	// used for both.
+	Interval int `default:"15" yaml:"interval"`
+}
+
+type WebsocketCompression struct {
+	// Enabled controls if per-message compression is offered to clients connecting
+	// to the websocket. Compression is only used if the client also supports it,
+	// and a client can opt out for its connection by connecting with
+	// "compression=false" in the query string.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Level is the compression level to use, between 1 (best speed) and 9 (best
+	// compression). Higher levels use noticeably more CPU for a fairly small gain
+	// on the short lines typically output by a server console.
+	Level int `default:"1" yaml:"level"`
+
+	// Threshold is the minimum size in bytes a message must be before it is compressed.
+	// Smaller messages compress poorly and are sent as-is to avoid wasting CPU time.
+	Threshold int `default:"512" yaml:"threshold"`
+}
+
 type ConsoleThrottles struct {
 	// Whether or not the throttler is enabled for this instance.
 	Enabled bool `json:"enabled" yaml:"enabled" default:"true"`
@@ -348,6 +978,7 @@ func Set(c *Configuration) {
 		_jwtAlgo = jwt.NewHS256([]byte(c.AuthenticationToken))
 	}
 	_config = c
+	updateSecretMask(c)
 	mu.Unlock()
 }
 
@@ -384,6 +1015,7 @@ func Get() *Configuration {
 func Update(callback func(c *Configuration)) {
 	mu.Lock()
 	callback(_config)
+	updateSecretMask(_config)
 	mu.Unlock()
 }
 
@@ -615,6 +1247,35 @@ func (sc *SystemConfiguration) GetStatesPath() string {
 	return path.Join(sc.RootDirectory, "/states.json")
 }
 
+// GetServerStatePath returns the location of the JSON file that keeps the crash
+// history of servers when PersistState is enabled.
+func (sc *SystemConfiguration) GetServerStatePath() string {
+	return path.Join(sc.RootDirectory, "/server_state.json")
+}
+
+// GetAutoStartPath returns the location of the JSON file that tracks whether
+// servers should be started when Wings boots.
+func (sc *SystemConfiguration) GetAutoStartPath() string {
+	return path.Join(sc.RootDirectory, "/auto_start.json")
+}
+
+// The locations loaded for GetLocation, keyed by the name of the timezone.
+var locations sync.Map
+
+// GetLocation returns the location of the timezone Wings is configured to use,
+// falling back to UTC if it cannot be loaded.
+func (sc *SystemConfiguration) GetLocation() *time.Location {
+	if loc, ok := locations.Load(sc.Timezone); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(sc.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	locations.Store(sc.Timezone, loc)
+	return loc
+}
+
 // ConfigureTimezone sets the timezone data for the configuration if it is
 // currently missing. If a value has been set, this functionality will only run
 // to validate that the timezone being used is valid.
@@ -78,6 +78,19 @@ type DockerConfiguration struct {
 	// software such as the JVM not staying below the maximum memory limit.
 	Overhead Overhead `json:"overhead" yaml:"overhead"`
 
+	// SeparateStderr creates server containers without a TTY so that output written
+	// to stderr can be told apart from stdout, and marked as such for websocket
+	// clients that ask for it. Some programs change their output, such as dropping
+	// colors, when they are not attached to a TTY. Changes to this take effect the
+	// next time each server is started.
+	SeparateStderr bool `default:"false" json:"separate_stderr" yaml:"separate_stderr"`
+
+	// ReportHealth includes the status of the container health check, such as
+	// "healthy" or "unhealthy", as a second argument in the status sent to
+	// websocket clients while a server is running. This only applies to server
+	// images that define a HEALTHCHECK, the status is not sent for any others.
+	ReportHealth bool `default:"false" json:"report_health" yaml:"report_health"`
+
 	UsePerformantInspect bool `default:"true" json:"use_performant_inspect" yaml:"use_performant_inspect"`
 
 	// Sets the user namespace mode for the container when user namespace remapping option is
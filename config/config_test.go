@@ -0,0 +1,90 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestCommandRateLimit_At(t *testing.T) {
+	g := Goblin(t)
+
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("15:04", clock)
+		return t
+	}
+
+	g.Describe("RateLimitProfile#Contains", func() {
+		g.It("contains times between the start and end", func() {
+			p := RateLimitProfile{Start: "02:00", End: "06:00"}
+			g.Assert(p.Contains(at("02:00"))).IsTrue()
+			g.Assert(p.Contains(at("05:59"))).IsTrue()
+			g.Assert(p.Contains(at("06:00"))).IsFalse()
+			g.Assert(p.Contains(at("01:59"))).IsFalse()
+		})
+
+		g.It("runs over midnight when the end is before the start", func() {
+			p := RateLimitProfile{Start: "22:00", End: "06:00"}
+			g.Assert(p.Contains(at("23:30"))).IsTrue()
+			g.Assert(p.Contains(at("00:15"))).IsTrue()
+			g.Assert(p.Contains(at("12:00"))).IsFalse()
+		})
+
+		g.It("never contains a time if it cannot be parsed", func() {
+			p := RateLimitProfile{Start: "2am", End: "06:00"}
+			g.Assert(p.Contains(at("03:00"))).IsFalse()
+		})
+	})
+
+	g.Describe("CommandRateLimit#At", func() {
+		l := CommandRateLimit{
+			Limit:  10,
+			Period: 1,
+			Profiles: []RateLimitProfile{
+				{Name: "quiet-hours", Start: "02:00", End: "06:00", Limit: 2, Period: 60},
+			},
+		}
+
+		g.It("uses the default limit outside of every profile", func() {
+			name, limit, period := l.At(at("12:00"))
+			g.Assert(name).Equal("")
+			g.Assert(limit).Equal(uint64(10))
+			g.Assert(period).Equal(time.Second)
+		})
+
+		g.It("uses the profile containing the time", func() {
+			name, limit, period := l.At(at("03:00"))
+			g.Assert(name).Equal("quiet-hours")
+			g.Assert(limit).Equal(uint64(2))
+			g.Assert(period).Equal(time.Minute)
+		})
+	})
+}
+
+func TestGetSecretMask(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("GetSecretMask", func() {
+		g.It("is compiled when the configuration is set or updated", func() {
+			c := &Configuration{AuthenticationToken: "test"}
+			c.System.SecretMasking = SecretMasking{Values: []string{"hunter2"}, Replacement: "***"}
+			Set(c)
+
+			m := GetSecretMask()
+			g.Assert(string(m.Pattern.ReplaceAllLiteral([]byte("pass hunter2"), m.Replacement))).Equal("pass ***")
+			g.Assert(GetSecretMask() == m).IsTrue()
+
+			Update(func(c *Configuration) {
+				c.System.SecretMasking.Values = []string{"p4ss.word"}
+			})
+			m = GetSecretMask()
+			g.Assert(string(m.Pattern.ReplaceAllLiteral([]byte("hunter2 p4ss.word"), m.Replacement))).Equal("hunter2 ***")
+		})
+
+		g.It("has no pattern when nothing is configured", func() {
+			Set(&Configuration{AuthenticationToken: "test"})
+			g.Assert(GetSecretMask().Pattern == nil).IsTrue()
+		})
+	})
+}
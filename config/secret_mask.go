@@ -0,0 +1,78 @@
+package config
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/apex/log"
+)
+
+// SecretMask is the compiled form of the SecretMasking configuration. It is only
+// compiled again when the configuration is set or updated with different secret
+// masking values, so that masking a line of output does not need to read the
+// configuration.
+type SecretMask struct {
+	// Pattern matches every configured secret, or is nil if there is nothing to
+	// mask.
+	Pattern     *regexp.Regexp
+	Replacement []byte
+
+	source SecretMasking
+}
+
+// Holds the *SecretMask for the current configuration.
+var _secretMask atomic.Value
+
+// GetSecretMask returns the compiled secret masking configuration. This does not
+// take any locks, so it is safe to call for every line of console output.
+func GetSecretMask() *SecretMask {
+	m, _ := _secretMask.Load().(*SecretMask)
+	if m == nil {
+		return &SecretMask{}
+	}
+	return m
+}
+
+// updateSecretMask compiles the secret masking configuration if it has changed
+// since it was last compiled. This must be called while holding the lock for the
+// configuration.
+func updateSecretMask(c *Configuration) {
+	cfg := c.System.SecretMasking
+	if m, ok := _secretMask.Load().(*SecretMask); ok && reflect.DeepEqual(m.source, cfg) {
+		return
+	}
+	_secretMask.Store(&SecretMask{
+		Pattern:     compileSecretMask(cfg.Patterns, cfg.Values),
+		Replacement: []byte(cfg.Replacement),
+		source:      SecretMasking{Patterns: append([]string(nil), cfg.Patterns...), Values: append([]string(nil), cfg.Values...), Replacement: cfg.Replacement},
+	})
+}
+
+// compileSecretMask combines all the configured patterns and values into a
+// single expression so that each line of output is only scanned once no matter
+// how many secrets are configured. A nil expression is returned if there is
+// nothing to mask.
+func compileSecretMask(patterns []string, values []string) *regexp.Regexp {
+	var parts []string
+	for _, p := range patterns {
+		if p == "" {
+			continue
+		}
+		if _, err := regexp.Compile(p); err != nil {
+			log.WithFields(log.Fields{"pattern": p, "error": err}).Warn("config: invalid secret masking pattern, it will be ignored")
+			continue
+		}
+		parts = append(parts, "(?:"+p+")")
+	}
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, regexp.QuoteMeta(v))
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(strings.Join(parts, "|"))
+}
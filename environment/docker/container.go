@@ -2,20 +2,24 @@ package docker
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"emperror.dev/errors"
 	"github.com/apex/log"
-	"github.com/buger/jsonparser"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/goccy/go-json"
 
 	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/environment"
@@ -56,6 +60,14 @@ func (e *Environment) Attach(ctx context.Context) error {
 		Stream: true,
 	}
 
+	// Containers created without a TTY send stdout and stderr multiplexed over
+	// the same stream, which has to be split apart again when it is read.
+	tty, healthcheck := true, false
+	if c, err := e.ContainerInspect(ctx); err == nil && c.Config != nil {
+		tty = c.Config.Tty
+		healthcheck = hasHealthcheck(c.Config)
+	}
+
 	// Set the stream again with the container.
 	if st, err := e.client.ContainerAttach(ctx, e.Id, opts); err != nil {
 		return errors.WrapIf(err, "environment/docker: error while attaching to container")
@@ -73,8 +85,16 @@ func (e *Environment) Attach(ctx context.Context) error {
 		defer func() {
 			e.SetState(environment.ProcessOfflineState)
 			e.SetStream(nil)
+			e.health.Store("")
 		}()
 
+		if config.Get().System.ZombieDetection.Enabled {
+			go e.pollZombieProcesses(pollCtx)
+		}
+		if healthcheck && config.Get().Docker.ReportHealth {
+			go e.pollHealth(pollCtx)
+		}
+
 		go func() {
 			if err := e.pollResources(pollCtx); err != nil {
 				if !errors.Is(err, context.Canceled) {
@@ -85,11 +105,9 @@ func (e *Environment) Attach(ctx context.Context) error {
 			}
 		}()
 
-		if err := system.ScanReader(e.stream.Reader, func(v []byte) {
-			e.logCallbackMx.Lock()
-			defer e.logCallbackMx.Unlock()
-			e.logCallback(v)
-		}); err != nil && err != io.EOF {
+		// The stream is closed from outside of this goroutine when the container
+		// is found to have exited without the stream ending.
+		if err := e.scanOutput(tty); err != nil && err != io.EOF && !errors.Is(err, net.ErrClosed) {
 			log.WithField("error", err).WithField("container_id", e.Id).Warn("error processing scanner line in console output")
 			return
 		}
@@ -98,6 +116,51 @@ func (e *Environment) Attach(ctx context.Context) error {
 	return nil
 }
 
+// scanOutput passes each line of output from the attached stream to the log
+// callback until the stream is closed. If the container is running without a
+// TTY the stream is demultiplexed so that the callback receives the stream
+// each line was written to.
+func (e *Environment) scanOutput(tty bool) error {
+	if tty {
+		return system.ScanReader(e.stream.Reader, e.logLine(environment.Stdout))
+	}
+	return scanMultiplexed(e.stream.Reader, e.logLine(environment.Stdout), e.logLine(environment.Stderr))
+}
+
+// scanMultiplexed demultiplexes a stream of output from Docker, passing each
+// line written to stdout and stderr to the matching callback until the stream
+// is closed.
+func scanMultiplexed(r io.Reader, stdoutFn func([]byte), stderrFn func([]byte)) error {
+	stdout, stdoutW := io.Pipe()
+	stderr, stderrW := io.Pipe()
+	var wg sync.WaitGroup
+	scan := func(r *io.PipeReader, fn func([]byte)) {
+		defer wg.Done()
+		// Closing the reader with the error causes the copy below to stop, rather
+		// than blocking forever on a pipe that is no longer being read.
+		_ = r.CloseWithError(system.ScanReader(r, fn))
+	}
+	wg.Add(2)
+	go scan(stdout, stdoutFn)
+	go scan(stderr, stderrFn)
+
+	_, err := stdcopy.StdCopy(stdoutW, stderrW, r)
+	_ = stdoutW.CloseWithError(err)
+	_ = stderrW.CloseWithError(err)
+	wg.Wait()
+	return err
+}
+
+// logLine returns a function that passes a line of output written to the given
+// stream to the log callback.
+func (e *Environment) logLine(stream environment.OutputStream) func([]byte) {
+	return func(v []byte) {
+		e.logCallbackMx.Lock()
+		defer e.logCallbackMx.Unlock()
+		e.logCallback(v, stream)
+	}
+}
+
 // InSituUpdate performs an in-place update of the Docker container's resource
 // limits without actually making any changes to the operational state of the
 // container. This allows memory, cpu, and IO limitations to be adjusted on the
@@ -170,7 +233,7 @@ func (e *Environment) Create() error {
 		labels[key] = confLabels[key]
 	}
 	labels["Service"] = "Pterodactyl"
-	labels["ContainerType"] = "server_process"
+	labels["ContainerType"] = environment.ContainerTypeServer
 
 	conf := &container.Config{
 		Hostname:     e.Id,
@@ -179,7 +242,7 @@ func (e *Environment) Create() error {
 		AttachStdout: true,
 		AttachStderr: true,
 		OpenStdin:    true,
-		Tty:          true,
+		Tty:          !cfg.Docker.SeparateStderr,
 		ExposedPorts: a.Exposed(),
 		Image:        strings.TrimPrefix(e.meta.Image, "~"),
 		Env:          e.Configuration.EnvironmentVariables(),
@@ -312,9 +375,22 @@ func (e *Environment) SendCommand(c string) error {
 	return errors.Wrap(err, "environment/docker: could not write to container stream")
 }
 
+// Resize changes the dimensions of the TTY attached to the running container.
+func (e *Environment) Resize(ctx context.Context, rows, cols uint) error {
+	if !e.IsAttached() {
+		return errors.Wrap(ErrNotAttached, "environment/docker: cannot resize container tty")
+	}
+
+	err := e.client.ContainerResize(ctx, e.Id, types.ResizeOptions{Height: rows, Width: cols})
+	return errors.Wrap(err, "environment/docker: could not resize container tty")
+}
+
 // Readlog reads the log file for the server. This does not care if the server
 // is running or not, it will simply try to read the last X bytes of the file
 // and return them.
+//
+// If reading fails part of the way through the lines read up to that point are
+// returned along with the error, so that a caller can still use them.
 func (e *Environment) Readlog(lines int) ([]string, error) {
 	r, err := e.client.ContainerLogs(context.Background(), e.Id, types.ContainerLogsOptions{
 		ShowStdout: true,
@@ -326,13 +402,56 @@ func (e *Environment) Readlog(lines int) ([]string, error) {
 	}
 	defer r.Close()
 
+	// Logs for containers without a TTY are multiplexed in the same way as the
+	// attached stream, both streams are written to the same buffer here to keep
+	// the lines in order.
+	var src io.Reader = r
+	var copyErr error
+	if c, err := e.ContainerInspect(context.Background()); err == nil && c.Config != nil && !c.Config.Tty {
+		var buf bytes.Buffer
+		if _, err := stdcopy.StdCopy(&buf, &buf, r); err != nil {
+			copyErr = errors.WithStack(err)
+		}
+		src = &buf
+	}
+
 	var out []string
-	scanner := bufio.NewScanner(r)
+	scanner := bufio.NewScanner(src)
 	for scanner.Scan() {
 		out = append(out, scanner.Text())
 	}
+	if copyErr != nil {
+		return out, copyErr
+	}
+
+	return out, errors.WithStack(scanner.Err())
+}
+
+// PullImage pulls the latest version of the image configured for the environment
+// without making any changes to the container itself. The new image will be used
+// the next time the container is created.
+func (e *Environment) PullImage() error {
+	e.mu.RLock()
+	image := e.meta.Image
+	e.mu.RUnlock()
 
-	return out, nil
+	return e.ensureImageExists(image)
+}
+
+// ImageExists returns true if the image configured for the environment is
+// already present on the host.
+func (e *Environment) ImageExists(ctx context.Context) (bool, error) {
+	e.mu.RLock()
+	image := strings.TrimPrefix(e.meta.Image, "~")
+	e.mu.RUnlock()
+
+	if _, _, err := e.client.ImageInspectWithRaw(ctx, image); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	return true, nil
 }
 
 // Pulls the image from Docker. If there is an error while pulling the image
@@ -347,6 +466,16 @@ func (e *Environment) ensureImageExists(image string) error {
 	e.Events().Publish(environment.DockerImagePullStarted, "")
 	defer e.Events().Publish(environment.DockerImagePullCompleted, "")
 
+	if err := e.pullImage(image); err != nil {
+		e.Events().Publish(environment.DockerImagePullFailed, err.Error())
+		return err
+	}
+	return nil
+}
+
+// pullImage pulls the image from its registry, publishing the progress of the
+// pull as it goes.
+func (e *Environment) pullImage(image string) error {
 	// Images prefixed with a ~ are local images that we do not need to try and pull.
 	if strings.HasPrefix(image, "~") {
 		return nil
@@ -418,13 +547,23 @@ func (e *Environment) ensureImageExists(image string) error {
 	// I'm not sure what the best approach here is, but this will block execution until the image
 	// is done being pulled, which is what we need.
 	scanner := bufio.NewScanner(out)
+	tracker := newPullTracker(image)
 
 	for scanner.Scan() {
-		b := scanner.Bytes()
-		status, _ := jsonparser.GetString(b, "status")
-		progress, _ := jsonparser.GetString(b, "progress")
+		var m pullMessage
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			continue
+		}
+		// Errors such as a missing image or failed authentication can be sent as
+		// part of the stream after the pull has already started.
+		if m.Error != "" {
+			return errors.Errorf("environment/docker: failed to pull \"%s\" image for server: %s", image, m.Error)
+		}
 
-		e.Events().Publish(environment.DockerImagePullStatus, status+" "+progress)
+		e.Events().Publish(environment.DockerImagePullStatus, m.Status+" "+m.Progress)
+		if p, ok := tracker.update(m, time.Now()); ok {
+			e.Events().Publish(environment.DockerImagePullProgress, p)
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -51,10 +51,14 @@ type Environment struct {
 	emitter *events.Bus
 
 	logCallbackMx sync.Mutex
-	logCallback   func([]byte)
+	logCallback   func([]byte, environment.OutputStream)
 
 	// Tracks the environment state.
 	st *system.AtomicString
+
+	// The status of the container health check, empty if the container does not
+	// have one or it is not being reported.
+	health *system.AtomicString
 }
 
 // New creates a new base Docker environment. The ID passed through will be the
@@ -73,6 +77,7 @@ func New(id string, m *Metadata, c *environment.Configuration) (*Environment, er
 		meta:          m,
 		client:        cli,
 		st:            system.NewAtomicString(environment.ProcessOfflineState),
+		health:        system.NewAtomicString(""),
 		emitter:       events.NewBus(),
 	}
 
@@ -212,7 +217,7 @@ func (e *Environment) SetState(state string) {
 	}
 }
 
-func (e *Environment) SetLogCallback(f func([]byte)) {
+func (e *Environment) SetLogCallback(f func([]byte, environment.OutputStream)) {
 	e.logCallbackMx.Lock()
 	defer e.logCallbackMx.Unlock()
 
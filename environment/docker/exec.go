@@ -0,0 +1,63 @@
+package docker
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	"github.com/docker/docker/api/types"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+// Exec runs a command inside the running container and passes each line of its
+// output to the callback along with the stream it was written to, returning the
+// exit code of the command once it has finished. The command is run directly
+// rather than through a shell.
+//
+// Cancelling the context stops reading the output and returns straight away.
+// Docker does not provide a way to kill an exec'd process, so the command may
+// continue to run inside the container until it finishes on its own.
+func (e *Environment) Exec(ctx context.Context, cmd []string, fn func(line []byte, stream environment.OutputStream)) (int, error) {
+	created, err := e.client.ContainerExecCreate(ctx, e.Id, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "environment/docker: failed to create exec instance")
+	}
+
+	res, err := e.client.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if err != nil {
+		return 0, errors.Wrap(err, "environment/docker: failed to attach to exec instance")
+	}
+	defer res.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- scanMultiplexed(res.Reader, func(v []byte) {
+			fn(v, environment.Stdout)
+		}, func(v []byte) {
+			fn(v, environment.Stderr)
+		})
+	}()
+
+	select {
+	case <-ctx.Done():
+		// Closing the connection stops the output from being read, wait for that to
+		// finish so that the callback is never called after returning.
+		res.Close()
+		<-done
+		return 0, ctx.Err()
+	case err := <-done:
+		if err != nil {
+			return 0, errors.Wrap(err, "environment/docker: failed to read exec output")
+		}
+	}
+
+	inspect, err := e.client.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return 0, errors.Wrap(err, "environment/docker: failed to inspect exec instance")
+	}
+	return inspect.ExitCode, nil
+}
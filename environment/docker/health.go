@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+// The interval at which the container is inspected for its health status. The
+// status only changes as often as the health check runs, which is every 30
+// seconds by default.
+const healthPollInterval = time.Second * 10
+
+// Health returns the status of the container health check, one of "starting",
+// "healthy", or "unhealthy". This is empty if the container does not have a
+// health check, or the status is not being reported.
+func (e *Environment) Health() string {
+	return e.health.Load()
+}
+
+// hasHealthcheck returns true if the container has a health check configured,
+// either in its image or when it was created.
+func hasHealthcheck(c *container.Config) bool {
+	if c.Healthcheck == nil || len(c.Healthcheck.Test) == 0 {
+		return false
+	}
+	return c.Healthcheck.Test[0] != "NONE"
+}
+
+// pollHealth periodically inspects the container and emits an event whenever
+// the status of its health check changes, until the context is canceled.
+func (e *Environment) pollHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c, err := e.ContainerInspect(ctx)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					e.log().WithField("error", err).Warn("failed to inspect container for health status")
+				}
+				continue
+			}
+			var status string
+			if c.State != nil && c.State.Health != nil {
+				status = c.State.Health.Status
+			}
+			if e.health.Load() != status {
+				e.health.Store(status)
+				e.Events().Publish(environment.HealthChangeEvent, status)
+			}
+		}
+	}
+}
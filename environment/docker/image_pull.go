@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"math"
+	"time"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+// How often progress is published while layers are being downloaded or extracted.
+// Docker sends an update for every chunk of a layer it processes, which is far
+// more than any client needs.
+const pullProgressInterval = time.Millisecond * 250
+
+// pullMessage is a single line of the JSON stream returned by Docker while an
+// image is being pulled.
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	Progress       string `json:"progress"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+type layerProgress struct {
+	current int64
+	total   int64
+}
+
+// pullTracker keeps track of the download progress of each layer of an image
+// so that the overall progress of the pull can be reported.
+type pullTracker struct {
+	image     string
+	layers    map[string]*layerProgress
+	published time.Time
+}
+
+func newPullTracker(image string) *pullTracker {
+	return &pullTracker{image: image, layers: make(map[string]*layerProgress)}
+}
+
+// update records a message from Docker and returns the progress to publish. The
+// second value is false if nothing should be published for the message, which
+// is the case for most byte level updates so that they are only sent at the
+// pullProgressInterval. Any change in status is always published.
+func (t *pullTracker) update(m pullMessage, now time.Time) (environment.ImagePullProgress, bool) {
+	detail := m.ProgressDetail
+	if m.ID != "" {
+		switch m.Status {
+		case "Downloading":
+			if detail.Total > 0 {
+				t.layers[m.ID] = &layerProgress{current: detail.Current, total: detail.Total}
+			}
+		case "Download complete":
+			if l, ok := t.layers[m.ID]; ok {
+				l.current = l.total
+			}
+		}
+	}
+
+	p := environment.ImagePullProgress{
+		Image:   t.image,
+		Layer:   m.ID,
+		Status:  m.Status,
+		Current: detail.Current,
+		Total:   detail.Total,
+		Percent: t.percent(),
+	}
+	if detail.Total > 0 && now.Sub(t.published) < pullProgressInterval {
+		return p, false
+	}
+	t.published = now
+	return p, true
+}
+
+// percent returns the percentage of the image that has been downloaded, to one
+// decimal place. Layers that already exist locally are not included.
+func (t *pullTracker) percent() float64 {
+	var current, total int64
+	for _, l := range t.layers {
+		current += l.current
+		total += l.total
+	}
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(current)/float64(total)*1000) / 10
+}
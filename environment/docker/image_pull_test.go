@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestPullTracker(t *testing.T) {
+	g := Goblin(t)
+
+	message := func(id, status string, current, total int64) pullMessage {
+		m := pullMessage{ID: id, Status: status}
+		m.ProgressDetail.Current = current
+		m.ProgressDetail.Total = total
+		return m
+	}
+
+	g.Describe("pullTracker#update", func() {
+		g.It("reports the download progress across every layer", func() {
+			tr := newPullTracker("image")
+			now := time.Now()
+			tr.update(message("a", "Downloading", 50, 100), now)
+			p, _ := tr.update(message("b", "Downloading", 0, 300), now.Add(time.Second))
+			g.Assert(p.Percent).Equal(12.5)
+
+			p, ok := tr.update(message("a", "Download complete", 0, 0), now.Add(time.Second))
+			g.Assert(ok).IsTrue()
+			g.Assert(p.Layer).Equal("a")
+			g.Assert(p.Percent).Equal(25.0)
+		})
+
+		g.It("only publishes byte level updates at an interval", func() {
+			tr := newPullTracker("image")
+			now := time.Now()
+			_, ok := tr.update(message("a", "Downloading", 10, 100), now)
+			g.Assert(ok).IsTrue()
+			_, ok = tr.update(message("a", "Downloading", 20, 100), now.Add(time.Millisecond))
+			g.Assert(ok).IsFalse()
+			_, ok = tr.update(message("a", "Downloading", 30, 100), now.Add(pullProgressInterval))
+			g.Assert(ok).IsTrue()
+		})
+
+		g.It("always publishes status changes", func() {
+			tr := newPullTracker("image")
+			now := time.Now()
+			tr.update(message("a", "Downloading", 10, 100), now)
+			p, ok := tr.update(message("a", "Pull complete", 0, 0), now)
+			g.Assert(ok).IsTrue()
+			g.Assert(p.Status).Equal("Pull complete")
+		})
+	})
+}
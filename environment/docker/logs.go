@@ -0,0 +1,126 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+// Returned by a log writer to stop reading the logs once the callback has asked
+// for no more lines.
+var errStopLogs = errors.Sentinel("environment/docker: stop reading logs")
+
+// Logs reads the lines written by the container between since and until from the
+// log kept by Docker, passing each one to fn along with the time Docker recorded
+// it at. A zero until reads up to the latest line. Reading stops early if fn
+// returns false.
+//
+// Unlike Readlog this does not need to read from the end of the log, so a range
+// of output can be found by time even if a lot has been written since.
+func (e *Environment) Logs(ctx context.Context, since, until time.Time, fn func(environment.LogEntry) bool) error {
+	opts := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Since:      since.UTC().Format(time.RFC3339Nano),
+	}
+	if !until.IsZero() {
+		opts.Until = until.UTC().Format(time.RFC3339Nano)
+	}
+	r, err := e.client.ContainerLogs(ctx, e.Id, opts)
+	if err != nil {
+		return errors.Wrap(err, "environment/docker: failed to read container logs")
+	}
+	defer r.Close()
+
+	// Output from containers with a TTY is not multiplexed and is all treated as
+	// stdout, the same as the attached stream.
+	c, err := e.ContainerInspect(ctx)
+	if err != nil {
+		return err
+	}
+	if c.Config != nil && c.Config.Tty {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if !fn(parseLogLine(scanner.Bytes(), environment.Stdout)) {
+				return nil
+			}
+		}
+		return errors.WithStack(scanner.Err())
+	}
+
+	stdout := &logWriter{stream: environment.Stdout, fn: fn}
+	stderr := &logWriter{stream: environment.Stderr, fn: fn}
+	if _, err := stdcopy.StdCopy(stdout, stderr, r); err != nil {
+		if errors.Is(err, errStopLogs) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	if stdout.flush() {
+		stderr.flush()
+	}
+	return nil
+}
+
+// logWriter splits a single demultiplexed log stream into lines. Docker can
+// split a long line over multiple frames, so anything after the last newline is
+// kept until the rest of the line arrives.
+type logWriter struct {
+	stream environment.OutputStream
+	fn     func(environment.LogEntry) bool
+	buf    []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := w.buf[:i]
+		w.buf = w.buf[i+1:]
+		if !w.fn(parseLogLine(line, w.stream)) {
+			return len(p), errStopLogs
+		}
+	}
+	return len(p), nil
+}
+
+// flush passes on the last line of the stream if it did not end in a newline,
+// returning false if no more lines should be read.
+func (w *logWriter) flush() bool {
+	if len(w.buf) == 0 {
+		return true
+	}
+	line := w.buf
+	w.buf = nil
+	return w.fn(parseLogLine(line, w.stream))
+}
+
+// parseLogLine splits the timestamp Docker adds to the start of each line when
+// timestamps are requested from the rest of the line. The time is left as zero
+// if the line does not start with one.
+func parseLogLine(line []byte, stream environment.OutputStream) environment.LogEntry {
+	entry := environment.LogEntry{Stream: stream}
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if i := bytes.IndexByte(line, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, string(line[:i])); err == nil {
+			entry.Time = t
+			line = line[i+1:]
+		}
+	}
+	entry.Line = string(line)
+	return entry
+}
+
+var _ io.Writer = (*logWriter)(nil)
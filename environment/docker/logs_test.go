@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+func TestParseLogLine(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("parseLogLine", func() {
+		g.It("splits the timestamp from the line", func() {
+			entry := parseLogLine([]byte("2024-01-02T10:00:05.123456789Z [Server] Done (1.2s)!\r"), environment.Stderr)
+			g.Assert(entry.Time.Equal(time.Date(2024, 1, 2, 10, 0, 5, 123456789, time.UTC))).IsTrue()
+			g.Assert(entry.Stream).Equal(environment.Stderr)
+			g.Assert(entry.Line).Equal("[Server] Done (1.2s)!")
+		})
+
+		g.It("keeps lines without a timestamp", func() {
+			entry := parseLogLine([]byte("no timestamp here"), environment.Stdout)
+			g.Assert(entry.Time.IsZero()).IsTrue()
+			g.Assert(entry.Line).Equal("no timestamp here")
+		})
+	})
+}
+
+func TestLogWriter(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("logWriter", func() {
+		g.It("demultiplexes lines split over frames", func() {
+			var src bytes.Buffer
+			_, _ = stdcopy.NewStdWriter(&src, stdcopy.Stdout).Write([]byte("2024-01-02T10:00:00Z first "))
+			_, _ = stdcopy.NewStdWriter(&src, stdcopy.Stderr).Write([]byte("2024-01-02T10:00:01Z error\n"))
+			_, _ = stdcopy.NewStdWriter(&src, stdcopy.Stdout).Write([]byte("line\n2024-01-02T10:00:02Z last"))
+
+			var entries []environment.LogEntry
+			fn := func(e environment.LogEntry) bool {
+				entries = append(entries, e)
+				return true
+			}
+			stdout := &logWriter{stream: environment.Stdout, fn: fn}
+			stderr := &logWriter{stream: environment.Stderr, fn: fn}
+			_, err := stdcopy.StdCopy(stdout, stderr, &src)
+			g.Assert(err).IsNil()
+			g.Assert(stdout.flush()).IsTrue()
+
+			g.Assert(len(entries)).Equal(3)
+			g.Assert(entries[0].Stream).Equal(environment.Stderr)
+			g.Assert(entries[0].Line).Equal("error")
+			g.Assert(entries[1].Stream).Equal(environment.Stdout)
+			g.Assert(entries[1].Line).Equal("first line")
+			g.Assert(entries[2].Line).Equal("last")
+		})
+
+		g.It("stops once the callback returns false", func() {
+			w := &logWriter{stream: environment.Stdout, fn: func(environment.LogEntry) bool { return false }}
+			_, err := w.Write([]byte("one\ntwo\n"))
+			g.Assert(err).Equal(errStopLogs)
+		})
+	})
+}
@@ -298,7 +298,87 @@ func (e *Environment) Terminate(ctx context.Context, signal os.Signal) error {
 	if err := e.client.ContainerKill(ctx, e.Id, sig); err != nil && !client.IsErrNotFound(err) {
 		return errors.WithStack(err)
 	}
+
+	// Softer signals are used for graceful stops, which are given as long as the
+	// stop timeout allows to finish by WaitForStop, so only a kill is confirmed
+	// here. Docker accepting the signal does not mean the process actually exited,
+	// so check that the container stopped before reporting it as offline.
+	if isKillSignal(signal) {
+		if err := e.waitForExit(ctx, killConfirmTimeout); err != nil {
+			if err := e.forceRemove(ctx); err != nil {
+				return e.killFailed(err)
+			}
+		}
+	}
 	e.SetState(environment.ProcessOfflineState)
 
 	return nil
 }
+
+// isKillSignal returns true if the signal is SIGKILL, which is the only signal a
+// container cannot ignore.
+func isKillSignal(signal os.Signal) bool {
+	s, ok := signal.(syscall.Signal)
+	return ok && s == syscall.SIGKILL
+}
+
+// The amount of time to wait for a container to stop after it has been sent a
+// kill signal, or force removed, before assuming that did not work.
+var killConfirmTimeout = time.Second * 10
+
+// ErrContainerSurvivedKill is returned when a container is still running after
+// being sent a kill signal.
+var ErrContainerSurvivedKill = errors.Sentinel("environment/docker: container is still running after kill signal")
+
+// waitForExit polls the state of the container until it is no longer running,
+// returning an error if it is still running once the timeout has passed.
+func (e *Environment) waitForExit(ctx context.Context, timeout time.Duration) error {
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Millisecond * 250)
+	defer ticker.Stop()
+
+	for {
+		c, err := e.ContainerInspect(tctx)
+		if err != nil {
+			if client.IsErrNotFound(err) {
+				return nil
+			}
+			if tctx.Err() == nil {
+				return errors.WithStack(err)
+			}
+		} else if !c.State.Running {
+			return nil
+		}
+
+		select {
+		case <-tctx.Done():
+			return errors.WithStack(ErrContainerSurvivedKill)
+		case <-ticker.C:
+		}
+	}
+}
+
+// forceRemove escalates a kill that the container survived by having Docker
+// force remove the container, which kills it again and removes it. This is safe
+// since the container is created again whenever the server is started.
+func (e *Environment) forceRemove(ctx context.Context) error {
+	e.log().Warn("server container is still running after kill signal, forcing removal")
+	err := e.client.ContainerRemove(ctx, e.Id, types.ContainerRemoveOptions{Force: true})
+	if err != nil && !client.IsErrNotFound(err) {
+		return errors.WithStack(err)
+	}
+	return e.waitForExit(ctx, killConfirmTimeout)
+}
+
+// killFailed reports that a container could not be killed and moves the server
+// back into a running state since that is what the container is actually doing.
+// A kill failed event is published so that the server can tell anyone watching
+// the console.
+func (e *Environment) killFailed(err error) error {
+	e.log().WithField("error", err).Error("failed to kill server container, process is still running")
+	e.SetState(environment.ProcessRunningState)
+	e.Events().Publish(environment.KillFailedEvent, err.Error())
+	return err
+}
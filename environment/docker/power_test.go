@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/docker/docker/client"
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/events"
+	"github.com/pterodactyl/wings/system"
+)
+
+func TestIsKillSignal(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("isKillSignal", func() {
+		g.It("matches SIGKILL", func() {
+			g.Assert(isKillSignal(os.Kill)).IsTrue()
+			g.Assert(isKillSignal(syscall.SIGKILL)).IsTrue()
+		})
+
+		g.It("does not match the signals used for graceful stops", func() {
+			g.Assert(isKillSignal(syscall.SIGTERM)).IsFalse()
+			g.Assert(isKillSignal(syscall.SIGINT)).IsFalse()
+			g.Assert(isKillSignal(os.Interrupt)).IsFalse()
+		})
+	})
+}
+
+func TestEnvironment_Terminate(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Environment#Terminate", func() {
+		// newEnvironment returns an environment using a fake Docker API where the
+		// container keeps running after being killed. It only stops once it has been
+		// force removed, and only if removing it works.
+		newEnvironment := func(removeStops bool) (*Environment, *int32) {
+			var stopped, removed int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/json"):
+					w.Header().Set("Content-Type", "application/json")
+					if atomic.LoadInt32(&stopped) == 1 {
+						_, _ = w.Write([]byte(`{"State":{"Running":false}}`))
+						return
+					}
+					_, _ = w.Write([]byte(`{"State":{"Running":true}}`))
+				case r.Method == http.MethodDelete:
+					atomic.StoreInt32(&removed, 1)
+					if removeStops {
+						atomic.StoreInt32(&stopped, 1)
+					}
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(srv.URL, "http://")), client.WithVersion("1.41"))
+			g.Assert(err).IsNil()
+			return &Environment{
+				Id:      "container",
+				client:  cli,
+				st:      system.NewAtomicString(environment.ProcessRunningState),
+				emitter: events.NewBus(),
+			}, &removed
+		}
+
+		g.BeforeEach(func() {
+			config.Set(&config.Configuration{AuthenticationToken: "test"})
+			killConfirmTimeout = time.Millisecond * 500
+		})
+		g.After(func() {
+			killConfirmTimeout = time.Second * 10
+		})
+
+		g.It("force removes a container that survives the kill signal", func() {
+			e, removed := newEnvironment(true)
+			g.Assert(e.Terminate(context.Background(), os.Kill)).IsNil()
+			g.Assert(atomic.LoadInt32(removed)).Equal(int32(1))
+			g.Assert(e.State()).Equal(environment.ProcessOfflineState)
+		})
+
+		g.It("reports the kill failed if the container is still running", func() {
+			e, removed := newEnvironment(false)
+			ch := make(chan []byte, 8)
+			g.Assert(e.Events().On(ch)).IsNil()
+			defer e.Events().Off(ch)
+
+			err := e.Terminate(context.Background(), os.Kill)
+			g.Assert(errors.Is(err, ErrContainerSurvivedKill)).IsTrue()
+			g.Assert(atomic.LoadInt32(removed)).Equal(int32(1))
+			g.Assert(e.State()).Equal(environment.ProcessRunningState)
+
+			for {
+				select {
+				case b := <-ch:
+					if events.MustDecode(b).Topic == environment.KillFailedEvent {
+						return
+					}
+				case <-time.After(time.Second):
+					g.Fail("kill failed event was not published")
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,58 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+
+	"emperror.dev/errors"
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+// The columns requested from ps when listing the processes in a container.
+var processColumns = []string{"-eo", "pid,user,pcpu,pmem,rss,args"}
+
+// Processes returns the processes running inside the container. This uses the
+// Docker "top" API, which runs ps on the host, so it does not depend on anything
+// being installed inside the container.
+func (e *Environment) Processes(ctx context.Context) ([]environment.Process, error) {
+	top, err := e.client.ContainerTop(ctx, e.Id, processColumns)
+	if err != nil {
+		return nil, errors.Wrap(err, "environment/docker: failed to list container processes")
+	}
+	return parseProcesses(top)
+}
+
+// parseProcesses converts the output of ps into processes, finding each of the
+// columns by its title since the order is not guaranteed.
+func parseProcesses(top container.ContainerTopOKBody) ([]environment.Process, error) {
+	cols := map[string]int{}
+	for i, t := range top.Titles {
+		cols[t] = i
+	}
+	for _, t := range []string{"PID", "USER", "%CPU", "%MEM", "RSS", "COMMAND"} {
+		if _, ok := cols[t]; !ok {
+			return nil, errors.New("environment/docker: process listing is missing " + t + " column")
+		}
+	}
+
+	out := make([]environment.Process, 0, len(top.Processes))
+	for _, row := range top.Processes {
+		if len(row) != len(top.Titles) {
+			continue
+		}
+		var p environment.Process
+		p.PID, _ = strconv.Atoi(row[cols["PID"]])
+		p.User = row[cols["USER"]]
+		p.CPU, _ = strconv.ParseFloat(row[cols["%CPU"]], 64)
+		p.Memory, _ = strconv.ParseFloat(row[cols["%MEM"]], 64)
+		// ps reports the resident memory in KiB.
+		if rss, err := strconv.ParseUint(row[cols["RSS"]], 10, 64); err == nil {
+			p.MemoryRss = rss * 1024
+		}
+		p.Command = row[cols["COMMAND"]]
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,36 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	. "github.com/franela/goblin"
+)
+
+func TestParseProcesses(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("parseProcesses", func() {
+		g.It("reads each column by its title", func() {
+			processes, err := parseProcesses(container.ContainerTopOKBody{
+				Titles: []string{"USER", "PID", "%CPU", "%MEM", "RSS", "COMMAND"},
+				Processes: [][]string{
+					{"container", "12", "45.5", "2.1", "2048", "java -jar server.jar"},
+				},
+			})
+			g.Assert(err).IsNil()
+			g.Assert(len(processes)).Equal(1)
+			g.Assert(processes[0].PID).Equal(12)
+			g.Assert(processes[0].User).Equal("container")
+			g.Assert(processes[0].CPU).Equal(45.5)
+			g.Assert(processes[0].Memory).Equal(2.1)
+			g.Assert(processes[0].MemoryRss).Equal(uint64(2048 * 1024))
+			g.Assert(processes[0].Command).Equal("java -jar server.jar")
+		})
+
+		g.It("returns an error if a column is missing", func() {
+			_, err := parseProcesses(container.ContainerTopOKBody{Titles: []string{"PID", "STAT"}})
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+}
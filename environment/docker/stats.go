@@ -4,12 +4,15 @@ import (
 	"context"
 	"io"
 	"math"
+	"strings"
 	"time"
 
 	"emperror.dev/errors"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
 	"github.com/goccy/go-json"
 
+	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/environment"
 )
 
@@ -73,24 +76,147 @@ func (e *Environment) pollResources(ctx context.Context) error {
 				return nil
 			}
 
+			if config.Get().System.VerifyRunningState && !e.verifyRunning(ctx, e.IsRunning) {
+				return nil
+			}
+
 			if !v.PreRead.IsZero() {
 				uptime = uptime + v.Read.Sub(v.PreRead).Milliseconds()
 			}
 
-			st := environment.Stats{
-				Uptime:      uptime,
-				Memory:      calculateDockerMemory(v.MemoryStats),
-				MemoryLimit: v.MemoryStats.Limit,
-				CpuAbsolute: calculateDockerAbsoluteCpu(v.PreCPUStats, v.CPUStats),
-				Network:     environment.NetworkStats{},
-			}
+			e.Events().Publish(environment.ResourceEvent, convertDockerStats(v, uptime))
+		}
+	}
+}
 
-			for _, nw := range v.Networks {
-				st.Network.RxBytes += nw.RxBytes
-				st.Network.TxBytes += nw.TxBytes
-			}
+// verifyRunning checks that the container is actually running while the
+// environment is marked as running. The attach stream can take a moment to close
+// after the process in the container exits, and until it does the server would
+// otherwise keep reporting that it is running. If the container has stopped the
+// attach stream is closed and false is returned. The state is left to the attach
+// goroutine, which marks the server offline as it would for any other exit.
+func (e *Environment) verifyRunning(ctx context.Context, isRunning func(context.Context) (bool, error)) bool {
+	if e.st.Load() != environment.ProcessRunningState {
+		return true
+	}
+	running, err := isRunning(ctx)
+	if err != nil && !client.IsErrNotFound(err) {
+		// Leave the state alone if Docker could not be reached, the next sample
+		// will check again.
+		if !errors.Is(err, context.Canceled) {
+			e.log().WithField("error", err).Debug("failed to verify container is running")
+		}
+		return true
+	}
+	if running {
+		return true
+	}
+	e.log().Warn("container is no longer running but the server is marked as running, closing attach stream")
+	e.mu.RLock()
+	if e.stream != nil {
+		e.stream.Close()
+	}
+	e.mu.RUnlock()
+	return false
+}
 
-			e.Events().Publish(environment.ResourceEvent, st)
+// Stats returns the current resource usage of the container straight from
+// Docker, rather than waiting on the next value from the resource polling.
+// Docker takes a moment to respond to this since it needs two samples to
+// calculate the CPU usage.
+func (e *Environment) Stats(ctx context.Context) (environment.Stats, error) {
+	res, err := e.client.ContainerStats(ctx, e.Id, false)
+	if err != nil {
+		return environment.Stats{}, errors.Wrap(err, "environment/docker: failed to get container stats")
+	}
+	defer res.Body.Close()
+
+	var v types.StatsJSON
+	if err := json.NewDecoder(res.Body).Decode(&v); err != nil {
+		return environment.Stats{}, errors.Wrap(err, "environment/docker: failed to decode container stats")
+	}
+
+	uptime, err := e.Uptime(ctx)
+	if err != nil {
+		e.log().WithField("error", err).Warn("failed to calculate container uptime")
+	}
+	return convertDockerStats(v, uptime), nil
+}
+
+// convertDockerStats converts the stats returned by Docker into the stats that
+// are tracked for the environment.
+func convertDockerStats(v types.StatsJSON, uptime int64) environment.Stats {
+	st := environment.Stats{
+		Uptime:      uptime,
+		Memory:      calculateDockerMemory(v.MemoryStats),
+		MemoryLimit: v.MemoryStats.Limit,
+		CpuAbsolute: calculateDockerAbsoluteCpu(v.PreCPUStats, v.CPUStats),
+		Network:     environment.NetworkStats{},
+	}
+	st.MemoryCache, st.MemoryRss, st.MemoryMapped = calculateDockerMemoryBreakdown(v.MemoryStats)
+
+	for _, nw := range v.Networks {
+		st.Network.RxBytes += nw.RxBytes
+		st.Network.TxBytes += nw.TxBytes
+	}
+	return st
+}
+
+// ZombieProcessCount returns the number of zombie (defunct) processes currently
+// present in the container. This uses the Docker "top" API, so it does not need
+// anything special to exist inside the container itself.
+func (e *Environment) ZombieProcessCount(ctx context.Context) (int, error) {
+	top, err := e.client.ContainerTop(ctx, e.Id, []string{"-eo", "pid,stat"})
+	if err != nil {
+		return 0, errors.Wrap(err, "environment/docker: failed to list container processes")
+	}
+	col := -1
+	for i, t := range top.Titles {
+		if t == "STAT" {
+			col = i
+			break
+		}
+	}
+	if col < 0 {
+		return 0, errors.New("environment/docker: process listing is missing STAT column")
+	}
+	var count int
+	for _, p := range top.Processes {
+		if len(p) > col && strings.HasPrefix(p[col], "Z") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Periodically inspects the container for zombie processes and emits the count
+// found until the context provided is canceled.
+func (e *Environment) pollZombieProcesses(ctx context.Context) {
+	zd := config.Get().System.ZombieDetection
+	interval := time.Duration(zd.Interval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if e.st.Load() == environment.ProcessOfflineState {
+				continue
+			}
+			count, err := e.ZombieProcessCount(ctx)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) {
+					e.log().WithField("error", err).Warn("failed to inspect container for zombie processes")
+				}
+				continue
+			}
+			e.Events().Publish(environment.ZombieProcessEvent, count)
 		}
 	}
 }
@@ -115,6 +241,25 @@ func calculateDockerMemory(stats types.MemoryStats) uint64 {
 	return stats.Usage
 }
 
+// Returns the cache, RSS, and mapped file memory from the memory stats map for a
+// container. The keys present in the map depend on the cgroup version, the v1
+// keys are checked first before falling back to the v2 equivalents.
+func calculateDockerMemoryBreakdown(stats types.MemoryStats) (cache uint64, rss uint64, mapped uint64) {
+	lookup := func(keys ...string) uint64 {
+		for _, k := range keys {
+			if v, ok := stats.Stats[k]; ok {
+				return v
+			}
+		}
+		return 0
+	}
+
+	cache = lookup("total_cache", "cache", "file")
+	rss = lookup("total_rss", "rss", "anon")
+	mapped = lookup("total_mapped_file", "mapped_file", "file_mapped")
+	return
+}
+
 // Calculates the absolute CPU usage used by the server process on the system, not constrained
 // by the defined CPU limits on the container.
 //
@@ -0,0 +1,85 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/events"
+	"github.com/pterodactyl/wings/system"
+)
+
+func TestEnvironment_VerifyRunning(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Environment#verifyRunning", func() {
+		newEnvironment := func(state string) *Environment {
+			return &Environment{
+				st:      system.NewAtomicString(state),
+				emitter: events.NewBus(),
+			}
+		}
+		running := func(v bool, err error) func(context.Context) (bool, error) {
+			return func(context.Context) (bool, error) {
+				return v, err
+			}
+		}
+
+		g.It("closes the attach stream when the process exits silently", func() {
+			e := newEnvironment(environment.ProcessRunningState)
+			local, remote := net.Pipe()
+			defer remote.Close()
+			e.SetStream(&types.HijackedResponse{Conn: local, Reader: bufio.NewReader(local)})
+
+			g.Assert(e.verifyRunning(context.Background(), running(false, nil))).IsFalse()
+			// The state is changed by the attach goroutine once the stream ends,
+			// not by the check itself.
+			g.Assert(e.State()).Equal(environment.ProcessRunningState)
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := remote.Read(make([]byte, 1))
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				g.Assert(errors.Is(err, io.EOF)).IsTrue()
+			case <-time.After(time.Second):
+				g.Fail("attach stream was not closed")
+			}
+		})
+
+		g.It("closes the attach stream when the container is gone", func() {
+			e := newEnvironment(environment.ProcessRunningState)
+			g.Assert(e.verifyRunning(context.Background(), running(false, errdefs.NotFound(errors.New("no such container"))))).IsFalse()
+			g.Assert(e.State()).Equal(environment.ProcessRunningState)
+		})
+
+		g.It("leaves the state alone if the container is running", func() {
+			e := newEnvironment(environment.ProcessRunningState)
+			g.Assert(e.verifyRunning(context.Background(), running(true, nil))).IsTrue()
+			g.Assert(e.State()).Equal(environment.ProcessRunningState)
+		})
+
+		g.It("leaves the state alone if Docker cannot be reached", func() {
+			e := newEnvironment(environment.ProcessRunningState)
+			g.Assert(e.verifyRunning(context.Background(), running(false, errors.New("connection refused")))).IsTrue()
+			g.Assert(e.State()).Equal(environment.ProcessRunningState)
+		})
+
+		g.It("only checks servers that are marked as running", func() {
+			e := newEnvironment(environment.ProcessStartingState)
+			g.Assert(e.verifyRunning(context.Background(), running(false, nil))).IsTrue()
+			g.Assert(e.State()).Equal(environment.ProcessStartingState)
+		})
+	})
+}
@@ -0,0 +1,62 @@
+package environment
+
+import (
+	"context"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// The types of containers created by Wings, set using the "ContainerType" label.
+const (
+	ContainerTypeServer    = "server_process"
+	ContainerTypeInstaller = "server_installer"
+)
+
+// ManagedContainer is a container in Docker that was created by Wings.
+type ManagedContainer struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+	Image string `json:"image"`
+}
+
+// ServerUuid returns the UUID of the server the container was created for based
+// on its name.
+func (c ManagedContainer) ServerUuid() string {
+	return strings.TrimSuffix(c.Name, "_installer")
+}
+
+// ListManagedContainers returns every container in Docker, running or not, that
+// has the labels Wings applies to the containers it creates.
+func ListManagedContainers(ctx context.Context) ([]ManagedContainer, error) {
+	cli, err := Docker()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", "Service=Pterodactyl")),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "environment: failed to list containers")
+	}
+	out := make([]ManagedContainer, 0, len(containers))
+	for _, c := range containers {
+		var name string
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		out = append(out, ManagedContainer{
+			ID:    c.ID,
+			Name:  name,
+			Type:  c.Labels["ContainerType"],
+			State: c.State,
+			Image: c.Image,
+		})
+	}
+	return out, nil
+}
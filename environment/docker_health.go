@@ -0,0 +1,58 @@
+package environment
+
+import (
+	"context"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/pterodactyl/wings/system"
+)
+
+// Tracks if the Docker daemon could be reached the last time it was checked.
+// This starts out as true since Wings cannot boot without Docker.
+var dockerAvailable = system.NewAtomicBool(true)
+
+// DockerAvailable returns false if the Docker daemon could not be reached the
+// last time it was checked by WatchDocker.
+func DockerAvailable() bool {
+	return dockerAvailable.Load()
+}
+
+// WatchDocker pings the Docker daemon at the given interval until the context
+// is cancelled. The callback is called whenever the daemon becomes unreachable
+// and again once it can be reached.
+func WatchDocker(ctx context.Context, interval time.Duration, fn func(available bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			available := pingDocker(ctx, interval)
+			if dockerAvailable.SwapIf(available) {
+				if available {
+					log.Info("docker daemon is reachable again, resuming normal operation")
+				} else {
+					log.Warn("docker daemon is unreachable, server operations are unavailable until it returns")
+				}
+				fn(available)
+			}
+		}
+	}
+}
+
+// pingDocker returns true if the Docker daemon responds to a ping before the
+// timeout is reached.
+func pingDocker(ctx context.Context, timeout time.Duration) bool {
+	cli, err := Docker()
+	if err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, err = cli.Ping(ctx)
+	return err == nil
+}
@@ -11,9 +11,14 @@ import (
 const (
 	StateChangeEvent         = "state change"
 	ResourceEvent            = "resources"
+	ZombieProcessEvent       = "zombie processes"
+	HealthChangeEvent        = "health change"
+	KillFailedEvent          = "kill failed"
 	DockerImagePullStarted   = "docker image pull started"
 	DockerImagePullStatus    = "docker image pull status"
 	DockerImagePullCompleted = "docker image pull completed"
+	DockerImagePullProgress  = "docker image pull progress"
+	DockerImagePullFailed    = "docker image pull failed"
 )
 
 const (
@@ -23,6 +28,17 @@ const (
 	ProcessStoppingState = "stopping"
 )
 
+// OutputStream is the stream that a line of console output was written to by
+// the server process.
+type OutputStream string
+
+const (
+	// Stdout is used for all output from environments that cannot tell stdout
+	// and stderr apart, such as a container running with a TTY.
+	Stdout OutputStream = "stdout"
+	Stderr OutputStream = "stderr"
+)
+
 // Defines the basic interface that all environments need to implement so that
 // a server can be properly controlled.
 type ProcessEnvironment interface {
@@ -111,6 +127,7 @@ type ProcessEnvironment interface {
 	// the time that has passed since it was last started.
 	Uptime(ctx context.Context) (int64, error)
 
-	// SetLogCallback sets the callback that the container's log output will be passed to.
-	SetLogCallback(func([]byte))
+	// SetLogCallback sets the callback that the container's log output will be passed to,
+	// along with the stream each line of output was written to.
+	SetLogCallback(func(line []byte, stream OutputStream))
 }
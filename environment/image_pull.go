@@ -0,0 +1,19 @@
+package environment
+
+// ImagePullProgress is published with a DockerImagePullProgress event while the
+// image for an environment is being pulled.
+type ImagePullProgress struct {
+	Image string `json:"image"`
+	// The layer the update is for, empty for updates about the image as a whole.
+	Layer string `json:"layer,omitempty"`
+	// The status reported by Docker, such as "Downloading" or "Extracting".
+	Status string `json:"status"`
+	// The number of bytes of the layer that have been downloaded or extracted so
+	// far, and the size of the layer. Both are zero if the status does not have
+	// any progress.
+	Current int64 `json:"current"`
+	Total   int64 `json:"total"`
+	// The percentage of the image that has been downloaded, across all the layers
+	// with a known size.
+	Percent float64 `json:"percent"`
+}
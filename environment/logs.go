@@ -0,0 +1,11 @@
+package environment
+
+import "time"
+
+// LogEntry is a single line of output stored in the log of the environment,
+// along with when it was written.
+type LogEntry struct {
+	Time   time.Time    `json:"time"`
+	Stream OutputStream `json:"stream"`
+	Line   string       `json:"line"`
+}
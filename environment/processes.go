@@ -0,0 +1,15 @@
+package environment
+
+// Process is a single process running inside the environment.
+type Process struct {
+	PID  int    `json:"pid"`
+	User string `json:"user"`
+	// The share of a single CPU core used by the process over its lifetime, as a
+	// percentage, so this can be above 100 for processes using multiple cores.
+	CPU float64 `json:"cpu"`
+	// The share of the memory on the host used by the process, as a percentage.
+	Memory float64 `json:"memory"`
+	// The resident memory used by the process in bytes.
+	MemoryRss uint64 `json:"memory_rss"`
+	Command   string `json:"command"`
+}
@@ -13,6 +13,15 @@ type Stats struct {
 	// abilities for the container, so it's not going to be a perfect match.
 	MemoryLimit uint64 `json:"memory_limit_bytes"`
 
+	// A breakdown of the memory being used by the container. Cache is memory used by
+	// the page cache which can generally be reclaimed, RSS is anonymous memory used
+	// by the processes themselves, and Mapped is file backed memory that has been
+	// mapped into a process. These come directly from the container's cgroup so
+	// their availability depends on the cgroup version in use.
+	MemoryCache  uint64 `json:"memory_cache_bytes"`
+	MemoryRss    uint64 `json:"memory_rss_bytes"`
+	MemoryMapped uint64 `json:"memory_mapped_bytes"`
+
 	// The absolute CPU usage is the amount of CPU used in relation to the entire system and
 	// does not take into account any limits on the server process itself.
 	CpuAbsolute float64 `json:"cpu_absolute"`
@@ -9,6 +9,10 @@ import (
 	"github.com/pterodactyl/wings/system"
 )
 
+// The number of events that can be waiting to be delivered to a single listener
+// before the oldest of them are dropped.
+const listenerQueueSize = 256
+
 // Event represents an Event sent over a Bus.
 type Event struct {
 	Topic string
@@ -27,9 +31,11 @@ type Bus struct {
 // All of the events emitted out of this bus are byte slices that can be decoded
 // back into an events.Event interface.
 func NewBus() *Bus {
-	return &Bus{
-		system.NewSinkPool(),
-	}
+	// Each listener gets its own queue so that publishing an event never waits on
+	// a listener, no matter how many events are being published.
+	p := system.NewSinkPool()
+	p.SetQueue(listenerQueueSize)
+	return &Bus{p}
 }
 
 // Publish publishes a message to the Bus.
@@ -1,6 +1,8 @@
 package events
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -93,6 +95,79 @@ func TestNewBus(t *testing.T) {
 				bus.Off(listener2)
 				bus.Off(listener3)
 			})
+
+			g.It("does not wait for a listener that is not reading", func() {
+				bus := NewBus()
+
+				stalled := make(chan []byte)
+				listener := make(chan []byte, listenerQueueSize)
+				bus.On(stalled)
+				bus.On(listener)
+
+				start := time.Now()
+				for i := 0; i < listenerQueueSize; i++ {
+					bus.Publish(topic, message)
+				}
+				g.Assert(time.Since(start) < time.Second).IsTrue()
+
+				for i := 0; i < listenerQueueSize; i++ {
+					select {
+					case <-listener:
+					case <-time.After(time.Second):
+						g.Fail("listener did not receive every message")
+					}
+				}
+
+				bus.Off(stalled)
+				bus.Off(listener)
+			})
+
+			g.It("delivers messages to a listener in order", func() {
+				bus := NewBus()
+
+				listener := make(chan []byte)
+				bus.On(listener)
+				for i := 0; i < 10; i++ {
+					bus.Publish(topic, i)
+				}
+				for i := 0; i < 10; i++ {
+					m := MustDecode(<-listener)
+					g.Assert(m.Data).Equal(float64(i))
+				}
+
+				bus.Off(listener)
+			})
 		})
 	})
 }
+
+// BenchmarkBus_Publish measures how quickly events can be published to a bus
+// with many listeners, such as a busy server with a lot of open websockets.
+func BenchmarkBus_Publish(b *testing.B) {
+	for _, listeners := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("listeners=%d", listeners), func(b *testing.B) {
+			bus := NewBus()
+			var wg sync.WaitGroup
+			for i := 0; i < listeners; i++ {
+				c := make(chan []byte, 8)
+				bus.On(c)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range c {
+					}
+				}()
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bus.Publish("console output", "a line of console output from the server")
+			}
+			b.StopTimer()
+
+			bus.Destroy()
+			wg.Wait()
+		})
+	}
+}
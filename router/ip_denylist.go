@@ -0,0 +1,81 @@
+package router
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// The parsed ranges of the websocket IP denylist, along with the configuration
+// they were parsed from so that they are only parsed again if it changes.
+var deniedIPs struct {
+	sync.Mutex
+	source string
+	nets   []*net.IPNet
+}
+
+// parseDeniedIPs parses a list of IP addresses and CIDR ranges, a single address
+// is treated as a range containing only that address. Anything that cannot be
+// parsed is logged and skipped.
+func parseDeniedIPs(entries []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				log.WithField("entry", entry).Warn("router: invalid address in websocket denylist, it will be ignored")
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.WithFields(log.Fields{"entry": entry, "error": err}).Warn("router: invalid range in websocket denylist, it will be ignored")
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// isDeniedIP returns true if the address is in one of the ranges that are not
+// allowed to open websocket connections.
+func isDeniedIP(addr string) bool {
+	entries := config.Get().System.Websocket.DeniedIPs
+	if len(entries) == 0 {
+		return false
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	source := strings.Join(entries, ",")
+	deniedIPs.Lock()
+	if deniedIPs.source != source {
+		deniedIPs.source = source
+		deniedIPs.nets = parseDeniedIPs(entries)
+	}
+	nets := deniedIPs.nets
+	deniedIPs.Unlock()
+
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/router/middleware"
+)
+
+// logJSON responds with a body containing log output, compressing it with gzip
+// if the client accepts it and the body is large enough to be worth it.
+func logJSON(c *gin.Context, obj interface{}) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		middleware.CaptureAndAbort(c, err)
+		return
+	}
+
+	cfg := config.Get().System.LogCompression
+	c.Header("Vary", "Accept-Encoding")
+	if !cfg.Enabled || len(b) < cfg.Threshold || !acceptsGzip(c.Request) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", b)
+		return
+	}
+
+	c.Header("Content-Encoding", "gzip")
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	gz := gzip.NewWriter(c.Writer)
+	if _, err := gz.Write(b); err != nil {
+		_ = c.Error(err)
+	}
+	if err := gz.Close(); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// acceptsGzip returns true if the Accept-Encoding header of the request allows
+// for a gzip response.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(strings.TrimSpace(enc), ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+		// A quality of zero means the client does not accept it.
+		for _, p := range parts[1:] {
+			if q := strings.TrimSpace(p); strings.HasPrefix(q, "q=") {
+				if v, err := strconv.ParseFloat(q[2:], 64); err == nil && v == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
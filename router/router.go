@@ -39,6 +39,10 @@ func Configure(m *wserver.Manager, client remote.Client) *gin.Engine {
 		return ""
 	}))
 
+	// This is used by load balancers and monitoring tools, so it does not require
+	// any authorization and only exposes basic information about the node.
+	router.GET("/health", getHealth)
+
 	// These routes use signed URLs to validate access to the resource being requested.
 	router.GET("/download/backup", getDownloadBackup)
 	router.GET("/download/file", getDownloadFile)
@@ -46,8 +50,11 @@ func Configure(m *wserver.Manager, client remote.Client) *gin.Engine {
 
 	// This route is special it sits above all the other requests because we are
 	// using a JWT to authorize access to it, therefore it needs to be publicly
-	// accessible.
+	// accessible. The same JWT can also be used to send a command or stream the
+	// resource usage of the server without having to open a websocket.
 	router.GET("/api/servers/:server/ws", middleware.ServerExists(), getServerWebsocket)
+	router.POST("/api/servers/:server/command", middleware.ServerExists(), postServerCommandWithToken)
+	router.GET("/api/servers/:server/stats/stream", middleware.ServerExists(), getServerStatsStream)
 
 	// This request is called by another daemon when a server is going to be transferred out.
 	// This request does not need the AuthorizationMiddleware as the panel should never call it
@@ -59,6 +66,13 @@ func Configure(m *wserver.Manager, client remote.Client) *gin.Engine {
 	protected := router.Use(middleware.RequireAuthorization())
 	protected.POST("/api/update", postUpdateConfiguration)
 	protected.GET("/api/system", getSystemInformation)
+	protected.GET("/api/system/websockets", getSystemWebsockets)
+	protected.GET("/api/system/resources", getSystemResources)
+	protected.GET("/api/system/console-backlog", getSystemConsoleBacklog)
+	protected.GET("/api/system/containers/orphaned", getSystemOrphanedContainers)
+	protected.GET("/api/system/drain", getSystemDrain)
+	protected.POST("/api/system/drain", postSystemDrain)
+	protected.DELETE("/api/system/drain", deleteSystemDrain)
 	protected.GET("/api/servers", getAllServers)
 	protected.POST("/api/servers", postCreateServer)
 	protected.DELETE("/api/transfers/:server", deleteTransfer)
@@ -72,12 +86,26 @@ func Configure(m *wserver.Manager, client remote.Client) *gin.Engine {
 		server.DELETE("", deleteServer)
 
 		server.GET("/logs", getServerLogs)
+		server.GET("/crash", getServerLastCrash)
+		server.GET("/audit", getServerCommandAudit)
+		server.GET("/limits", getServerLimits)
+		server.GET("/startup", getServerStartupCommand)
+		server.GET("/auto-start", getServerAutoStart)
+		server.PUT("/auto-start", putServerAutoStart)
+		server.GET("/debug", getServerDebugLogging)
+		server.PUT("/debug", putServerDebugLogging)
+		server.GET("/preflight", getServerPreflight)
+		server.GET("/players", getServerPlayerCount)
 		server.POST("/power", postServerPower)
 		server.POST("/commands", postServerCommands)
 		server.POST("/install", postServerInstall)
+		server.GET("/install/log", getServerInstallLog)
 		server.POST("/reinstall", postServerReinstall)
+		server.POST("/recreate", postServerRecreate)
 		server.POST("/sync", postServerSync)
 		server.POST("/ws/deny", postServerDenyWSTokens)
+		server.POST("/ws/revoke", postServerRevokeWSSessions)
+		server.POST("/ws/permissions", postServerUpdateWSPermissions)
 
 		// This archive request causes the archive to start being created
 		// this should only be triggered by the panel.
@@ -88,6 +116,7 @@ func Configure(m *wserver.Manager, client remote.Client) *gin.Engine {
 		{
 			files.GET("/contents", getServerFileContents)
 			files.GET("/list-directory", getServerListDirectory)
+			files.GET("/disk-usage", getServerDiskUsage)
 			files.PUT("/rename", putServerRenameFiles)
 			files.POST("/copy", postServerCopyFile)
 			files.POST("/write", postServerWriteFile)
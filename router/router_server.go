@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/apex/log"
@@ -13,6 +15,7 @@ import (
 	"github.com/pterodactyl/wings/router/downloader"
 	"github.com/pterodactyl/wings/router/middleware"
 	"github.com/pterodactyl/wings/router/tokens"
+	"github.com/pterodactyl/wings/router/websocket"
 	"github.com/pterodactyl/wings/server"
 	"github.com/pterodactyl/wings/server/transfer"
 )
@@ -39,7 +42,132 @@ func getServerLogs(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": out})
+	logJSON(c, gin.H{"data": out})
+}
+
+// Returns the log from the last time the server was installed.
+func getServerInstallLog(c *gin.Context) {
+	l, _ := strconv.Atoi(c.DefaultQuery("size", "1000"))
+	if l <= 0 || l > 1000 {
+		l = 1000
+	}
+
+	out, err := ExtractServer(c).InstallLog(l)
+	if err != nil {
+		middleware.CaptureAndAbort(c, err)
+		return
+	}
+
+	logJSON(c, gin.H{"data": out})
+}
+
+// Returns the details of the last detected crash for a server instance. If the
+// server has not crashed recently the data returned will be null.
+func getServerLastCrash(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": ExtractServer(c).LastCrash()})
+}
+
+// Returns the console commands that were recently sent to the server.
+func getServerCommandAudit(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": ExtractServer(c).CommandAudit().Entries()})
+}
+
+// Returns the configured resource limits for a server instance.
+func getServerLimits(c *gin.Context) {
+	c.JSON(http.StatusOK, ExtractServer(c).ResourceLimits())
+}
+
+// Returns the startup command for the server with all the variables substituted
+// and any secret values redacted.
+func getServerStartupCommand(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"command": ExtractServer(c).StartupCommand()})
+}
+
+// Returns whether the server is started when Wings boots.
+func getServerAutoStart(c *gin.Context) {
+	a, err := ExtractServer(c).AutoStart()
+	if err != nil {
+		middleware.CaptureAndAbort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"auto_start": a})
+}
+
+// Updates whether the server is started when Wings boots.
+func putServerAutoStart(c *gin.Context) {
+	var data struct {
+		AutoStart server.AutoStart `json:"auto_start"`
+	}
+	if err := c.BindJSON(&data); err != nil {
+		return
+	}
+	if !data.AutoStart.Valid() {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "The auto start setting must be one of \"previous\", \"always\", or \"never\".",
+		})
+		return
+	}
+	if err := ExtractServer(c).SetAutoStart(data.AutoStart); err != nil {
+		middleware.CaptureAndAbort(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Returns whether debug logging is turned on for the server.
+func getServerDebugLogging(c *gin.Context) {
+	c.JSON(http.StatusOK, ExtractServer(c).DebugLogging())
+}
+
+// Turns debug logging on or off for the server. Debug logging turns itself off
+// again after the duration in seconds, or the configured maximum if none is
+// given.
+func putServerDebugLogging(c *gin.Context) {
+	var data struct {
+		Enabled  bool `json:"enabled"`
+		Duration int  `json:"duration"`
+	}
+	if err := c.BindJSON(&data); err != nil {
+		return
+	}
+	if data.Duration < 0 {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "The debug logging duration cannot be negative.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, ExtractServer(c).SetDebugLogging(data.Enabled, time.Duration(data.Duration)*time.Second))
+}
+
+// Returns the number of players connected to the server, sending the configured
+// player count command to the server if the last count is too old. This is only
+// used by the Panel, so no checks are made for a user.
+func getServerPlayerCount(c *gin.Context) {
+	count, err := ExtractServer(c).PlayerCount(c.Request.Context(), nil)
+	if err != nil {
+		switch {
+		case errors.Is(err, server.ErrPlayerCountDisabled):
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Player counts are not configured for this server."})
+		case errors.Is(err, server.ErrNotRunning):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "The server must be running to check its player count."})
+		case errors.Is(err, server.ErrPlayerCountUnavailable), errors.Is(err, server.ErrCaptureExists), errors.Is(err, server.ErrTooManyCaptures):
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "The player count could not be checked: " + err.Error() + "."})
+		default:
+			middleware.CaptureAndAbort(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, count)
+}
+
+// Runs the checks performed before starting a server without actually starting
+// it, and returns anything that would currently prevent the server from starting.
+func getServerPreflight(c *gin.Context) {
+	blockers := ExtractServer(c).Preflight(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{
+		"ready":    len(blockers) == 0,
+		"blockers": blockers,
+	})
 }
 
 // Handles a request to control the power state of a server. If the action being passed
@@ -69,6 +197,13 @@ func postServerPower(c *gin.Context) {
 		return
 	}
 
+	if s.PowerActionDisabled(data.Action) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "This power action has been disabled for the server.",
+		})
+		return
+	}
+
 	// Because we route all of the actual bootup process to a separate thread we need to
 	// check the suspension status here, otherwise the user will hit the endpoint and then
 	// just sit there wondering why it returns a success but nothing actually happens.
@@ -92,7 +227,7 @@ func postServerPower(c *gin.Context) {
 		if err := s.HandlePowerAction(data.Action, data.WaitSeconds); err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
 				s.Log().WithField("action", data.Action).WithField("error", err).Warn("could not process server power action")
-			} else if errors.Is(err, server.ErrIsRunning) {
+			} else if errors.Is(err, server.ErrIsRunning) || errors.Is(err, server.ErrInvalidPowerAction) || errors.Is(err, server.ErrPowerActionInProgress) {
 				// Do nothing, this isn't something we care about for logging,
 			} else {
 				s.Log().WithFields(log.Fields{"action": data.Action, "wait_seconds": data.WaitSeconds, "error": err}).
@@ -118,6 +253,13 @@ func postServerCommands(c *gin.Context) {
 		return
 	}
 
+	if s.CommandsDisabled() {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Commands are disabled for this server.",
+		})
+		return
+	}
+
 	var data struct {
 		Commands []string `json:"commands"`
 	}
@@ -126,13 +268,86 @@ func postServerCommands(c *gin.Context) {
 		return
 	}
 
-	for _, command := range data.Commands {
-		if err := s.Environment.SendCommand(command); err != nil {
-			s.Log().WithFields(log.Fields{"command": command, "error": err}).Warn("failed to send command to server instance")
+	s.SendCommands(data.Commands)
+
+	c.Status(http.StatusNoContent)
+}
+
+// postServerCommandWithToken sends a single console command to a server. Unlike
+// postServerCommands this is authorized using the same JWT that would be used to
+// connect to the server websocket, which must have the send command permission,
+// allowing scripts to send a command without keeping a websocket open.
+func postServerCommandWithToken(c *gin.Context) {
+	s := ExtractServer(c)
+
+	auth := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(auth) != 2 || auth[0] != "Bearer" {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "The required authorization heads were not present in the request.",
+		})
+		return
+	}
+
+	token, err := websocket.NewTokenPayload([]byte(auth[1]))
+	if err == nil {
+		err = websocket.ValidateToken(s, token)
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "The provided token is not valid for this server.",
+		})
+		return
+	}
+	var data struct {
+		Command string `json:"command"`
+	}
+	// BindJSON sends 400 if the request fails, all we need to do is return
+	if err := c.BindJSON(&data); err != nil {
+		return
+	}
+
+	// These are the same checks made for commands sent over the websocket, and the
+	// rate limit is shared with the user's websocket connections.
+	err = websocket.CheckCommand(s, token, data.Command, func() bool {
+		return websocket.AllowUserCommand(s, token.UserUUID)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, websocket.ErrCommandPermission):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "You do not have permission to send commands to this server.",
+			})
+		case errors.Is(err, websocket.ErrCommandsDisabled):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Commands are disabled for this server.",
+			})
+		case errors.Is(err, websocket.ErrCommandRateLimited):
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		}
+		return
 	}
 
-	c.Status(http.StatusNoContent)
+	res, err := websocket.SendCommand(s, s.NewRequestActivity(token.UserUUID, c.ClientIP()), data.Command)
+	if err != nil {
+		switch {
+		case errors.Is(err, server.ErrNotRunning):
+			c.AbortWithStatusJSON(http.StatusBadGateway, gin.H{
+				"error": "Cannot send commands to a stopped server instance.",
+			})
+		case errors.Is(err, server.ErrCommandQueueFull):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			middleware.CaptureAndAbort(c, err)
+		}
+		return
+	}
+	if !res.Queued {
+		s.Websockets().EchoCommand(token.UserUUID, data.Command)
+	}
+	c.JSON(http.StatusOK, res)
 }
 
 // postServerSync will accept a POST request and trigger a re-sync of the given
@@ -188,6 +403,28 @@ func postServerReinstall(c *gin.Context) {
 	c.Status(http.StatusAccepted)
 }
 
+// Removes the container for a server and creates it again from the server's
+// configuration, without touching any of the server's files. This waits for
+// the container to be recreated so that any failure can be reported back.
+func postServerRecreate(c *gin.Context) {
+	s := ExtractServer(c)
+
+	res, err := s.RecreateContainer()
+	if err != nil {
+		if errors.Is(err, server.ErrPowerActionInProgress) || errors.Is(err, server.ErrServerIsInstalling) ||
+			errors.Is(err, server.ErrServerIsTransferring) || errors.Is(err, server.ErrServerIsRestoring) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+				"error": "Cannot recreate the server container right now: " + err.Error() + ".",
+			})
+			return
+		}
+		middleware.CaptureAndAbort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
 // Deletes a server from the wings daemon and dissociate its objects.
 func deleteServer(c *gin.Context) {
 	s := middleware.ExtractServer(c)
@@ -261,3 +498,50 @@ func postServerDenyWSTokens(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// Closes any open websocket connections for the server belonging to the users
+// passed through in the body. Unlike the deny list this does not invalidate any
+// tokens, the user is able to reconnect if they still have access to the server.
+func postServerRevokeWSSessions(c *gin.Context) {
+	s := ExtractServer(c)
+
+	var data struct {
+		Users []string `json:"users"`
+	}
+
+	if err := c.BindJSON(&data); err != nil {
+		return
+	}
+
+	for _, user := range data.Users {
+		if n := s.Websockets().RevokeUser(user); n > 0 {
+			s.Log().WithField("user_uuid", user).WithField("connections", n).Info("revoked websocket sessions for user")
+		}
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Replaces the permissions of a user for every token for the server issued
+// before now, so that a change to the permissions of a subuser takes effect
+// straight away rather than once their token is refreshed. This covers open
+// websocket connections as well as any token used with the API later on, and
+// connections that no longer have permission to connect are closed.
+func postServerUpdateWSPermissions(c *gin.Context) {
+	s := ExtractServer(c)
+
+	var data struct {
+		User        string   `json:"user" binding:"required"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.BindJSON(&data); err != nil {
+		return
+	}
+
+	if n := s.UpdateUserPermissions(data.User, data.Permissions); n > 0 {
+		s.Log().WithField("user_uuid", data.User).WithField("connections", n).Info("updated websocket permissions for user")
+	}
+
+	c.Status(http.StatusNoContent)
+}
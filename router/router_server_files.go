@@ -85,6 +85,19 @@ func getServerListDirectory(c *gin.Context) {
 	}
 }
 
+// getServerDiskUsage returns the disk space used by each directory within a
+// directory of the server, so that users can see what is taking up their space.
+func getServerDiskUsage(c *gin.Context) {
+	s := ExtractServer(c)
+	dir := c.DefaultQuery("directory", "/")
+	usage, err := s.Filesystem().DiskUsageBreakdown(c.Request.Context(), dir)
+	if err != nil {
+		middleware.CaptureAndAbort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
 type renameFile struct {
 	To   string `json:"to"`
 	From string `json:"from"`
@@ -0,0 +1,131 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/router/websocket"
+	"github.com/pterodactyl/wings/server"
+)
+
+// The interval at which a comment is sent over an idle stats stream so that
+// proxies do not close the connection.
+const statsStreamKeepAlive = time.Second * 15
+
+// getServerStatsStream streams the resource usage of a server as server-sent
+// events, sending the same data as the stats event on the websocket. This is
+// authorized using the websocket JWT, which can be passed as a Bearer token or
+// in the "token" query parameter since the browser EventSource API does not
+// allow setting headers. The stream is closed once the token expires.
+func getServerStatsStream(c *gin.Context) {
+	s := ExtractServer(c)
+
+	raw := c.Query("token")
+	if auth := strings.SplitN(c.GetHeader("Authorization"), " ", 2); len(auth) == 2 && auth[0] == "Bearer" {
+		raw = auth[1]
+	}
+	if raw == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "The required authorization heads were not present in the request.",
+		})
+		return
+	}
+
+	token, err := websocket.NewTokenPayload([]byte(raw))
+	if err == nil {
+		err = websocket.ValidateToken(s, token)
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "The provided token is not valid for this server.",
+		})
+		return
+	}
+	if !websocket.HasEventPermission(token, server.StatsEvent) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "You do not have permission to view the resource usage of this server.",
+		})
+		return
+	}
+
+	ch := make(chan []byte, 8)
+	if err := s.Events().On(ch); err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": "Too many listeners are registered for this server, please try again later.",
+		})
+		return
+	}
+	defer s.Events().Off(ch)
+
+	var expired <-chan time.Time
+	if exp := token.GetPayload().ExpirationTime; exp != nil {
+		t := time.NewTimer(time.Until(exp.Time))
+		defer t.Stop()
+		expired = t.C
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(data []byte) bool {
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", server.StatsEvent, data); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	// Send the current usage straight away rather than making the client wait for
+	// the next sample.
+	if b, err := json.Marshal(s.Proc()); err == nil && !send(b) {
+		return
+	}
+
+	keepAlive := time.NewTicker(statsStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-expired:
+			_, _ = fmt.Fprint(c.Writer, "event: token expired\ndata: {}\n\n")
+			c.Writer.Flush()
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case b, ok := <-ch:
+			// The channel is closed if the server is deleted.
+			if !ok {
+				return
+			}
+			var e struct {
+				Topic string          `json:"Topic"`
+				Data  json.RawMessage `json:"Data"`
+			}
+			if err := json.Unmarshal(b, &e); err != nil || e.Topic != server.StatsEvent {
+				continue
+			}
+			// Stop streaming if the token is revoked, or the user loses permission to
+			// see the stats, while connected.
+			if websocket.ValidateToken(s, token) != nil || !websocket.HasEventPermission(token, server.StatsEvent) {
+				return
+			}
+			if !send(e.Data) {
+				return
+			}
+		}
+	}
+}
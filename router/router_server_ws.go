@@ -2,6 +2,7 @@ package router
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,21 +11,33 @@ import (
 
 	"github.com/pterodactyl/wings/router/middleware"
 	"github.com/pterodactyl/wings/router/websocket"
+	"github.com/pterodactyl/wings/server"
 )
 
-var expectedCloseCodes = []int{
-	ws.CloseGoingAway,
-	ws.CloseAbnormalClosure,
-	ws.CloseNormalClosure,
-	ws.CloseNoStatusReceived,
-	ws.CloseServiceRestart,
-}
-
 // Upgrades a connection to a websocket and passes events along between.
 func getServerWebsocket(c *gin.Context) {
 	manager := middleware.ExtractManager(c)
 	s, _ := manager.Get(c.Param("server"))
 
+	// c.ClientIP only uses the forwarded address when the request came from one of
+	// the trusted proxies configured for the API.
+	if isDeniedIP(c.ClientIP()) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "Connections from your address are not allowed.",
+		})
+		return
+	}
+
+	// Refuse new connections while the node is being drained before maintenance,
+	// the client should try again once it is back.
+	if manager.Draining() {
+		c.Header("Retry-After", "60")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error": "This node is going down for maintenance, please try again later.",
+		})
+		return
+	}
+
 	// Create a context that can be canceled when the user disconnects from this
 	// socket that will also cancel listeners running in separate threads. If the
 	// connection itself is terminated listeners using this context will also be
@@ -41,11 +54,17 @@ func getServerWebsocket(c *gin.Context) {
 
 	// Track this open connection on the server so that we can close them all programmatically
 	// if the server is deleted.
-	s.Websockets().Push(handler.Uuid(), &cancel)
+	s.Websockets().Push(handler.Uuid(), &cancel, handler.CloseWithReason)
+	s.Websockets().OnPermissionsUpdate(handler.Uuid(), handler.UpdatePermissions)
+	s.Websockets().OnCommandEcho(handler.Uuid(), func(command string) {
+		_ = handler.EchoCommand(command)
+	})
 	handler.Logger().Debug("opening connection to server websocket")
 
 	defer func() {
 		s.Websockets().Remove(handler.Uuid())
+		handler.ReleaseReconnectToken()
+		handler.ReleaseCommandLimit()
 		handler.Logger().Debug("closing connection to server websocket")
 	}()
 
@@ -58,26 +77,38 @@ func getServerWebsocket(c *gin.Context) {
 		case <-ctx.Done():
 			break
 		case <-s.Context().Done():
-			_ = handler.Connection.WriteControl(ws.CloseMessage, ws.FormatCloseMessage(ws.CloseGoingAway, "server deleted"), time.Now().Add(time.Second*5))
+			_ = handler.Connection.WriteControl(ws.CloseMessage, ws.FormatCloseMessage(ws.CloseGoingAway, server.NewCloseReason(server.CloseReasonServerDeleted, "server deleted").String()), time.Now().Add(time.Second*5))
 			break
 		}
 	}()
 
+	// Close the connection once it has been open for too long, even if the client
+	// keeps sending new tokens.
+	go handler.EnforceSessionLimit(ctx)
+
 	for {
 		j := websocket.Message{}
 
-		_, p, err := handler.Connection.ReadMessage()
+		p, err := handler.ReadMessage()
 		if err != nil {
-			if ws.IsUnexpectedCloseError(err, expectedCloseCodes...) {
-				handler.Logger().WithField("error", err).Warn("error handling websocket message for server")
-			}
+			handler.HandleClose(err)
 			break
 		}
 
-		// Discard and JSON parse errors into the void and don't continue processing this
-		// specific socket request. If we did a break here the client would get disconnected
-		// from the socket, which is NOT what we want to do.
+		// Let the client know about any JSON parse errors and don't continue processing
+		// this specific socket request. If we did a break here the client would get
+		// disconnected from the socket, which is NOT what we want to do.
 		if err := json.Unmarshal(p, &j); err != nil {
+			handler.SendBadMessage(err)
+			continue
+		}
+
+		// Subscriptions are handled in the read loop itself so that they are always
+		// applied before an authentication event sent immediately afterwards.
+		if j.Event == websocket.SubscribeEvent {
+			if err := handler.HandleInbound(ctx, j); err != nil {
+				_ = handler.SendErrorJson(j, err)
+			}
 			continue
 		}
 
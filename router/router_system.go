@@ -5,12 +5,15 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/gin-gonic/gin"
 
 	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
 	"github.com/pterodactyl/wings/router/middleware"
+	"github.com/pterodactyl/wings/router/websocket"
 	"github.com/pterodactyl/wings/server"
 	"github.com/pterodactyl/wings/server/installer"
 	"github.com/pterodactyl/wings/system"
@@ -155,3 +158,125 @@ func postUpdateConfiguration(c *gin.Context) {
 		Applied: true,
 	})
 }
+
+// Returns the progress of draining the websocket connections from this node.
+func getSystemDrain(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.ExtractManager(c).DrainStatus())
+}
+
+// Returns the total, allocated and used memory, CPU and disk on this node, so
+// that the Panel can check there is room for a server before starting it.
+func getSystemResources(c *gin.Context) {
+	res, err := middleware.ExtractManager(c).NodeResources()
+	if err != nil {
+		middleware.CaptureAndAbort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// getHealth is an unauthenticated health check for load balancers and monitoring.
+// It reports if the Docker daemon could be reached, using the result of the
+// last background ping so that this is always fast, along with how many servers
+// are on the node and running, and the number of open websocket connections. A
+// 503 is returned when Docker is unreachable.
+func getHealth(c *gin.Context) {
+	var running, open int
+	servers := middleware.ExtractManager(c).All()
+	for _, s := range servers {
+		if s.IsRunning() {
+			running++
+		}
+		open += s.Websockets().Len()
+	}
+
+	status, code := "ok", http.StatusOK
+	docker := environment.DockerAvailable()
+	if !docker {
+		status, code = "unhealthy", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":          status,
+		"docker":          docker,
+		"servers":         len(servers),
+		"running_servers": running,
+		"websockets":      open,
+	})
+}
+
+// Returns the number of websocket connections open to this node, and the number
+// that have been dropped since Wings started for not reading their messages,
+// along with the bandwidth used by each server's connections.
+func getSystemWebsockets(c *gin.Context) {
+	var open int
+	bandwidth := make(map[string]gin.H)
+	for _, s := range middleware.ExtractManager(c).All() {
+		open += s.Websockets().Len()
+		bandwidth[s.ID()] = gin.H{
+			"bytes_sent":    s.WebsocketBandwidth().BytesSent(),
+			"lines_dropped": s.WebsocketBandwidth().LinesDropped(),
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"connections":          open,
+		"slow_clients_dropped": websocket.SlowClientsDropped(),
+		"servers":              bandwidth,
+	})
+}
+
+// Returns the total memory used by the console backlogs of every server on the
+// node, along with the node wide limit.
+func getSystemConsoleBacklog(c *gin.Context) {
+	budget := server.ConsoleBacklogBudget()
+	c.JSON(http.StatusOK, gin.H{
+		"bytes":     budget.Size(),
+		"max_bytes": budget.Max(),
+		"servers":   budget.Len(),
+	})
+}
+
+// Returns the containers in Docker that were created by Wings but do not belong
+// to any server on the node, so that they can be cleaned up.
+func getSystemOrphanedContainers(c *gin.Context) {
+	containers, err := middleware.ExtractManager(c).OrphanedContainers(c.Request.Context())
+	if err != nil {
+		middleware.CaptureAndAbort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, containers)
+}
+
+// Starts draining the websocket connections from this node ahead of maintenance.
+// New connections are refused while draining, and the open connections are closed
+// over the window provided, or the configured default window.
+func postSystemDrain(c *gin.Context) {
+	var data struct {
+		Window *int `json:"window"`
+	}
+	// An empty body is fine here, the default window is used.
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&data); err != nil {
+			return
+		}
+	}
+
+	window := config.Get().System.Websocket.DrainWindow
+	if data.Window != nil {
+		window = *data.Window
+	}
+	if window < 0 || window > 3600 {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "The drain window must be between 0 and 3600 seconds.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, middleware.ExtractManager(c).Drain(time.Duration(window)*time.Second))
+}
+
+// Stops draining this node, allowing new websocket connections to be made again.
+func deleteSystemDrain(c *gin.Context) {
+	middleware.ExtractManager(c).StopDrain()
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package tokens
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/goccy/go-json"
+)
+
+var ErrTokenRejected = errors.New("jwt: token was rejected by the introspection endpoint")
+
+// WebsocketValidator verifies a token sent to the websocket and fills in the
+// payload with the claims it contains. This allows deployments using their own
+// authentication service to replace how tokens are checked, while the rest of
+// Wings continues to work with the same WebsocketPayload.
+//
+// The server, permissions, and denylist checks are still performed by Wings once
+// the token has been validated.
+type WebsocketValidator interface {
+	ValidateWebsocketToken(token []byte, payload *WebsocketPayload) error
+}
+
+// WebsocketValidatorFunc allows a function to be used as a WebsocketValidator.
+type WebsocketValidatorFunc func(token []byte, payload *WebsocketPayload) error
+
+func (f WebsocketValidatorFunc) ValidateWebsocketToken(token []byte, payload *WebsocketPayload) error {
+	return f(token, payload)
+}
+
+// HMACValidator validates tokens as JWTs signed by the Panel using the token
+// configured for Wings. This is the default validator.
+var HMACValidator = WebsocketValidatorFunc(func(token []byte, payload *WebsocketPayload) error {
+	return ParseToken(token, payload)
+})
+
+var websocketValidator = struct {
+	sync.RWMutex
+	v WebsocketValidator
+}{v: HMACValidator}
+
+// SetWebsocketValidator replaces the validator used for websocket tokens. Passing
+// nil restores the default HMAC validator.
+func SetWebsocketValidator(v WebsocketValidator) {
+	if v == nil {
+		v = HMACValidator
+	}
+	websocketValidator.Lock()
+	websocketValidator.v = v
+	websocketValidator.Unlock()
+}
+
+// ParseWebsocketToken validates a websocket token using the configured validator
+// and returns the parsed claims in the payload.
+func ParseWebsocketToken(token []byte, payload *WebsocketPayload) error {
+	websocketValidator.RLock()
+	v := websocketValidator.v
+	websocketValidator.RUnlock()
+
+	return v.ValidateWebsocketToken(token, payload)
+}
+
+// IntrospectionValidator validates websocket tokens by sending them to a remote
+// endpoint, such as an OAuth token introspection endpoint in front of an SSO
+// provider. The token is sent as JSON in the form {"token": "..."}, and the
+// endpoint must respond with a 200 status and the claims of the token using the
+// same fields as a Panel issued token. Any other status rejects the token.
+type IntrospectionValidator struct {
+	URL string
+	// Sent as a Bearer token so that the endpoint can verify the request came from
+	// Wings, this is optional.
+	Token  string
+	Client *http.Client
+}
+
+// NewIntrospectionValidator returns a validator sending tokens to the given
+// endpoint, giving up on requests that take longer than the timeout.
+func NewIntrospectionValidator(url string, token string, timeout time.Duration) *IntrospectionValidator {
+	return &IntrospectionValidator{URL: url, Token: token, Client: &http.Client{Timeout: timeout}}
+}
+
+func (v *IntrospectionValidator) ValidateWebsocketToken(token []byte, payload *WebsocketPayload) error {
+	body, err := json.Marshal(map[string]string{"token": string(token)})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, v.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if v.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+v.Token)
+	}
+
+	res, err := v.Client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "jwt: failed to reach introspection endpoint")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.WithMessagef(ErrTokenRejected, "status %d", res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(payload); err != nil {
+		return errors.Wrap(err, "jwt: failed to decode introspection response")
+	}
+
+	// Tokens without an issued time are always treated as denied, since the token
+	// was only just checked by the endpoint it is treated as being issued now.
+	if payload.IssuedAt == nil {
+		payload.IssuedAt = &jwt.Time{Time: time.Now()}
+	}
+	// The not before claim is required the same as it is for Panel issued tokens,
+	// it is not filled in since that would allow tokens without one to be used.
+	if payload.NotBefore == nil {
+		return errors.WithMessage(ErrTokenRejected, "missing not before (nbf) claim")
+	}
+
+	// The endpoint is trusted to validate the token, but an expired token, or one
+	// that is not valid yet, is never accepted even if it did not check the times
+	// itself.
+	now := time.Now()
+	if exp := payload.GetPayload().ExpirationTime; exp != nil && exp.Before(now) {
+		return jwt.ErrExpValidation
+	}
+	if payload.NotBefore.After(now) {
+		return jwt.ErrNbfValidation
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package tokens
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/goccy/go-json"
+)
+
+func TestIntrospectionValidator(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("IntrospectionValidator", func() {
+		var claims map[string]interface{}
+		var status int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Token string `json:"token"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.Token != "valid" || r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(claims)
+		}))
+		g.After(func() {
+			srv.Close()
+		})
+
+		v := NewIntrospectionValidator(srv.URL, "secret", time.Second)
+
+		g.BeforeEach(func() {
+			status = http.StatusOK
+			claims = map[string]interface{}{
+				"user_uuid":   "user",
+				"server_uuid": "server",
+				"permissions": []string{"websocket.connect"},
+				"exp":         time.Now().Add(time.Minute).Unix(),
+				"nbf":         time.Now().Add(-time.Minute).Unix(),
+			}
+		})
+
+		g.It("fills in the payload from the response", func() {
+			var p WebsocketPayload
+			g.Assert(v.ValidateWebsocketToken([]byte("valid"), &p)).IsNil()
+			g.Assert(p.UserUUID).Equal("user")
+			g.Assert(p.GetServerUuid()).Equal("server")
+			g.Assert(p.HasPermission("websocket.connect")).IsTrue()
+		})
+
+		g.It("rejects tokens the endpoint does not accept", func() {
+			var p WebsocketPayload
+			err := v.ValidateWebsocketToken([]byte("invalid"), &p)
+			g.Assert(errors.Is(err, ErrTokenRejected)).IsTrue()
+		})
+
+		g.It("rejects tokens that have expired", func() {
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			var p WebsocketPayload
+			err := v.ValidateWebsocketToken([]byte("valid"), &p)
+			g.Assert(errors.Is(err, jwt.ErrExpValidation)).IsTrue()
+		})
+
+		g.It("rejects tokens without a not before claim", func() {
+			delete(claims, "nbf")
+			var p WebsocketPayload
+			err := v.ValidateWebsocketToken([]byte("valid"), &p)
+			g.Assert(errors.Is(err, ErrTokenRejected)).IsTrue()
+		})
+
+		g.It("rejects tokens that are not valid yet", func() {
+			claims["nbf"] = time.Now().Add(time.Hour).Unix()
+			var p WebsocketPayload
+			err := v.ValidateWebsocketToken([]byte("valid"), &p)
+			g.Assert(errors.Is(err, jwt.ErrNbfValidation)).IsTrue()
+		})
+	})
+
+	g.Describe("SetWebsocketValidator", func() {
+		g.It("uses the validator that was set", func() {
+			SetWebsocketValidator(WebsocketValidatorFunc(func(token []byte, payload *WebsocketPayload) error {
+				payload.UserUUID = string(token)
+				return nil
+			}))
+			defer SetWebsocketValidator(nil)
+
+			var p WebsocketPayload
+			g.Assert(ParseWebsocketToken([]byte("custom"), &p)).IsNil()
+			g.Assert(p.UserUUID).Equal("custom")
+		})
+	})
+}
@@ -43,6 +43,11 @@ type WebsocketPayload struct {
 	UserUUID    string   `json:"user_uuid"`
 	ServerUUID  string   `json:"server_uuid"`
 	Permissions []string `json:"permissions"`
+
+	// MaxSessionDuration is the longest, in seconds, that a connection using this
+	// token can stay open for, regardless of when the token expires. This is not
+	// limited by the token if it is 0 or missing.
+	MaxSessionDuration int `json:"max_session_duration,omitempty"`
 }
 
 // Returns the JWT payload.
@@ -61,6 +66,18 @@ func (p *WebsocketPayload) GetServerUuid() string {
 	return p.ServerUUID
 }
 
+// GetMaxSessionDuration returns the longest a connection using this token can
+// stay open for, or 0 if the token does not limit it.
+func (p *WebsocketPayload) GetMaxSessionDuration() time.Duration {
+	p.RLock()
+	defer p.RUnlock()
+
+	if p.MaxSessionDuration <= 0 {
+		return 0
+	}
+	return time.Duration(p.MaxSessionDuration) * time.Second
+}
+
 // Check if the JWT has been marked as denied by the instance due to either being issued
 // before Wings was booted, or because we have denied all tokens with the same JTI
 // occurring before a set time.
@@ -88,6 +105,15 @@ func (p *WebsocketPayload) Denylisted() bool {
 	return false
 }
 
+// SetPermissions replaces the permissions of the token, this is used to apply a
+// change to the permissions of a user to a connection that is already open.
+func (p *WebsocketPayload) SetPermissions(permissions []string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.Permissions = append([]string{}, permissions...)
+}
+
 // Checks if the given token payload has a permission string.
 func (p *WebsocketPayload) HasPermission(permission string) bool {
 	p.RLock()
@@ -0,0 +1,37 @@
+package websocket
+
+import (
+	"strconv"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/server"
+)
+
+// sendConsoleOutput sends a line of console output to the client unless the
+// server's websocket connections are close to their bandwidth limit, in which
+// case the line is dropped. The first time a line is dropped in each second the
+// client is sent a notice, with the total number of lines dropped for the
+// server, so that it can show that the console is incomplete.
+func (h *Handler) sendConsoleOutput(line []byte, stream environment.OutputStream) error {
+	return h.sendServerConsoleOutput(h.server, h.consoleOutput(line, stream), len(line), &h.throttledWindow)
+}
+
+// sendServerConsoleOutput sends a console output message for the given server,
+// which is not always the server the connection was opened for, using the
+// bandwidth limit and accounting of that server. The window the last throttle
+// notice was sent in is tracked by the caller.
+func (h *Handler) sendServerConsoleOutput(s *server.Server, msg Message, size int, throttled *int64) error {
+	ok, window := s.WebsocketBandwidth().AllowConsole(size)
+	if ok {
+		return h.sendJsonFor(s, msg)
+	}
+	if *throttled == window {
+		return nil
+	}
+	*throttled = window
+	return h.sendJsonFor(s, Message{
+		Event:    ConsoleThrottledEvent,
+		Args:     []string{strconv.FormatUint(s.WebsocketBandwidth().LinesDropped(), 10)},
+		Metadata: msg.Metadata,
+	})
+}
@@ -0,0 +1,125 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/router/tokens"
+	"github.com/pterodactyl/wings/server"
+)
+
+var (
+	ErrBatchUserMismatch  = errors.New("jwt: batch stats token belongs to a different user")
+	ErrBatchServerMissing = errors.New("batch stats server does not exist on this node")
+)
+
+// batchTarget is a single server included in a batch stats subscription along
+// with the token that authorized access to it.
+type batchTarget struct {
+	server *server.Server
+	token  *tokens.WebsocketPayload
+}
+
+// subscribeBatchStats validates a token for each of the servers the client would
+// like to receive stats for and then starts sending a single combined stats frame
+// for all of them at the connection's stats interval. Any existing batch
+// subscription for the connection is replaced. Passing no tokens stops sending
+// batch stats entirely.
+func (h *Handler) subscribeBatchStats(ctx context.Context, jwts []string) error {
+	targets, err := h.authorizeBatchTargets(jwts)
+	if err != nil {
+		return err
+	}
+
+	h.Lock()
+	if h.batchCancel != nil {
+		h.batchCancel()
+		h.batchCancel = nil
+	}
+	interval := h.statsInterval
+	if len(targets) > 0 {
+		var bctx context.Context
+		bctx, h.batchCancel = context.WithCancel(ctx)
+		go h.streamBatchStats(bctx, targets, interval)
+	}
+	h.Unlock()
+
+	return nil
+}
+
+// authorizeBatchTargets parses each of the provided tokens and returns the
+// servers they grant access to. Every token must be valid, belong to the user
+// this connection is authenticated as, and be for a server on this node.
+func (h *Handler) authorizeBatchTargets(jwts []string) ([]batchTarget, error) {
+	user := h.GetJwt().UserUUID
+	targets := make([]batchTarget, 0, len(jwts))
+	for _, j := range jwts {
+		token, err := NewTokenPayload([]byte(j))
+		if err != nil {
+			return nil, err
+		}
+		if token.UserUUID != user {
+			return nil, ErrBatchUserMismatch
+		}
+		s, ok := h.manager.Get(token.GetServerUuid())
+		if !ok {
+			return nil, errors.WithMessage(ErrBatchServerMissing, token.GetServerUuid())
+		}
+		if err := ValidateToken(s, token); err != nil {
+			return nil, err
+		}
+		targets = append(targets, batchTarget{server: s, token: token})
+	}
+	return targets, nil
+}
+
+// streamBatchStats sends the combined stats for all the target servers at the
+// given interval until the context is canceled. Every token is checked again on
+// each tick, servers are dropped from the frame once the token that authorized
+// them is no longer valid, and the stream stops when none are left or the token
+// for the connection itself is no longer valid.
+func (h *Handler) streamBatchStats(ctx context.Context, targets []batchTarget, interval time.Duration) {
+	if interval < statsCollectionInterval {
+		interval = statsCollectionInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.TokenValid(); err != nil {
+				return
+			}
+
+			valid := targets[:0]
+			stats := make(map[string]json.RawMessage, len(targets))
+			for _, t := range targets {
+				if err := ValidateToken(t.server, t.token); err != nil {
+					continue
+				}
+				valid = append(valid, t)
+				b, err := json.Marshal(t.server.Proc())
+				if err != nil {
+					continue
+				}
+				stats[t.server.ID()] = b
+			}
+			targets = valid
+			if len(targets) == 0 {
+				return
+			}
+
+			b, err := json.Marshal(stats)
+			if err != nil {
+				continue
+			}
+			_ = h.SendJson(Message{Event: BatchStatsEvent, Args: []string{string(b)}})
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package websocket
+
+import (
+	"net"
+
+	"emperror.dev/errors"
+	"github.com/apex/log"
+	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/server"
+)
+
+// The close codes that are a normal way for a connection to be closed, these are
+// logged at the debug level unless configured otherwise.
+var expectedCloseCodes = []int{
+	websocket.CloseGoingAway,
+	websocket.CloseAbnormalClosure,
+	websocket.CloseNormalClosure,
+	websocket.CloseNoStatusReceived,
+	websocket.CloseServiceRestart,
+}
+
+// WebsocketClosed is sent with a server.WebsocketClosedEvent when a connection
+// closes with a close code configured to publish one.
+type WebsocketClosed struct {
+	Connection string `json:"connection"`
+	Code       int    `json:"code"`
+	Reason     string `json:"reason"`
+}
+
+// closeHandling returns how a connection closing with the given code is handled,
+// using the configuration for the code if there is one.
+func closeHandling(code int) config.WebsocketCloseHandling {
+	if c, ok := config.Get().System.Websocket.CloseCodes[code]; ok {
+		return c
+	}
+	for _, expected := range expectedCloseCodes {
+		if code == expected {
+			return config.WebsocketCloseHandling{Log: "debug"}
+		}
+	}
+	return config.WebsocketCloseHandling{Log: "warn"}
+}
+
+// closeCode returns the close code and reason for the error that ended the read
+// loop for a connection. A connection that was closed from this side, or that
+// timed out, is treated the same as one that ended without a close frame.
+// Returns false if the error is not from a connection closing.
+func closeCode(err error) (int, string, bool) {
+	var closeErr *websocket.CloseError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &closeErr):
+		return closeErr.Code, closeErr.Text, true
+	case errors.Is(err, ErrMessageTooLarge):
+		return websocket.CloseMessageTooBig, "message too large", true
+	case errors.Is(err, net.ErrClosed):
+		return websocket.CloseAbnormalClosure, "connection closed", true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return websocket.CloseAbnormalClosure, "connection timed out", true
+	}
+	return 0, "", false
+}
+
+// HandleClose handles the error that ended the read loop for the connection. If
+// the connection was closed the close code and the reason the client gave are
+// logged at the level configured for the code, and a websocket closed event is
+// published for the server if configured. Any other error is logged at the
+// debug level.
+func (h *Handler) HandleClose(err error) {
+	code, reason, ok := closeCode(err)
+	if !ok {
+		h.Logger().WithField("error", err).Debug("error handling websocket message for server")
+		return
+	}
+
+	handling := closeHandling(code)
+	logger := h.Logger().WithFields(log.Fields{"code": code, "reason": reason})
+	switch handling.Log {
+	case "none":
+	case "debug":
+		logger.Debug("websocket connection closed")
+	case "info":
+		logger.Info("websocket connection closed")
+	default:
+		logger.Warn("websocket connection closed")
+	}
+
+	if !handling.Event {
+		return
+	}
+	b, err := json.Marshal(WebsocketClosed{Connection: h.uuid.String(), Code: code, Reason: reason})
+	if err != nil {
+		return
+	}
+	h.server.Events().Publish(server.WebsocketClosedEvent, string(b))
+}
@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/internal/models"
+	"github.com/pterodactyl/wings/server"
+)
+
+// The longest capture ID a client can use.
+const maxCaptureIDLength = 64
+
+// The window used when a client does not say how long to capture output for.
+const defaultCaptureWindow = time.Second * 2
+
+var ErrInvalidCapture = errors.New("a capture id and command must be provided")
+
+// validCaptureID returns true if a client can use the ID for a capture. The IDs
+// reserved for Wings share the same namespace, so clients cannot use them.
+func validCaptureID(id string) bool {
+	return id != "" && len(id) <= maxCaptureIDLength && !strings.HasPrefix(id, server.ReservedCaptureIDPrefix)
+}
+
+// captureCommand sends a command to the server and sends the console output that
+// follows it back to the client once the capture is completed. The arguments are
+// the ID the client uses to match up the result, the command, and optionally the
+// number of seconds to capture output for.
+func (h *Handler) captureCommand(ctx context.Context, args []string) error {
+	if len(args) < 2 || !validCaptureID(args[0]) {
+		return ErrInvalidCapture
+	}
+	id, command := args[0], args[1]
+	if err := ValidateCommand(command); err != nil {
+		return err
+	}
+
+	window := defaultCaptureWindow
+	if len(args) > 2 {
+		seconds, err := strconv.Atoi(strings.TrimSpace(args[2]))
+		if err != nil || seconds <= 0 {
+			return ErrInvalidCapture
+		}
+		window = time.Duration(seconds) * time.Second
+	}
+
+	// Commands sent to an offline server are ignored, in the same way as they are
+	// for the send command event.
+	if h.server.Environment.State() == environment.ProcessOfflineState {
+		return nil
+	}
+
+	go func() {
+		capture, err := h.server.CaptureCommand(ctx, id, command, window)
+		if err != nil {
+			if !errors.Is(err, server.ErrTooManyCaptures) && !errors.Is(err, server.ErrCaptureExists) {
+				h.Logger().WithField("error", err).Warn("failed to capture command output")
+			}
+			m, _ := h.GetErrorMessage(err.Error())
+			_ = h.SendJson(Message{Event: ErrorEvent, Args: []string{m}})
+			return
+		}
+		b, err := json.Marshal(capture)
+		if err != nil {
+			return
+		}
+		_ = h.SendJson(Message{Event: CommandCaptureEvent, Args: []string{string(b)}})
+	}()
+
+	h.server.SaveActivity(h.ra, server.ActivityConsoleCommand, models.ActivityMeta{
+		"command": command,
+	})
+
+	return nil
+}
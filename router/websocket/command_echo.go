@@ -0,0 +1,48 @@
+package websocket
+
+import (
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/server"
+)
+
+// The stream sent with echoed commands to clients using the ConsoleStreamProtocol
+// so that they can tell them apart from output written by the server.
+const commandEchoStream = environment.OutputStream("stdin")
+
+var ErrInvalidCommandEcho = errors.New("command echo must be either \"true\" or \"false\"")
+
+// setCommandEcho sets if commands sent from this connection are echoed back to
+// it as console output. This is off by default since many games already echo
+// commands themselves, which would otherwise show every command twice.
+func (h *Handler) setCommandEcho(v string) error {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		return ErrInvalidCommandEcho
+	}
+	h.Lock()
+	h.echoCommands = enabled
+	h.Unlock()
+	return nil
+}
+
+// EchoCommand sends a command that was sent to the server back to this
+// connection as a line of console output, prefixed so that it is clearly marked
+// as user input. The echo is only sent to this connection, never to anyone else
+// watching the console, and is not added to the console history. Nothing is sent
+// if the connection has not enabled echoing or cannot see the console.
+func (h *Handler) EchoCommand(command string) error {
+	h.RLock()
+	enabled := h.echoCommands
+	h.RUnlock()
+	if !enabled || !h.isSubscribed(server.ConsoleOutputEvent) || !h.hasEventPermission(server.ConsoleOutputEvent) {
+		return nil
+	}
+	line := config.Get().System.Websocket.CommandEchoPrefix + command
+	return h.SendJson(h.consoleOutput([]byte(line), commandEchoStream))
+}
@@ -0,0 +1,84 @@
+package websocket
+
+import (
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/server"
+	"github.com/pterodactyl/wings/system"
+)
+
+var ErrCommandRateLimited = errors.New("commands are being sent too quickly, please slow down")
+
+// commandRateLimit returns the command rate limit in effect right now.
+func commandRateLimit() (uint64, time.Duration) {
+	_, limit, period := config.Get().System.Websocket.CommandRateLimit.At(time.Now().In(config.Get().System.GetLocation()))
+	return limit, period
+}
+
+// allowCommand returns false if the connection has sent too many commands to
+// the server recently. When the limit is shared between a user's connections the
+// limiter is acquired from the server the first time a command is sent, and
+// released by ReleaseCommandLimit.
+//
+// The limit of the limiter is updated whenever a different limit comes into use,
+// such as when the configuration changes or another rate limit profile starts,
+// and commands sent before that do not count towards the new limit.
+func (h *Handler) allowCommand() bool {
+	limit, period := commandRateLimit()
+	if limit == 0 {
+		return true
+	}
+
+	key := ""
+	if config.Get().System.Websocket.CommandRateLimit.PerUser {
+		if j := h.GetJwt(); j != nil {
+			key = j.UserUUID
+		}
+	}
+
+	h.Lock()
+	if h.commandRate == nil || h.commandRateUser != key {
+		if h.commandRateUser != "" {
+			h.server.CommandLimits().Release(h.commandRateUser)
+		}
+		if key != "" {
+			h.commandRate = h.server.CommandLimits().Acquire(key, limit, period)
+		} else {
+			h.commandRate = system.NewRate(limit, period)
+		}
+		h.commandRateUser = key
+	}
+	rate := h.commandRate
+	h.Unlock()
+
+	rate.SetLimit(limit, period)
+	return rate.Try()
+}
+
+// AllowUserCommand returns false if the user has sent too many commands to the
+// server recently. This is used for commands sent without a websocket connection.
+// When the limit is shared between a user's connections the same limiter is used
+// so that the API cannot be used to get around it, otherwise the API has its own
+// limiter for the user in the same way as another connection would.
+func AllowUserCommand(s *server.Server, user string) bool {
+	limit, period := commandRateLimit()
+	if limit == 0 {
+		return true
+	}
+	return s.CommandLimits().Allow(user, limit, period)
+}
+
+// ReleaseCommandLimit releases the rate limiter shared with the user's other
+// connections. This should be called once the connection has closed.
+func (h *Handler) ReleaseCommandLimit() {
+	h.Lock()
+	defer h.Unlock()
+	if h.commandRateUser != "" {
+		h.server.CommandLimits().Release(h.commandRateUser)
+		h.commandRateUser = ""
+	}
+	h.commandRate = nil
+}
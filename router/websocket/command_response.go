@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/internal/models"
+	"github.com/pterodactyl/wings/server"
+)
+
+// The longest response pattern a client can send.
+const maxResponsePatternLength = 512
+
+// The time to wait for a response when a client does not say how long to wait.
+const defaultResponseTimeout = time.Second * 5
+
+var (
+	ErrInvalidCommandResponse = errors.New("an id, command, and response pattern must be provided")
+	ErrInvalidResponsePattern = errors.New("the response pattern is not a valid regular expression")
+)
+
+// awaitCommandResponse sends a command to the server and sends the first line of
+// console output matching a pattern back to the client. The arguments are the ID
+// the client uses to match up the response, the command, the regular expression
+// the response must match, and optionally the number of seconds to wait for it.
+//
+// The response event is sent whether or not a line matched, so a client can
+// always tell when the wait is over.
+func (h *Handler) awaitCommandResponse(ctx context.Context, args []string) error {
+	if len(args) < 3 || !validCaptureID(args[0]) || args[2] == "" {
+		return ErrInvalidCommandResponse
+	}
+	id, command := args[0], args[1]
+	if err := ValidateCommand(command); err != nil {
+		return err
+	}
+	if len(args[2]) > maxResponsePatternLength {
+		return ErrInvalidResponsePattern
+	}
+	pattern, err := regexp.Compile(args[2])
+	if err != nil {
+		return ErrInvalidResponsePattern
+	}
+
+	timeout := defaultResponseTimeout
+	if len(args) > 3 {
+		seconds, err := strconv.Atoi(strings.TrimSpace(args[3]))
+		if err != nil || seconds <= 0 {
+			return ErrInvalidCommandResponse
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	// Commands sent to an offline server are ignored, in the same way as they are
+	// for the send command event.
+	if h.server.Environment.State() == environment.ProcessOfflineState {
+		return nil
+	}
+
+	go func() {
+		res, err := h.server.AwaitCommandResponse(ctx, id, command, pattern, timeout)
+		if err != nil {
+			if !errors.Is(err, server.ErrTooManyCaptures) && !errors.Is(err, server.ErrCaptureExists) {
+				h.Logger().WithField("error", err).Warn("failed to wait on command response")
+			}
+			m, _ := h.GetErrorMessage(err.Error())
+			_ = h.SendJson(Message{Event: ErrorEvent, Args: []string{m}})
+			return
+		}
+		b, err := json.Marshal(res)
+		if err != nil {
+			return
+		}
+		_ = h.SendJson(Message{Event: CommandResponseEvent, Args: []string{string(b)}})
+	}()
+
+	h.server.SaveActivity(h.ra, server.ActivityConsoleCommand, models.ActivityMeta{
+		"command": command,
+	})
+
+	return nil
+}
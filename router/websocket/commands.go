@@ -0,0 +1,112 @@
+package websocket
+
+import (
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/environment/docker"
+	"github.com/pterodactyl/wings/internal/models"
+	"github.com/pterodactyl/wings/router/tokens"
+	"github.com/pterodactyl/wings/server"
+)
+
+var (
+	ErrCommandPermission = errors.New("you do not have permission to send commands to this server")
+	ErrCommandsDisabled  = errors.New("commands are disabled for this server")
+)
+
+// CommandResult is what happened to a command sent to a server.
+type CommandResult struct {
+	// The commands that were sent, which is more than one if a macro was used.
+	Commands []string `json:"commands"`
+	// Set if the server was not running and the commands were queued to be sent
+	// once it starts.
+	Queued bool `json:"queued"`
+}
+
+// CheckCommand returns an error if the user of the token cannot send the command
+// to the server. This is every check made before a console command is sent, no
+// matter where it was sent from, and allow is called to apply the rate limit to
+// the command.
+func CheckCommand(s *server.Server, token *tokens.WebsocketPayload, command string, allow func() bool) error {
+	if token == nil || !token.HasPermission(PermissionSendCommand) {
+		return ErrCommandPermission
+	}
+	// This overrides the permissions of the user entirely, nobody can send
+	// commands to a server that has them disabled.
+	if s.CommandsDisabled() {
+		return ErrCommandsDisabled
+	}
+	if !allow() {
+		return ErrCommandRateLimited
+	}
+	return ValidateCommand(command)
+}
+
+// SendCommand sends a console command that has passed CheckCommand to the server
+// and records it in the activity log. Macros are expanded into their commands,
+// and if the server cannot receive commands they are either queued for when it
+// starts or server.ErrNotRunning is returned, depending on the configuration.
+func SendCommand(s *server.Server, ra server.RequestActivity, command string) (*CommandResult, error) {
+	commands, macro := s.Macro(command)
+	if !macro {
+		commands = []string{command}
+	}
+	meta := models.ActivityMeta{"command": command}
+	if macro {
+		meta["commands"] = commands
+	}
+
+	if !canReceiveCommands(s) {
+		if !config.Get().System.Websocket.QueueOfflineCommands {
+			return nil, server.ErrNotRunning
+		}
+		if err := s.QueueCommands(commands...); err != nil {
+			return nil, err
+		}
+		meta["queued"] = true
+		s.SaveActivity(ra, server.ActivityConsoleCommand, meta)
+		return &CommandResult{Commands: commands, Queued: true}, nil
+	}
+
+	if macro {
+		s.SendCommands(commands)
+	} else if err := s.Environment.SendCommand(command); err != nil {
+		return nil, err
+	}
+	s.SaveActivity(ra, server.ActivityConsoleCommand, meta)
+	return &CommandResult{Commands: commands}, nil
+}
+
+// checkPlayerCountCommand makes the checks for a command sent by this connection
+// before the player count command is sent for it, and records it in the activity
+// log in the same way.
+func (h *Handler) checkPlayerCountCommand(command string) error {
+	if err := CheckCommand(h.server, h.GetJwt(), command, h.allowCommand); err != nil {
+		return err
+	}
+	h.server.SaveActivity(h.ra, server.ActivityConsoleCommand, models.ActivityMeta{
+		"command":      command,
+		"player_count": true,
+	})
+	return nil
+}
+
+// canReceiveCommands returns true if the server process is able to receive
+// commands sent to it.
+func canReceiveCommands(s *server.Server) bool {
+	switch s.Environment.State() {
+	case environment.ProcessOfflineState:
+		return false
+	case environment.ProcessStartingState:
+		// TODO(dane): should probably add a new process state that is "booting environment" or something
+		//  so that we can better handle this and only set the environment to booted once we're attached.
+		//
+		//  Or maybe just an IsBooted function?
+		if e, ok := s.Environment.(*docker.Environment); ok {
+			return e.IsAttached()
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/apex/log"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/server"
+)
+
+var ErrInvalidConsoleTimestamps = errors.New("console timestamps must be either \"true\" or \"false\"")
+
+// The named formats that can be used for console timestamps, anything else is
+// used as a Go time layout.
+const (
+	TimestampFormatRFC3339     = "rfc3339"
+	TimestampFormatRFC3339Nano = "rfc3339nano"
+	TimestampFormatUnix        = "unix"
+	TimestampFormatUnixMilli   = "unix_milli"
+)
+
+// consoleTimestamps is how the timestamps sent with console output to a
+// connection are formatted.
+type consoleTimestamps struct {
+	enabled  bool
+	format   string
+	location *time.Location
+}
+
+// newConsoleTimestamps returns the timestamps configured for the node, which
+// every connection starts with.
+func newConsoleTimestamps(cfg config.WebsocketConsoleTimestamps) consoleTimestamps {
+	loc, err := loadTimezone(cfg.Timezone)
+	if err != nil {
+		log.WithFields(log.Fields{"timezone": cfg.Timezone, "error": err}).Warn("websocket: unknown console timestamp timezone, using UTC")
+	}
+	return consoleTimestamps{enabled: cfg.Enabled, format: cfg.Format, location: loc}
+}
+
+// loadTimezone returns the named timezone, or UTC along with an error if the
+// name is not a timezone known to the system.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC, errors.WithStack(err)
+	}
+	return loc, nil
+}
+
+// stamp formats the time in the connection's timezone and format.
+func (t consoleTimestamps) stamp(now time.Time) string {
+	loc := t.location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now = now.In(loc)
+	switch t.format {
+	case "", TimestampFormatRFC3339:
+		return now.Format(time.RFC3339)
+	case TimestampFormatRFC3339Nano:
+		return now.Format(time.RFC3339Nano)
+	case TimestampFormatUnix:
+		return strconv.FormatInt(now.Unix(), 10)
+	case TimestampFormatUnixMilli:
+		return strconv.FormatInt(now.UnixMilli(), 10)
+	default:
+		return now.Format(t.format)
+	}
+}
+
+// setConsoleTimestamps changes the timestamps sent with console output to this
+// connection. The first argument turns them on or off, and the optional second
+// and third arguments set the timezone and format, leaving the current ones in
+// place when they are empty. An unknown timezone falls back to UTC, and the
+// client is sent a message saying so rather than the change being rejected.
+//
+// Timestamps are only sent to connections using the ConsoleStreamProtocol, since
+// other clients only ever expect a single argument with each line.
+func (h *Handler) setConsoleTimestamps(args []string) error {
+	if len(args) == 0 {
+		return ErrInvalidConsoleTimestamps
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(args[0]))
+	if err != nil {
+		return ErrInvalidConsoleTimestamps
+	}
+
+	h.Lock()
+	t := h.timestamps
+	h.Unlock()
+
+	t.enabled = enabled
+	var unknown string
+	if len(args) > 1 && strings.TrimSpace(args[1]) != "" {
+		name := strings.TrimSpace(args[1])
+		if t.location, err = loadTimezone(name); err != nil {
+			h.Logger().WithFields(log.Fields{"timezone": name, "error": err}).Warn("websocket: unknown console timestamp timezone, using UTC")
+			unknown = name
+		}
+	}
+	if len(args) > 2 && strings.TrimSpace(args[2]) != "" {
+		t.format = strings.TrimSpace(args[2])
+	}
+
+	h.Lock()
+	h.timestamps = t
+	h.Unlock()
+
+	if unknown != "" {
+		return h.SendJson(Message{
+			Event: server.DaemonMessageEvent,
+			Args:  []string{"Unknown timezone \"" + unknown + "\", console timestamps will be in UTC."},
+		})
+	}
+	return nil
+}
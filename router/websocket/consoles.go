@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/server"
+	"github.com/pterodactyl/wings/system"
+)
+
+var (
+	ErrTooManyConsoles     = errors.New("too many servers were requested for a combined console")
+	ErrConsolePermission   = errors.New("jwt: token does not allow receiving the console output of the server")
+	ErrConsoleOwnSubscribe = errors.New("the console output of this connection's server is already being sent")
+)
+
+// subscribeConsoles validates a token for each of the other servers the client
+// would like to receive the console output of, and then sends their output over
+// this connection along with the output of its own server. Lines from the other
+// servers are sent as normal console output with the UUID of the server they
+// came from in the "server" metadata, lines without it are from the server the
+// connection was opened for. Any existing subscription for the connection is
+// replaced, passing no tokens stops sending the output of other servers.
+func (h *Handler) subscribeConsoles(ctx context.Context, jwts []string) error {
+	if max := config.Get().System.Websocket.MaxConsoleServers; len(jwts) > max {
+		return errors.WithMessagef(ErrTooManyConsoles, "at most %d servers can be included", max)
+	}
+	targets, err := h.authorizeBatchTargets(jwts)
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		if t.server.ID() == h.server.ID() {
+			return ErrConsoleOwnSubscribe
+		}
+		if !HasEventPermission(t.token, server.ConsoleOutputEvent) {
+			return errors.WithMessage(ErrConsolePermission, t.server.ID())
+		}
+	}
+
+	h.Lock()
+	if h.consolesCancel != nil {
+		h.consolesCancel()
+		h.consolesCancel = nil
+	}
+	if len(targets) > 0 {
+		var cctx context.Context
+		cctx, h.consolesCancel = context.WithCancel(ctx)
+		for _, t := range targets {
+			go h.streamConsole(cctx, t)
+		}
+	}
+	h.Unlock()
+
+	return nil
+}
+
+// streamConsole sends the console output of another server to the client until
+// the context is canceled, the token that authorized it is no longer valid or
+// allowed to receive the console, or the server is deleted. The token is checked
+// again for each line, and the output counts against the bandwidth of the
+// server it came from.
+func (h *Handler) streamConsole(ctx context.Context, t batchTarget) {
+	size := config.Get().System.Websocket.ConsoleBuffer
+	// Each sink pool closes the channel when it is removed, so a separate channel
+	// is needed for each of them.
+	stdout, stderr := make(chan []byte, size), make(chan []byte, size)
+	if err := t.server.Sink(system.LogSink).On(stdout); err != nil {
+		h.Logger().WithField("target", t.server.ID()).WithField("error", err).Warn("failed to listen for console output of server")
+		return
+	}
+	defer t.server.Sink(system.LogSink).Off(stdout)
+	if err := t.server.Sink(system.ErrorSink).On(stderr); err != nil {
+		h.Logger().WithField("target", t.server.ID()).WithField("error", err).Warn("failed to listen for console output of server")
+		return
+	}
+	defer t.server.Sink(system.ErrorSink).Off(stderr)
+
+	var expired <-chan time.Time
+	if exp := t.token.GetPayload().ExpirationTime; exp != nil {
+		timer := time.NewTimer(time.Until(exp.Time))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	metadata := make(map[string]string, len(h.metadata)+1)
+	for k, v := range h.metadata {
+		metadata[k] = v
+	}
+	metadata["server"] = t.server.ID()
+
+	var throttled int64
+	for {
+		var line []byte
+		var ok bool
+		stream := environment.Stdout
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired:
+			return
+		case line, ok = <-stdout:
+		case line, ok = <-stderr:
+			stream = environment.Stderr
+		}
+		if !ok {
+			return
+		}
+		if err := ValidateToken(t.server, t.token); err != nil {
+			return
+		}
+		if !HasEventPermission(t.token, server.ConsoleOutputEvent) {
+			return
+		}
+		if !h.shouldSendLine(line) {
+			continue
+		}
+		msg := h.consoleOutput(line, stream)
+		msg.Metadata = metadata
+		if err := h.sendServerConsoleOutput(t.server, msg, len(line), &throttled); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+)
+
+// The longest time spent reading container logs for a single request.
+const containerLogsTimeout = time.Second * 30
+
+var ErrInvalidLogTime = errors.New("log range times must be RFC 3339 timestamps or unix timestamps in seconds")
+
+// ContainerLogsEnd is sent once all the lines for a container logs request
+// have been sent.
+type ContainerLogsEnd struct {
+	Lines int `json:"lines"`
+	// Set if there were more lines in the range than the maximum log count and
+	// the rest were not sent.
+	Truncated bool `json:"truncated"`
+}
+
+// parseLogTime parses a time sent by a client for a container logs request,
+// which can either be an RFC 3339 timestamp or a unix timestamp in seconds.
+func parseLogTime(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t, nil
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil && seconds >= 0 {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Time{}, errors.WithMessage(ErrInvalidLogTime, v)
+}
+
+// sendContainerLogs sends the lines the server's container wrote between two
+// times, read from the log kept by Docker. The first argument is the start of the
+// range and the optional second argument is the end of it, if no end is sent
+// everything up to now is sent. Each line is sent with the stream it was written
+// to and when it was written, followed by an end event once they are all
+// sent. At most the configured maximum log count of lines is sent.
+func (h *Handler) sendContainerLogs(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "" {
+		return ErrInvalidLogTime
+	}
+	since, err := parseLogTime(args[0])
+	if err != nil {
+		return err
+	}
+	var until time.Time
+	if len(args) > 1 && args[1] != "" {
+		if until, err = parseLogTime(args[1]); err != nil {
+			return err
+		}
+	}
+
+	h.RLock()
+	t := h.timestamps
+	h.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, containerLogsTimeout)
+	defer cancel()
+
+	var n int
+	truncated, err := h.server.ContainerLogs(ctx, since, until, config.Get().System.Websocket.MaxLogCount, func(entry environment.LogEntry) {
+		if !h.shouldSendLine([]byte(entry.Line)) {
+			return
+		}
+		stamp := entry.Time.UTC().Format(time.RFC3339Nano)
+		if t.enabled {
+			stamp = t.stamp(entry.Time)
+		}
+		n++
+		_ = h.SendJson(Message{Event: ContainerLogsEvent, Args: []string{entry.Line, string(entry.Stream), stamp}})
+	})
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(ContainerLogsEnd{Lines: n, Truncated: truncated})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return h.SendJson(Message{Event: ContainerLogsEndEvent, Args: []string{string(b)}})
+}
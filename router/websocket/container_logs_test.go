@@ -0,0 +1,102 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/gbrlsnchs/jwt/v3"
+	"github.com/goccy/go-json"
+	ws "github.com/gorilla/websocket"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/router/tokens"
+	"github.com/pterodactyl/wings/server"
+)
+
+// logEnvironment is an environment that only supports reading container logs,
+// returning the entries it was created with.
+type logEnvironment struct {
+	environment.ProcessEnvironment
+	entries []environment.LogEntry
+}
+
+func (e *logEnvironment) Logs(_ context.Context, _, _ time.Time, fn func(environment.LogEntry) bool) error {
+	for _, entry := range e.entries {
+		if !fn(entry) {
+			break
+		}
+	}
+	return nil
+}
+
+func TestHandler_SendContainerLogs(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#sendContainerLogs", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.SecretMasking = config.SecretMasking{Values: []string{"hunter2"}, Replacement: "***"}
+			config.Set(c)
+		})
+
+		g.It("masks secrets in every line", func() {
+			now := time.Now()
+			env := &logEnvironment{entries: []environment.LogEntry{
+				{Time: now, Stream: environment.Stdout, Line: "rcon password is hunter2"},
+				{Time: now, Stream: environment.Stderr, Line: "[ERROR] bad login hunter2"},
+			}}
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := (&ws.Upgrader{}).Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				h := &Handler{
+					Connection: conn,
+					server:     &server.Server{Environment: env},
+					jwt: &tokens.WebsocketPayload{
+						Payload: jwt.Payload{
+							IssuedAt:       jwt.NumericDate(now.Add(time.Minute)),
+							NotBefore:      jwt.NumericDate(now.Add(-time.Minute)),
+							ExpirationTime: jwt.NumericDate(now.Add(time.Hour)),
+						},
+						Permissions: []string{PermissionConnect},
+					},
+				}
+				_ = h.sendContainerLogs(context.Background(), []string{"1704189600"})
+				// Wait for the client to close the connection once it has read the
+				// messages.
+				_, _, _ = conn.ReadMessage()
+			}))
+			defer srv.Close()
+
+			conn, _, err := ws.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+			g.Assert(err).IsNil()
+			defer conn.Close()
+
+			var lines []string
+			for {
+				_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+				var m Message
+				g.Assert(conn.ReadJSON(&m)).IsNil()
+				if m.Event == ContainerLogsEndEvent {
+					var end ContainerLogsEnd
+					g.Assert(json.Unmarshal([]byte(m.Args[0]), &end)).IsNil()
+					g.Assert(end.Lines).Equal(2)
+					break
+				}
+				g.Assert(m.Event).Equal(ContainerLogsEvent)
+				g.Assert(strings.Contains(strings.Join(m.Args, " "), "hunter2")).IsFalse()
+				lines = append(lines, m.Args[0])
+			}
+			g.Assert(lines).Equal([]string{"rcon password is ***", "[ERROR] bad login ***"})
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package websocket
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+)
+
+var ErrInvalidDebugLogging = errors.New("debug logging must be either \"true\" or \"false\", optionally followed by a number of seconds")
+
+// setDebugLogging turns debug logging on or off for the server, sending the new
+// state back to the client. The first argument turns it on or off, and the
+// optional second argument is the number of seconds it stays on for.
+func (h *Handler) setDebugLogging(args []string) error {
+	if len(args) == 0 {
+		return ErrInvalidDebugLogging
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(args[0]))
+	if err != nil {
+		return ErrInvalidDebugLogging
+	}
+	var d time.Duration
+	if len(args) > 1 {
+		seconds, err := strconv.Atoi(strings.TrimSpace(args[1]))
+		if err != nil || seconds <= 0 {
+			return ErrInvalidDebugLogging
+		}
+		d = time.Duration(seconds) * time.Second
+	}
+
+	b, err := json.Marshal(h.server.SetDebugLogging(enabled, d))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return h.SendJson(Message{Event: DebugLoggingEvent, Args: []string{string(b)}})
+}
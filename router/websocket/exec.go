@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"context"
+	"sync/atomic"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/internal/models"
+	"github.com/pterodactyl/wings/server"
+)
+
+var (
+	ErrInvalidExec    = errors.New("an exec id and command must be provided")
+	ErrExecInProgress = errors.New("a command is already being run for this connection")
+)
+
+// execCommand runs a command inside the server's container, sending each line
+// of output back to the client as it is written, followed by the result of the
+// command once it has finished. The arguments are the ID the client uses to
+// match up the output and the command to run. Only one command can be run at a
+// time for each connection.
+func (h *Handler) execCommand(ctx context.Context, args []string) error {
+	if len(args) < 2 || args[0] == "" || len(args[0]) > maxCaptureIDLength || args[1] == "" {
+		return ErrInvalidExec
+	}
+	id, command := args[0], args[1]
+	if err := ValidateCommand(command); err != nil {
+		return err
+	}
+	// Check the command can be run before it is saved to the activity log, the
+	// same checks are made again when it is actually run.
+	if err := server.CheckExec(command); err != nil {
+		return err
+	}
+	if !atomic.CompareAndSwapInt32(&h.executing, 0, 1) {
+		return ErrExecInProgress
+	}
+
+	h.server.SaveActivity(h.ra, server.ActivityContainerExec, models.ActivityMeta{
+		"command": command,
+	})
+
+	go func() {
+		defer atomic.StoreInt32(&h.executing, 0)
+
+		res, err := h.server.Exec(ctx, command, func(line []byte, stream environment.OutputStream) {
+			_ = h.SendJson(Message{
+				Event:    ExecOutputEvent,
+				Args:     []string{id, string(stream), string(line)},
+				Metadata: h.metadata,
+			})
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if !errors.Is(err, server.ErrExecDisabled) && !errors.Is(err, server.ErrExecNotAllowed) && !errors.Is(err, server.ErrExecNotRunning) {
+				h.Logger().WithField("error", err).Warn("failed to run command in container")
+			}
+			m, _ := h.GetErrorMessage(err.Error())
+			_ = h.SendJson(Message{Event: ErrorEvent, Args: []string{m}})
+			return
+		}
+		b, err := json.Marshal(res)
+		if err != nil {
+			return
+		}
+		_ = h.SendJson(Message{Event: ExecCompletedEvent, Args: []string{id, string(b)}, Metadata: h.metadata})
+	}()
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/server/filesystem"
+)
+
+const (
+	// The interval at which file changes are sent to the client. Changes made
+	// between each interval are combined into a single message.
+	fileChangesInterval = time.Second
+
+	// The largest number of changes sent in a single message. Anything beyond this
+	// is dropped, and the number dropped is sent instead.
+	maxFileChanges = 100
+)
+
+// fileChanges is the payload sent with a FileChangesEvent.
+type fileChanges struct {
+	Changes []filesystem.Change `json:"changes"`
+	Dropped int                 `json:"dropped"`
+}
+
+// watchFiles starts sending the changes made to files in the given directory of
+// the server to the client, replacing any directory that is already being
+// watched for this connection.
+func (h *Handler) watchFiles(ctx context.Context, dir string) error {
+	h.unwatchFiles()
+
+	wctx, cancel := context.WithCancel(ctx)
+	changes, err := h.server.Filesystem().Watch(wctx, dir)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	h.Lock()
+	h.watchCancel = cancel
+	h.Unlock()
+
+	go h.streamFileChanges(wctx, changes)
+
+	return nil
+}
+
+// unwatchFiles stops sending file changes to the client.
+func (h *Handler) unwatchFiles() {
+	h.Lock()
+	defer h.Unlock()
+	if h.watchCancel != nil {
+		h.watchCancel()
+		h.watchCancel = nil
+	}
+}
+
+// streamFileChanges collects the changes from the channel and sends them to the
+// client at a fixed interval until the channel is closed. Repeated changes to a
+// file within the same interval are only sent once.
+func (h *Handler) streamFileChanges(ctx context.Context, changes <-chan filesystem.Change) {
+	ticker := time.NewTicker(fileChangesInterval)
+	defer ticker.Stop()
+
+	seen := make(map[filesystem.Change]bool)
+	pending := fileChanges{Changes: []filesystem.Change{}}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c, ok := <-changes:
+			if !ok {
+				return
+			}
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			if len(pending.Changes) >= maxFileChanges {
+				pending.Dropped++
+				continue
+			}
+			pending.Changes = append(pending.Changes, c)
+		case <-ticker.C:
+			if len(seen) == 0 {
+				continue
+			}
+			// Stop sending changes once the token has expired or no longer has
+			// permission to see them, but keep the watch running in case the client
+			// refreshes the token.
+			if h.TokenValid() == nil && h.GetJwt().HasPermission(PermissionReadFiles) {
+				b, _ := json.Marshal(pending)
+				_ = h.SendJson(Message{Event: FileChangesEvent, Args: []string{string(b)}})
+			}
+			seen = make(map[filesystem.Change]bool)
+			pending = fileChanges{Changes: []filesystem.Change{}}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package websocket
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// setKeepalive turns on TCP keepalive for the connection underneath a websocket.
+// Connections that are not TCP, such as those over a unix socket, are left
+// alone.
+func setKeepalive(conn net.Conn, cfg config.WebsocketKeepalive) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	tcp, ok := tcpConn(conn)
+	if !ok {
+		return nil
+	}
+	if err := tcp.SetKeepAlive(true); err != nil {
+		return errors.Wrap(err, "websocket: failed to enable keepalive")
+	}
+	// This sets both the idle time and the interval, so the interval has to be
+	// set again afterwards.
+	if cfg.Idle > 0 {
+		if err := tcp.SetKeepAlivePeriod(time.Duration(cfg.Idle) * time.Second); err != nil {
+			return errors.Wrap(err, "websocket: failed to set keepalive idle time")
+		}
+	}
+	if cfg.Interval > 0 {
+		if err := setKeepaliveInterval(tcp, cfg.Interval); err != nil {
+			return errors.Wrap(err, "websocket: failed to set keepalive interval")
+		}
+	}
+	return nil
+}
+
+// tcpConn returns the TCP connection underneath the connection, if there is one.
+func tcpConn(conn net.Conn) (*net.TCPConn, bool) {
+	switch c := conn.(type) {
+	case *net.TCPConn:
+		return c, true
+	case *tls.Conn:
+		return tcpConn(c.NetConn())
+	}
+	return nil, false
+}
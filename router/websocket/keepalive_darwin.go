@@ -0,0 +1,11 @@
+package websocket
+
+import (
+	"net"
+)
+
+// setKeepaliveInterval is a no-op on macOS, where the interval between probes
+// is the same as the idle time.
+func setKeepaliveInterval(_ *net.TCPConn, _ int) error {
+	return nil
+}
@@ -0,0 +1,21 @@
+package websocket
+
+import (
+	"net"
+	"syscall"
+)
+
+// setKeepaliveInterval sets the number of seconds between each keepalive probe.
+func setKeepaliveInterval(c *net.TCPConn, seconds int) error {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, seconds)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
@@ -7,7 +7,10 @@ import (
 
 	"emperror.dev/errors"
 	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
 
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
 	"github.com/pterodactyl/wings/events"
 	"github.com/pterodactyl/wings/system"
 
@@ -29,6 +32,12 @@ func (h *Handler) registerListenerEvents(ctx context.Context) {
 
 	go func() {
 		if err := h.listenForServerEvents(ctx); err != nil {
+			if errors.Is(err, system.ErrSinkLimitReached) {
+				h.Logger().WithField("limit", config.Get().System.Websocket.MaxListeners).
+					Error("maximum number of event listeners reached for server; this likely indicates listeners are leaking, refusing websocket connection")
+				h.CloseWithReason(websocket.CloseTryAgainLater, server.NewCloseReason(server.CloseReasonTooManyConnections, "too many connections to this server").String())
+				return
+			}
 			h.Logger().Warn("error while processing server event; closing websocket connection")
 			if err := h.Connection.Close(); err != nil {
 				h.Logger().WithField("error", errors.WithStack(err)).Error("error closing websocket connection")
@@ -69,15 +78,42 @@ func (h *Handler) listenForExpiration(ctx context.Context) {
 var e = []string{
 	server.StatsEvent,
 	server.StatusEvent,
+	server.StatusSummaryEvent,
+	server.RestartPendingEvent,
+	server.ContainerRecreateEvent,
+	server.IdleStoppedEvent,
+	server.DiskUsageCalculatedEvent,
+	server.WebsocketClosedEvent,
 	server.ConsoleOutputEvent,
 	server.InstallOutputEvent,
 	server.InstallStartedEvent,
 	server.InstallCompletedEvent,
 	server.DaemonMessageEvent,
 	server.BackupCompletedEvent,
+	server.BackupProgressEvent,
 	server.BackupRestoreCompletedEvent,
 	server.TransferLogsEvent,
 	server.TransferStatusEvent,
+	server.LimitsEvent,
+	server.StartupTimeoutEvent,
+	server.BackendStatusEvent,
+	server.OutputStalledEvent,
+	server.AutoStartEvent,
+	server.ConfigurationReloadedEvent,
+	server.ResourceLimitEvent,
+	server.ImagePullProgressEvent,
+	server.ImagePullFailedEvent,
+}
+
+// isServerEvent returns true if the event is one that originates from the
+// server and can therefore be subscribed to.
+func isServerEvent(event string) bool {
+	for _, evt := range e {
+		if evt == event {
+			return true
+		}
+	}
+	return false
 }
 
 // ListenForServerEvents will listen for different events happening on a server
@@ -90,13 +126,42 @@ func (h *Handler) listenForServerEvents(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	size := config.Get().System.Websocket.ConsoleBuffer
+	if size <= 0 {
+		size = 8
+	}
 	eventChan := make(chan []byte)
-	logOutput := make(chan []byte, 8)
+	logOutput := make(chan []byte, size)
+	errorOutput := make(chan []byte, size)
 	installOutput := make(chan []byte, 4)
 
-	h.server.Events().On(eventChan) // TODO: make a sinky
-	h.server.Sink(system.LogSink).On(logOutput)
-	h.server.Sink(system.InstallSink).On(installOutput)
+	// These functions will automatically close the channel if it hasn't been already.
+	defer func() {
+		h.server.Events().Off(eventChan)
+		h.server.Sink(system.LogSink).Off(logOutput)
+		h.server.Sink(system.ErrorSink).Off(errorOutput)
+		h.server.Sink(system.InstallSink).Off(installOutput)
+	}()
+
+	// Only register the console and install sinks if the client actually wants
+	// that output and is allowed to see it, these are by far the highest volume
+	// listeners on a server.
+	if err := h.server.Events().On(eventChan); err != nil { // TODO: make a sinky
+		return errors.WithStack(err)
+	}
+	if h.isSubscribed(server.ConsoleOutputEvent) && h.hasEventPermission(server.ConsoleOutputEvent) {
+		if err := h.server.Sink(system.LogSink).On(logOutput); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := h.server.Sink(system.ErrorSink).On(errorOutput); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if h.isSubscribed(server.InstallOutputEvent) && h.hasEventPermission(server.InstallOutputEvent) {
+		if err := h.server.Sink(system.InstallSink).On(installOutput); err != nil {
+			return errors.WithStack(err)
+		}
+	}
 
 	onError := func(evt string, err2 error) {
 		h.Logger().WithField("event", evt).WithField("error", err2).Error("failed to send event over server websocket")
@@ -114,13 +179,25 @@ func (h *Handler) listenForServerEvents(ctx context.Context) error {
 		case <-ctx.Done():
 			break
 		case b := <-logOutput:
-			sendErr := h.SendJson(Message{Event: server.ConsoleOutputEvent, Args: []string{string(b)}})
+			if !h.shouldSendLine(b) {
+				continue
+			}
+			sendErr := h.sendConsoleOutput(b, environment.Stdout)
+			if sendErr == nil {
+				continue
+			}
+			onError(server.ConsoleOutputEvent, sendErr)
+		case b := <-errorOutput:
+			if !h.shouldSendLine(b) {
+				continue
+			}
+			sendErr := h.sendConsoleOutput(b, environment.Stderr)
 			if sendErr == nil {
 				continue
 			}
 			onError(server.ConsoleOutputEvent, sendErr)
 		case b := <-installOutput:
-			sendErr := h.SendJson(Message{Event: server.InstallOutputEvent, Args: []string{string(b)}})
+			sendErr := h.SendJson(Message{Event: server.InstallOutputEvent, Args: []string{string(b)}, Metadata: h.metadata})
 			if sendErr == nil {
 				continue
 			}
@@ -130,10 +207,18 @@ func (h *Handler) listenForServerEvents(ctx context.Context) error {
 			if err := events.DecodeTo(b, &e); err != nil {
 				continue
 			}
+			if !h.isSubscribed(e.Topic) {
+				continue
+			}
+			if e.Topic == server.StatsEvent && !h.shouldSendStats() {
+				continue
+			}
 			var sendErr error
-			message := Message{Event: e.Topic}
+			message := Message{Event: e.Topic, Metadata: h.metadata}
 			if str, ok := e.Data.(string); ok {
 				message.Args = []string{str}
+			} else if args, ok := stringArgs(e.Data); ok {
+				message.Args = args
 			} else if b, ok := e.Data.([]byte); ok {
 				message.Args = []string{string(b)}
 			} else {
@@ -154,11 +239,6 @@ func (h *Handler) listenForServerEvents(ctx context.Context) error {
 		break
 	}
 
-	// These functions will automatically close the channel if it hasn't been already.
-	h.server.Events().Off(eventChan)
-	h.server.Sink(system.LogSink).Off(logOutput)
-	h.server.Sink(system.InstallSink).Off(installOutput)
-
 	// If the internal context is stopped it is either because the parent context
 	// got canceled or because we ran into an error. If the "err" variable is nil
 	// we can assume the parent was canceled and need not perform any actions.
@@ -168,3 +248,36 @@ func (h *Handler) listenForServerEvents(ctx context.Context) error {
 
 	return nil
 }
+
+// stringArgs returns the event data as a slice of strings if it was published
+// as one, such as the state and reason sent with a status event.
+func stringArgs(data interface{}) ([]string, bool) {
+	v, ok := data.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	args := make([]string, len(v))
+	for i, arg := range v {
+		str, ok := arg.(string)
+		if !ok {
+			return nil, false
+		}
+		args[i] = str
+	}
+	return args, true
+}
+
+// consoleOutput returns the message used to send a line of console output to
+// the client, including the stream it was written to if the client asked for it.
+func (h *Handler) consoleOutput(line []byte, stream environment.OutputStream) Message {
+	if !h.consoleStreams {
+		return Message{Event: server.ConsoleOutputEvent, Args: []string{string(line)}, Metadata: h.metadata}
+	}
+	h.RLock()
+	t := h.timestamps
+	h.RUnlock()
+	if !t.enabled {
+		return Message{Event: server.ConsoleOutputEvent, Args: []string{string(line), string(stream)}, Metadata: h.metadata}
+	}
+	return Message{Event: server.ConsoleOutputEvent, Args: []string{string(line), string(stream), t.stamp(time.Now())}, Metadata: h.metadata}
+}
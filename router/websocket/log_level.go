@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/apex/log"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+var ErrInvalidLogLevel = errors.New("log level must be one of trace, debug, info, warn, error, or fatal")
+
+// The pattern used to find the level of a line of console output when one is not
+// configured. This matches the level as its own word, optionally wrapped in
+// brackets, such as "[12:00:00 INFO]: ..." or "[Server thread/WARN]: ...".
+const defaultLogLevelPattern = `(?i)(?:^|[\[\s/|(])(trace|finest|finer|fine|debug|info|notice|warning|warn|error|err|severe|fatal|critical)(?:[\]\s:|)]|$)`
+
+// The level each of the names matched in console output is treated as. Names
+// used by Java's logging are mapped to the closest of the common levels.
+var logLevels = map[string]int{
+	"trace":    1,
+	"finest":   1,
+	"finer":    1,
+	"fine":     2,
+	"debug":    2,
+	"info":     3,
+	"notice":   3,
+	"warn":     4,
+	"warning":  4,
+	"error":    5,
+	"err":      5,
+	"severe":   5,
+	"fatal":    6,
+	"critical": 6,
+}
+
+var logLevelPattern struct {
+	sync.Mutex
+	source string
+	re     *regexp.Regexp
+}
+
+// getLogLevelPattern returns the compiled pattern used to find the level of a
+// line of console output. The pattern is only compiled again if the configured
+// pattern is changed, and the default is used if it cannot be compiled.
+func getLogLevelPattern() *regexp.Regexp {
+	source := config.Get().System.Websocket.LogLevelPattern
+	if source == "" {
+		source = defaultLogLevelPattern
+	}
+
+	logLevelPattern.Lock()
+	defer logLevelPattern.Unlock()
+	if logLevelPattern.re != nil && logLevelPattern.source == source {
+		return logLevelPattern.re
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		log.WithFields(log.Fields{"pattern": source, "error": err}).Warn("websocket: invalid log level pattern, using the default")
+		re = regexp.MustCompile(defaultLogLevelPattern)
+	}
+	logLevelPattern.source = source
+	logLevelPattern.re = re
+	return re
+}
+
+// setLogLevel sets the minimum level of console output sent to this connection.
+// An empty level, or "all", sends every line again.
+func (h *Handler) setLogLevel(level string) error {
+	level = strings.ToLower(level)
+
+	var min int
+	if level != "" && level != "all" {
+		var ok bool
+		if min, ok = logLevels[level]; !ok {
+			return errors.WithMessage(ErrInvalidLogLevel, level)
+		}
+	}
+
+	// The pattern is looked up once here rather than for every line, so a change
+	// to the configured pattern applies the next time the level is set.
+	var re *regexp.Regexp
+	if min > 0 {
+		re = getLogLevelPattern()
+	}
+
+	h.Lock()
+	h.minLogLevel = min
+	h.logLevelPattern = re
+	h.Unlock()
+
+	return nil
+}
+
+// shouldSendLine returns false if a line of console output is below the minimum
+// level the client asked for. This is only a best guess since servers log in all
+// kinds of formats, so any line without a recognizable level is always sent.
+func (h *Handler) shouldSendLine(line []byte) bool {
+	h.RLock()
+	min, re := h.minLogLevel, h.logLevelPattern
+	h.RUnlock()
+
+	if min == 0 || re == nil {
+		return true
+	}
+	level, ok := lineLogLevel(re, line)
+	return !ok || level >= min
+}
+
+// lineLogLevel returns the level of a line of console output using the first
+// match of the pattern. If the pattern has a capture group the first group is
+// used as the name of the level, otherwise the entire match is.
+func lineLogLevel(re *regexp.Regexp, line []byte) (int, bool) {
+	m := re.FindSubmatch(line)
+	if m == nil {
+		return 0, false
+	}
+	name := m[0]
+	if len(m) > 1 {
+		name = m[1]
+	}
+	level, ok := logLevels[strings.ToLower(strings.TrimSpace(string(name)))]
+	return level, ok
+}
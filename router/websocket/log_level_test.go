@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"regexp"
+	"testing"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+func TestLogLevel(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("lineLogLevel", func() {
+		re := regexp.MustCompile(defaultLogLevelPattern)
+
+		g.It("finds the level in common log formats", func() {
+			for line, want := range map[string]int{
+				"[12:00:00 INFO]: Done (1.234s)!":                logLevels["info"],
+				"[12:00:00] [Server thread/WARN]: Can't keep up": logLevels["warn"],
+				"2023-01-01 12:00:00 DEBUG something happened":   logLevels["debug"],
+				"[SEVERE] java.lang.NullPointerException":        logLevels["error"],
+				"error: could not bind to port":                  logLevels["error"],
+			} {
+				level, ok := lineLogLevel(re, []byte(line))
+				g.Assert(ok).IsTrue(line)
+				g.Assert(level).Equal(want, line)
+			}
+		})
+
+		g.It("does not find a level in lines without one", func() {
+			for _, line := range []string{"Player joined the game", "information about the server", "terrors"} {
+				_, ok := lineLogLevel(re, []byte(line))
+				g.Assert(ok).IsFalse(line)
+			}
+		})
+	})
+
+	g.Describe("Handler#shouldSendLine", func() {
+		g.BeforeEach(func() {
+			config.Set(&config.Configuration{AuthenticationToken: "test"})
+		})
+
+		g.It("sends every line when no level is set", func() {
+			h := &Handler{}
+			g.Assert(h.shouldSendLine([]byte("[DEBUG] noisy"))).IsTrue()
+		})
+
+		g.It("only sends lines at or above the level", func() {
+			h := &Handler{}
+			g.Assert(h.setLogLevel("WARN")).IsNil()
+			g.Assert(h.shouldSendLine([]byte("[DEBUG] noisy"))).IsFalse()
+			g.Assert(h.shouldSendLine([]byte("[INFO] normal"))).IsFalse()
+			g.Assert(h.shouldSendLine([]byte("[WARN] careful"))).IsTrue()
+			g.Assert(h.shouldSendLine([]byte("[ERROR] broken"))).IsTrue()
+		})
+
+		g.It("always sends lines without a level", func() {
+			h := &Handler{}
+			g.Assert(h.setLogLevel("error")).IsNil()
+			g.Assert(h.shouldSendLine([]byte("> say hello"))).IsTrue()
+		})
+
+		g.It("sends every line again once the level is cleared", func() {
+			h := &Handler{}
+			g.Assert(h.setLogLevel("error")).IsNil()
+			g.Assert(h.setLogLevel("all")).IsNil()
+			g.Assert(h.shouldSendLine([]byte("[DEBUG] noisy"))).IsTrue()
+		})
+
+		g.It("rejects unknown levels", func() {
+			h := &Handler{}
+			g.Assert(errors.Is(h.setLogLevel("verbose"), ErrInvalidLogLevel)).IsTrue()
+		})
+	})
+}
@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/server"
+)
+
+// LogsFailed is sent when the recent console output for a server could not be
+// read in full.
+type LogsFailed struct {
+	Message string `json:"message"`
+	// Set if the error may not happen again, so the client can ask for the logs
+	// again. Otherwise, the logs cannot be read until something changes on the
+	// node, such as the container being created again.
+	Transient bool `json:"transient"`
+	// The number of lines that were read, and sent, before the error.
+	Lines int `json:"lines"`
+}
+
+// sendLogsFailed lets the client know that the recent console output could not
+// be read, after sending any lines that were read before the error. This does
+// not close the connection, so live console output is still sent.
+func (h *Handler) sendLogsFailed(err error, lines int) {
+	transient := server.IsTransientLogError(err)
+	logger := h.Logger().WithField("error", err).WithField("lines", lines)
+	if transient {
+		logger.Warn("failed to read recent console logs for websocket connection")
+	} else {
+		logger.Debug("recent console logs are not available for websocket connection")
+	}
+
+	msg := "The recent console output could not be read, live output will still be shown."
+	if h.GetJwt().HasPermission(PermissionReceiveErrors) {
+		msg, _ = h.GetErrorMessage(err.Error())
+	}
+	b, err := json.Marshal(LogsFailed{Message: msg, Transient: transient, Lines: lines})
+	if err != nil {
+		return
+	}
+	_ = h.SendJson(Message{Event: LogsFailedEvent, Args: []string{string(b)}})
+}
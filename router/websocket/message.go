@@ -5,14 +5,81 @@ const (
 	TokenExpiringEvent         = "token expiring"
 	TokenExpiredEvent          = "token expired"
 	AuthenticationEvent        = "auth"
+	ReconnectEvent             = "auth reconnect"
+	ReconnectTokenEvent        = "reconnect token"
+	PermissionsUpdatedEvent    = "permissions updated"
 	SetStateEvent              = "set state"
+	SendPowerActionsEvent      = "send power actions"
 	SendServerLogsEvent        = "send logs"
+	LogsFailedEvent            = "logs failed"
+	SendContainerLogsEvent     = "send container logs"
+	ContainerLogsEvent         = "container logs"
+	ContainerLogsEndEvent      = "container logs end"
 	SendCommandEvent           = "send command"
+	CaptureCommandEvent        = "capture command"
+	CommandCaptureEvent        = "command capture"
+	AwaitCommandResponseEvent  = "await command response"
+	CommandResponseEvent       = "command response"
+	CommandQueuedEvent         = "command queued"
+	ConsoleThrottledEvent      = "console throttled"
+	ExecEvent                  = "exec"
+	ExecOutputEvent            = "exec output"
+	ExecCompletedEvent         = "exec completed"
+	SendProcessesEvent         = "send processes"
+	ProcessesEvent             = "processes"
+	SendLogFileEvent           = "send log file"
+	SendInstallLogEvent        = "send install log"
+	InstallLogEvent            = "install log"
+	ResizeEvent                = "resize"
 	SendStatsEvent             = "send stats"
+	SendStatsTrendEvent        = "send stats trend"
+	SendStatsSnapshotEvent     = "send stats snapshot"
+	SubscribeEvent             = "subscribe"
+	SendSubscriptionsEvent     = "send subscriptions"
+	SubscriptionsEvent         = "subscriptions"
+	SendCrashDetailsEvent      = "send crash details"
+	SendLimitsEvent            = "send limits"
+	SendNodeResourcesEvent     = "send node resources"
+	SendIdleStatusEvent        = "send idle status"
+	IdleStatusEvent            = "idle status"
+	SendPlayerCountEvent       = "send player count"
+	PlayerCountEvent           = "player count"
+	NodeResourcesEvent         = "node resources"
+	SetStatsIntervalEvent      = "set stats interval"
+	SetStatsUnitEvent          = "set stats unit"
+	SetLogLevelEvent           = "set log level"
+	SetCommandEchoEvent        = "set command echo"
+	SetConsoleTimestampsEvent  = "set console timestamps"
+	SetDebugLoggingEvent       = "set debug logging"
+	DebugLoggingEvent          = "debug logging"
+	SubscribeBatchStatsEvent   = "subscribe batch stats"
+	SubscribeConsolesEvent     = "subscribe consoles"
+	SendStartupCommandEvent    = "send startup command"
+	SendAutoStartEvent         = "send auto start"
+	SetAutoStartEvent          = "set auto start"
+	ReloadConfigurationEvent   = "reload configuration"
+	WatchFilesEvent            = "watch files"
+	UnwatchFilesEvent          = "unwatch files"
+	FileChangesEvent           = "file changes"
+	StartBackupEvent           = "start backup"
+	CancelBackupEvent          = "cancel backup"
+	BatchStatsEvent            = "batch stats"
+	PingEvent                  = "ping"
+	PongEvent                  = "pong"
+	PowerActionInProgressEvent = "power action in progress"
+	PowerActionDisabledEvent   = "power action disabled"
 	ErrorEvent                 = "daemon error"
 	JwtErrorEvent              = "jwt error"
+	BadMessageEvent            = "bad message"
 )
 
+// ConsoleStreamProtocol is the websocket subprotocol a client can request to
+// have console output sent with the stream it was written to as a second
+// argument, either "stdout" or "stderr". Clients that do not request it receive
+// all the output without the stream, as they always have. If console timestamps
+// are enabled they are sent as a third argument to these clients only.
+const ConsoleStreamProtocol = "wings.console-streams.v1"
+
 type Message struct {
 	// The event to perform.
 	Event string `json:"event"`
@@ -20,4 +87,9 @@ type Message struct {
 	// The data to pass along, only used by power/command currently. Other requests
 	// should either omit the field or pass an empty value as it is ignored.
 	Args []string `json:"args,omitempty"`
+
+	// Additional values attached to events sent by the server, such as the ID of
+	// the node or a trace ID, used to match up events with other systems. This is
+	// only sent when configured.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
@@ -0,0 +1,32 @@
+package websocket
+
+import (
+	"net/http"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// The metadata key the trace header is sent under.
+const traceMetadataKey = "trace_id"
+
+// eventMetadata returns the metadata attached to the server events sent to a
+// connection. This is made up of the metadata configured for the node, and the
+// value of the trace header from the request that opened the connection if one
+// is configured. Nil is returned if there is nothing to attach, so that the
+// field is left out of every message.
+func eventMetadata(r *http.Request) map[string]string {
+	cfg := config.Get().System.Websocket
+	m := make(map[string]string, len(cfg.EventMetadata)+1)
+	for k, v := range cfg.EventMetadata {
+		m[k] = v
+	}
+	if cfg.TraceHeader != "" {
+		if v := r.Header.Get(cfg.TraceHeader); v != "" {
+			m[traceMetadataKey] = v
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
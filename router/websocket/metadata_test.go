@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+func TestEventMetadata(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("eventMetadata", func() {
+		g.It("returns nil when nothing is configured", func() {
+			config.Set(&config.Configuration{AuthenticationToken: "test"})
+			r := httptest.NewRequest("GET", "/", nil)
+			g.Assert(eventMetadata(r) == nil).IsTrue()
+		})
+
+		g.It("includes the configured metadata and trace header", func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.EventMetadata = map[string]string{"node": "node-1"}
+			c.System.Websocket.TraceHeader = "X-Request-Id"
+			config.Set(c)
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("X-Request-Id", "abc123")
+			g.Assert(eventMetadata(r)).Equal(map[string]string{"node": "node-1", "trace_id": "abc123"})
+		})
+
+		g.It("leaves out the trace id when the header is missing", func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.TraceHeader = "X-Request-Id"
+			config.Set(c)
+
+			r := httptest.NewRequest("GET", "/", nil)
+			g.Assert(eventMetadata(r) == nil).IsTrue()
+		})
+	})
+}
@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"github.com/gorilla/websocket"
+
+	"github.com/pterodactyl/wings/router/tokens"
+	"github.com/pterodactyl/wings/server"
+)
+
+// ApplyPermissionOverrides replaces the permissions of a token for the server if
+// the Panel has updated the permissions of its user since the token was issued.
+// This must be done everywhere a token is used so that revoking a permission
+// takes effect straight away rather than once the user gets a new token.
+func ApplyPermissionOverrides(s *server.Server, token *tokens.WebsocketPayload) {
+	if token == nil || token.IssuedAt == nil {
+		return
+	}
+	if permissions, ok := s.PermissionOverrides().Get(token.UserUUID, token.IssuedAt.Time); ok {
+		token.SetPermissions(permissions)
+	}
+}
+
+// UpdatePermissions replaces the permissions of the connection, such as when
+// the Panel changes the permissions of a subuser while they are connected. The
+// server keeps the permissions so that they are also applied to any older token
+// the client authenticates with later. The connection is closed if it no longer
+// has permission to connect at all.
+func (h *Handler) UpdatePermissions(permissions []string) {
+	// Nothing else needs to happen until the connection has authenticated, since
+	// the permissions are applied to its token at that point.
+	j := h.GetJwt()
+	if j == nil {
+		return
+	}
+	j.SetPermissions(permissions)
+
+	if !j.HasPermission(PermissionConnect) {
+		h.CloseWithReason(websocket.ClosePolicyViolation, server.NewCloseReason(server.CloseReasonPermissionsRevoked, "permission to connect was revoked").String())
+		return
+	}
+	_ = h.SendJson(Message{Event: PermissionsUpdatedEvent, Args: permissions})
+}
@@ -0,0 +1,49 @@
+package websocket
+
+import (
+	"io"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/gorilla/websocket"
+)
+
+var ErrMessageTooLarge = errors.New("websocket: message is larger than the maximum message size")
+
+// ReadMessage reads the next message sent by the client, rejecting any message
+// larger than the maximum message size without reading the rest of it.
+//
+// A message can be split over any number of frames, so the limit is applied to
+// the message as a whole. The read limit set on the connection rejects a message
+// as soon as the header of a frame would take it over the limit, before the
+// payload of that frame is read. That limit only counts the bytes sent over the
+// wire though, so compressed messages are also counted as they are inflated,
+// and reading stops as soon as they are over the limit.
+//
+// The connection is closed with a message too big close frame if the limit is
+// exceeded, since the rest of the message cannot be skipped safely.
+func (h *Handler) ReadMessage() ([]byte, error) {
+	_, r, err := h.Connection.NextReader()
+	if err != nil {
+		if errors.Is(err, websocket.ErrReadLimit) {
+			return nil, errors.WithStack(ErrMessageTooLarge)
+		}
+		return nil, err
+	}
+	if h.maxMessageSize <= 0 {
+		return io.ReadAll(r)
+	}
+	p, err := io.ReadAll(io.LimitReader(r, h.maxMessageSize+1))
+	if err != nil {
+		// The connection has already sent the close frame in this case.
+		if errors.Is(err, websocket.ErrReadLimit) {
+			return nil, errors.WithStack(ErrMessageTooLarge)
+		}
+		return nil, err
+	}
+	if int64(len(p)) > h.maxMessageSize {
+		_ = h.Connection.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseMessageTooBig, ""), time.Now().Add(time.Second*5))
+		return nil, errors.WithStack(ErrMessageTooLarge)
+	}
+	return p, nil
+}
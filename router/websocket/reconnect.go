@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/router/tokens"
+)
+
+var ErrReconnectTokenInvalid = errors.New("jwt: reconnection token is invalid or has expired")
+
+// reconnectEntry is a reconnection token that has been issued to a connection.
+// The token does not expire until the connection it was issued to is closed.
+type reconnectEntry struct {
+	server  string
+	payload *tokens.WebsocketPayload
+	expires time.Time
+}
+
+var reconnectTokens = struct {
+	sync.Mutex
+	m map[string]*reconnectEntry
+}{m: make(map[string]*reconnectEntry)}
+
+// issueReconnectToken creates a new reconnection token bound to this connection's
+// server and the user the payload belongs to, replacing any token previously
+// issued to the connection. An empty string is returned if reconnection tokens
+// are disabled.
+func (h *Handler) issueReconnectToken(payload *tokens.WebsocketPayload) string {
+	if config.Get().System.Websocket.ReconnectWindow <= 0 {
+		return ""
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		h.Logger().WithField("error", err).Warn("failed to generate websocket reconnection token")
+		return ""
+	}
+	token := hex.EncodeToString(b)
+
+	h.Lock()
+	previous := h.reconnectToken
+	h.reconnectToken = token
+	h.Unlock()
+
+	reconnectTokens.Lock()
+	defer reconnectTokens.Unlock()
+
+	// Clean up any tokens that were never used while the lock is held.
+	now := time.Now()
+	for k, v := range reconnectTokens.m {
+		if !v.expires.IsZero() && v.expires.Before(now) {
+			delete(reconnectTokens.m, k)
+		}
+	}
+	delete(reconnectTokens.m, previous)
+	reconnectTokens.m[token] = &reconnectEntry{server: h.server.ID(), payload: payload}
+
+	return token
+}
+
+// ReleaseReconnectToken starts the reconnection window for the token issued to
+// this connection. This should be called once the connection has closed.
+func (h *Handler) ReleaseReconnectToken() {
+	h.RLock()
+	token := h.reconnectToken
+	h.RUnlock()
+	if token == "" {
+		return
+	}
+
+	window := time.Duration(config.Get().System.Websocket.ReconnectWindow) * time.Second
+	reconnectTokens.Lock()
+	if e, ok := reconnectTokens.m[token]; ok {
+		e.expires = time.Now().Add(window)
+	}
+	reconnectTokens.Unlock()
+}
+
+// redeemReconnectToken exchanges a reconnection token for the token payload it
+// was issued with. The reconnection token can only be used once, must be used
+// on a connection to the same server, and the original payload must still be
+// valid.
+func (h *Handler) redeemReconnectToken(token string) (*tokens.WebsocketPayload, error) {
+	reconnectTokens.Lock()
+	e, ok := reconnectTokens.m[token]
+	// Tokens are only redeemable once the connection they were issued to has
+	// closed, and are removed as soon as they are redeemed.
+	if ok && !e.expires.IsZero() {
+		delete(reconnectTokens.m, token)
+	}
+	reconnectTokens.Unlock()
+
+	if !ok || e.expires.IsZero() || e.expires.Before(time.Now()) || e.server != h.server.ID() {
+		return nil, ErrReconnectTokenInvalid
+	}
+	if err := h.validateToken(e.payload); err != nil {
+		return nil, err
+	}
+	return e.payload, nil
+}
@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+	"github.com/gbrlsnchs/jwt/v3"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/router/tokens"
+	"github.com/pterodactyl/wings/server"
+)
+
+func TestReconnectTokens(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Reconnection tokens", func() {
+		var h *Handler
+		var payload *tokens.WebsocketPayload
+
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.ReconnectWindow = 30
+			config.Set(c)
+
+			h = &Handler{server: &server.Server{}}
+			payload = &tokens.WebsocketPayload{
+				Payload: jwt.Payload{
+					IssuedAt:       jwt.NumericDate(time.Now().Add(time.Minute)),
+					NotBefore:      jwt.NumericDate(time.Now().Add(-time.Minute)),
+					ExpirationTime: jwt.NumericDate(time.Now().Add(time.Hour)),
+				},
+				Permissions: []string{PermissionConnect},
+			}
+		})
+
+		g.It("can only be redeemed once the connection has closed", func() {
+			token := h.issueReconnectToken(payload)
+			g.Assert(token == "").IsFalse()
+
+			_, err := h.redeemReconnectToken(token)
+			g.Assert(errors.Is(err, ErrReconnectTokenInvalid)).IsTrue()
+
+			h.ReleaseReconnectToken()
+			p, err := h.redeemReconnectToken(token)
+			g.Assert(err).IsNil()
+			g.Assert(p == payload).IsTrue()
+		})
+
+		g.It("can only be redeemed once", func() {
+			token := h.issueReconnectToken(payload)
+			h.ReleaseReconnectToken()
+
+			_, err := h.redeemReconnectToken(token)
+			g.Assert(err).IsNil()
+
+			_, err = h.redeemReconnectToken(token)
+			g.Assert(errors.Is(err, ErrReconnectTokenInvalid)).IsTrue()
+		})
+
+		g.It("rejects an expired payload", func() {
+			payload.ExpirationTime = jwt.NumericDate(time.Now().Add(-time.Minute))
+			token := h.issueReconnectToken(payload)
+			h.ReleaseReconnectToken()
+
+			_, err := h.redeemReconnectToken(token)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("is not issued when disabled", func() {
+			config.Update(func(c *config.Configuration) {
+				c.System.Websocket.ReconnectWindow = 0
+			})
+			g.Assert(h.issueReconnectToken(payload)).Equal("")
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/server"
+)
+
+// The longest the session limit waits before checking the limit again, since a
+// new token sent by the client can change it.
+const sessionCheckInterval = time.Second * 10
+
+// sessionLimit returns the longest the connection can stay open for based on
+// the configuration and the current token, or 0 if it is not limited. The limit
+// only ever gets shorter, a token with a longer limit than one used before it on
+// the same connection does not extend the session.
+func (h *Handler) sessionLimit() time.Duration {
+	limit := time.Duration(config.Get().System.Websocket.MaxSessionDuration) * time.Second
+	if j := h.GetJwt(); j != nil {
+		if d := j.GetMaxSessionDuration(); d > 0 && (limit <= 0 || d < limit) {
+			limit = d
+		}
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	if h.sessionMax > 0 && (limit <= 0 || h.sessionMax < limit) {
+		limit = h.sessionMax
+	}
+	h.sessionMax = limit
+	return limit
+}
+
+// EnforceSessionLimit closes the connection once it has been open for longer
+// than the session limit, regardless of whether its token is still valid. This
+// blocks until the context is cancelled or the connection is closed.
+func (h *Handler) EnforceSessionLimit(ctx context.Context) {
+	start := time.Now()
+	for {
+		wait := sessionCheckInterval
+		if limit := h.sessionLimit(); limit > 0 {
+			remaining := time.Until(start.Add(limit))
+			if remaining <= 0 {
+				h.Logger().WithField("duration", limit).Debug("websocket session duration exceeded, closing connection")
+				h.CloseWithReason(websocket.ClosePolicyViolation, server.NewCloseReason(server.CloseReasonSessionExpired, "session duration exceeded").String())
+				return
+			}
+			if remaining < wait {
+				wait = remaining
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"math"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+)
+
+var ErrInvalidStatsUnit = errors.New("stats unit must be one of bytes, kilobytes, or megabytes")
+
+// The units that memory, disk, and network values in stats can be sent in, and
+// the number of bytes in each.
+const (
+	StatsUnitBytes     = "bytes"
+	StatsUnitKilobytes = "kilobytes"
+	StatsUnitMegabytes = "megabytes"
+)
+
+var statsUnits = map[string]float64{
+	StatsUnitBytes:     1,
+	StatsUnitKilobytes: 1024,
+	StatsUnitMegabytes: 1024 * 1024,
+}
+
+// The fields in the stats payload that hold a number of bytes.
+var statsByteFields = []string{
+	"memory_bytes",
+	"memory_limit_bytes",
+	"memory_cache_bytes",
+	"memory_rss_bytes",
+	"memory_mapped_bytes",
+	"disk_bytes",
+}
+
+// The fields in the network section of the stats payload that hold a number of
+// bytes.
+var statsNetworkFields = []string{"rx_bytes", "tx_bytes"}
+
+// setStatsUnit sets the unit that stats are sent to this connection in.
+func (h *Handler) setStatsUnit(unit string) error {
+	if _, ok := statsUnits[unit]; !ok {
+		return errors.WithMessage(ErrInvalidStatsUnit, unit)
+	}
+
+	h.Lock()
+	h.statsUnit = unit
+	h.Unlock()
+
+	return nil
+}
+
+// formatStats converts the byte values in a stats payload into the unit the
+// client asked for. Payloads sent in a unit other than bytes include a "unit"
+// field since the names of the fields no longer match their values.
+func (h *Handler) formatStats(payload string) string {
+	h.RLock()
+	unit := h.statsUnit
+	h.RUnlock()
+
+	if unit == "" || unit == StatsUnitBytes {
+		return payload
+	}
+	out, err := convertStats(payload, unit)
+	if err != nil {
+		return payload
+	}
+	return out
+}
+
+// convertStats converts the byte values in the stats payload into the given unit,
+// rounded to two decimal places.
+func convertStats(payload string, unit string) (string, error) {
+	divisor, ok := statsUnits[unit]
+	if !ok {
+		return "", ErrInvalidStatsUnit
+	}
+
+	var stats map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &stats); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if err := convertStatsFields(stats, statsByteFields, divisor); err != nil {
+		return "", err
+	}
+	if raw, ok := stats["network"]; ok {
+		var network map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &network); err != nil {
+			return "", errors.WithStack(err)
+		}
+		if err := convertStatsFields(network, statsNetworkFields, divisor); err != nil {
+			return "", err
+		}
+		b, err := json.Marshal(network)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		stats["network"] = b
+	}
+	stats["unit"], _ = json.Marshal(unit)
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return string(b), nil
+}
+
+// convertStatsFields divides each of the fields present in the map by the
+// divisor.
+func convertStatsFields(m map[string]json.RawMessage, fields []string, divisor float64) error {
+	for _, f := range fields {
+		raw, ok := m[f]
+		if !ok {
+			continue
+		}
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return errors.WithStack(err)
+		}
+		b, err := json.Marshal(math.Round(v/divisor*100) / 100)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		m[f] = b
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package websocket
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+	"github.com/goccy/go-json"
+)
+
+func TestConvertStats(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("convertStats", func() {
+		payload := `{"memory_bytes":1048576,"memory_limit_bytes":2097152,"cpu_absolute":12.5,"disk_bytes":1572864,"network":{"rx_bytes":1024,"tx_bytes":512}}`
+
+		g.It("converts byte values to the unit", func() {
+			out, err := convertStats(payload, StatsUnitMegabytes)
+			g.Assert(err).IsNil()
+
+			var stats struct {
+				Memory  float64 `json:"memory_bytes"`
+				Limit   float64 `json:"memory_limit_bytes"`
+				Cpu     float64 `json:"cpu_absolute"`
+				Disk    float64 `json:"disk_bytes"`
+				Unit    string  `json:"unit"`
+				Network struct {
+					Rx float64 `json:"rx_bytes"`
+					Tx float64 `json:"tx_bytes"`
+				} `json:"network"`
+			}
+			g.Assert(json.Unmarshal([]byte(out), &stats)).IsNil()
+			g.Assert(stats.Memory).Equal(float64(1))
+			g.Assert(stats.Limit).Equal(float64(2))
+			g.Assert(stats.Disk).Equal(1.5)
+			g.Assert(stats.Cpu).Equal(12.5)
+			g.Assert(stats.Network.Rx).Equal(float64(0))
+			g.Assert(stats.Unit).Equal(StatsUnitMegabytes)
+		})
+
+		g.It("rounds to two decimal places", func() {
+			out, err := convertStats(`{"network":{"rx_bytes":1000,"tx_bytes":0}}`, StatsUnitKilobytes)
+			g.Assert(err).IsNil()
+			g.Assert(out).Equal(`{"network":{"rx_bytes":0.98,"tx_bytes":0},"unit":"kilobytes"}`)
+		})
+
+		g.It("rejects unknown units", func() {
+			_, err := convertStats(payload, "gigabytes")
+			g.Assert(errors.Is(err, ErrInvalidStatsUnit)).IsTrue()
+
+			h := &Handler{}
+			g.Assert(errors.Is(h.setStatsUnit("gigabytes"), ErrInvalidStatsUnit)).IsTrue()
+		})
+
+		g.It("leaves stats in bytes alone", func() {
+			h := &Handler{}
+			g.Assert(h.formatStats(payload)).Equal(payload)
+
+			g.Assert(h.setStatsUnit(StatsUnitBytes)).IsNil()
+			g.Assert(h.formatStats(payload)).Equal(payload)
+		})
+	})
+}
@@ -3,9 +3,13 @@ package websocket
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pterodactyl/wings/internal/models"
@@ -23,8 +27,10 @@ import (
 	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/environment"
 	"github.com/pterodactyl/wings/environment/docker"
+	"github.com/pterodactyl/wings/router/middleware"
 	"github.com/pterodactyl/wings/router/tokens"
 	"github.com/pterodactyl/wings/server"
+	"github.com/pterodactyl/wings/server/backup"
 )
 
 const (
@@ -33,10 +39,19 @@ const (
 	PermissionSendPowerStart   = "control.start"
 	PermissionSendPowerStop    = "control.stop"
 	PermissionSendPowerRestart = "control.restart"
+	PermissionUpdateImage      = "startup.docker-image"
 	PermissionReceiveErrors    = "admin.websocket.errors"
 	PermissionReceiveInstall   = "admin.websocket.install"
 	PermissionReceiveTransfer  = "admin.websocket.transfer"
+	PermissionExec             = "admin.websocket.exec"
+	PermissionProcesses        = "admin.websocket.processes"
+	PermissionDebugLogging     = "admin.websocket.debug"
 	PermissionReceiveBackups   = "backup.read"
+	PermissionCreateBackup     = "backup.create"
+	PermissionReadStartup      = "startup.read"
+	PermissionUpdateStartup    = "startup.update"
+	PermissionReadFile         = "file.read-content"
+	PermissionReadFiles        = "file.read"
 )
 
 type Handler struct {
@@ -46,13 +61,104 @@ type Handler struct {
 	server       *server.Server
 	ra           server.RequestActivity
 	uuid         uuid.UUID
+
+	// The server event types this connection has asked to receive. If nil the
+	// connection receives every event it has permission to see.
+	subscriptions map[string]struct{}
+
+	// The minimum size of a message before it is compressed, or -1 if compression
+	// is disabled for this connection.
+	compressionThreshold int
+	// The size messages containing past log output must be before they are
+	// compressed, or -1 if they are only compressed like any other message.
+	logCompressionThreshold int
+
+	// Limits how often the client is told about malformed messages it has sent.
+	badMessages *system.Rate
+
+	// The interval the client would like to receive stats at, and the number of
+	// stats samples seen since one was last sent to the client.
+	statsInterval time.Duration
+	statsSkipped  int
+
+	// The unit that memory, disk, and network values are sent in, defaults to
+	// bytes when empty.
+	statsUnit string
+
+	// The reconnection token issued to this connection, if any.
+	reconnectToken string
+
+	// Limits how quickly commands can be sent, and the user it is shared under if
+	// it is shared between the user's connections.
+	commandRate     *system.Rate
+	commandRateUser string
+
+	// The minimum level of console output sent to this connection, or 0 if every
+	// line is sent, and the pattern used to find the level of each line.
+	minLogLevel     int
+	logLevelPattern *regexp.Regexp
+
+	// The largest message in bytes the client can send, 0 if there is no limit.
+	maxMessageSize int64
+
+	// The shortest session limit seen on this connection, so that a new token
+	// sent by the client cannot extend it. 0 if the session is not limited.
+	sessionMax time.Duration
+
+	// Set if the client connected using the ConsoleStreamProtocol, in which case
+	// console output is sent along with the stream it was written to.
+	consoleStreams bool
+
+	// How the timestamps sent with console output to this connection are
+	// formatted, only used along with consoleStreams.
+	timestamps consoleTimestamps
+
+	// The metadata attached to every server event sent to this connection.
+	metadata map[string]string
+
+	// Used to look up other servers on the node for batch stats subscriptions,
+	// and to stop the current batch subscription when it is replaced.
+	manager     *server.Manager
+	batchCancel context.CancelFunc
+
+	// Stops sending the console output of other servers to the client.
+	consolesCancel context.CancelFunc
+
+	// Stops sending file changes to the client.
+	watchCancel context.CancelFunc
+
+	// Set to 1 while a command run using the exec event is running.
+	executing int32
+
+	// Set if commands sent from this connection are echoed back to it.
+	echoCommands bool
+
+	// The second in which this connection was last told its console output is
+	// being throttled, only used by the listener goroutine.
+	throttledWindow int64
 }
 
+// statsCollectionInterval is the rate at which Docker reports resource usage for
+// a container, and therefore the fastest stats can be sent to a client.
+const statsCollectionInterval = time.Second
+
+// The number of connections dropped because they stopped reading messages.
+var slowClientsDropped uint64
+
 var (
-	ErrJwtNotPresent    = errors.New("jwt: no jwt present")
-	ErrJwtNoConnectPerm = errors.New("jwt: missing connect permission")
-	ErrJwtUuidMismatch  = errors.New("jwt: server uuid mismatch")
-	ErrJwtOnDenylist    = errors.New("jwt: created too far in past (denylist)")
+	ErrJwtNotPresent       = errors.New("jwt: no jwt present")
+	ErrJwtNoConnectPerm    = errors.New("jwt: missing connect permission")
+	ErrJwtUuidMismatch     = errors.New("jwt: server uuid mismatch")
+	ErrJwtOnDenylist       = errors.New("jwt: created too far in past (denylist)")
+	ErrJwtNoExpiration     = errors.New("jwt: missing expiration time (exp) claim")
+	ErrJwtNoNotBefore      = errors.New("jwt: missing not before (nbf) claim")
+	ErrCommandTooLong      = errors.New("command exceeds the maximum allowed length")
+	ErrSubscribeAfterAuth  = errors.New("subscriptions must be declared before authenticating")
+	ErrUnknownSubscription = errors.New("cannot subscribe to unknown event")
+	ErrInvalidInterval     = errors.New("interval must be a whole number of seconds")
+	ErrInvalidDimensions   = errors.New("terminal dimensions are out of range")
+	ErrInvalidBackup       = errors.New("backup token is not valid")
+	ErrInvalidLogCount     = errors.New("log line count must be a whole number")
 )
 
 func IsJwtError(err error) bool {
@@ -60,13 +166,29 @@ func IsJwtError(err error) bool {
 		errors.Is(err, ErrJwtNoConnectPerm) ||
 		errors.Is(err, ErrJwtUuidMismatch) ||
 		errors.Is(err, ErrJwtOnDenylist) ||
-		errors.Is(err, jwt.ErrExpValidation)
+		errors.Is(err, ErrJwtNoExpiration) ||
+		errors.Is(err, ErrJwtNoNotBefore) ||
+		errors.Is(err, ErrReconnectTokenInvalid) ||
+		errors.Is(err, tokens.ErrTokenRejected) ||
+		errors.Is(err, jwt.ErrExpValidation) ||
+		errors.Is(err, jwt.ErrNbfValidation)
 }
 
 // NewTokenPayload parses a JWT into a websocket token payload.
 func NewTokenPayload(token []byte) (*tokens.WebsocketPayload, error) {
 	var payload tokens.WebsocketPayload
-	if err := tokens.ParseToken(token, &payload); err != nil {
+	if err := tokens.ParseWebsocketToken(token, &payload); err != nil {
+		// The payload has already been decoded when the expiration check fails, so
+		// report a missing claim rather than the token appearing to have expired.
+		if errors.Is(err, jwt.ErrExpValidation) {
+			if cerr := requireTimeClaims(&payload); cerr != nil {
+				return nil, cerr
+			}
+		}
+		return nil, err
+	}
+
+	if err := requireTimeClaims(&payload); err != nil {
 		return nil, err
 	}
 
@@ -81,9 +203,32 @@ func NewTokenPayload(token []byte) (*tokens.WebsocketPayload, error) {
 	return &payload, nil
 }
 
+// requireTimeClaims returns an error if the token is missing its expiration or
+// not before time. Tokens issued by the Panel always include both, so a token
+// without them is malformed and is rejected with an error naming the claim.
+func requireTimeClaims(j *tokens.WebsocketPayload) error {
+	p := j.GetPayload()
+	if p.ExpirationTime == nil {
+		return ErrJwtNoExpiration
+	}
+	if p.NotBefore == nil {
+		return ErrJwtNoNotBefore
+	}
+	return nil
+}
+
 // GetHandler returns a new websocket handler using the context provided.
 func GetHandler(s *server.Server, w http.ResponseWriter, r *http.Request, c *gin.Context) (*Handler, error) {
+	cfg := config.Get().System.Websocket
+	compression := cfg.Compression
+	compression.Enabled = compression.Enabled && !compressionDisabledByClient(r)
+	logCompression := config.Get().System.LogCompression
+	logCompression.Enabled = logCompression.Enabled && !compressionDisabledByClient(r)
 	upgrader := websocket.Upgrader{
+		ReadBufferSize:    cfg.ReadBufferSize,
+		WriteBufferSize:   cfg.WriteBufferSize,
+		EnableCompression: compression.Enabled || logCompression.Enabled,
+		Subprotocols:      []string{ConsoleStreamProtocol},
 		// Ensure that the websocket request is originating from the Panel itself,
 		// and not some other location.
 		CheckOrigin: func(r *http.Request) bool {
@@ -104,6 +249,25 @@ func GetHandler(s *server.Server, w http.ResponseWriter, r *http.Request, c *gin
 	if err != nil {
 		return nil, err
 	}
+	if cfg.MaxMessageSize > 0 {
+		conn.SetReadLimit(cfg.MaxMessageSize)
+	}
+	if err := setKeepalive(conn.UnderlyingConn(), cfg.Keepalive); err != nil {
+		s.Log().WithField("error", err).Warn("failed to configure keepalive for websocket connection")
+	}
+
+	threshold, logThreshold := -1, -1
+	if compression.Enabled {
+		threshold = compression.Threshold
+	}
+	if logCompression.Enabled {
+		logThreshold = logCompression.Threshold
+	}
+	if compression.Enabled || logCompression.Enabled {
+		if err := conn.SetCompressionLevel(compression.Level); err != nil {
+			s.Log().WithField("level", compression.Level).Warn("invalid websocket compression level configured, using default")
+		}
+	}
 
 	u, err := uuid.NewRandom()
 	if err != nil {
@@ -114,22 +278,51 @@ func GetHandler(s *server.Server, w http.ResponseWriter, r *http.Request, c *gin
 		Connection: conn,
 		jwt:        nil,
 		server:     s,
+		manager:    middleware.ExtractManager(c),
 		ra:         s.NewRequestActivity("", c.ClientIP()),
 		uuid:       u,
+
+		compressionThreshold:    threshold,
+		logCompressionThreshold: logThreshold,
+		badMessages:             system.NewRate(1, time.Second*5),
+		maxMessageSize:          cfg.MaxMessageSize,
+		consoleStreams:          conn.Subprotocol() == ConsoleStreamProtocol,
+		timestamps:              newConsoleTimestamps(config.Get().System.Websocket.ConsoleTimestamps),
+		metadata:                eventMetadata(r),
 	}, nil
 }
 
+// compressionDisabledByClient returns true if the client asked for compression
+// to not be used for its connection by connecting with "compression=false" in
+// the query string, such as clients on a fast local network that would rather
+// save the CPU time. Clients cannot turn compression on if it is disabled for
+// the node.
+func compressionDisabledByClient(r *http.Request) bool {
+	v := r.URL.Query().Get("compression")
+	if v == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(v)
+	return err == nil && !enabled
+}
+
 func (h *Handler) Uuid() uuid.UUID {
 	return h.uuid
 }
 
 func (h *Handler) Logger() *log.Entry {
-	return log.WithField("subsystem", "websocket").
-		WithField("connection", h.Uuid().String()).
-		WithField("server", h.server.ID())
+	return h.server.Log().WithField("subsystem", "websocket").
+		WithField("connection", h.Uuid().String())
 }
 
 func (h *Handler) SendJson(v Message) error {
+	return h.sendJsonFor(h.server, v)
+}
+
+// sendJsonFor sends JSON over the websocket connection the same as SendJson,
+// counting the bytes sent against the websocket bandwidth of the given server
+// rather than the server the connection was opened for.
+func (h *Handler) sendJsonFor(s *server.Server, v Message) error {
 	// Do not send JSON down the line if the JWT on the connection is not valid!
 	if err := h.TokenValid(); err != nil {
 		_ = h.unsafeSendJson(Message{
@@ -139,32 +332,20 @@ func (h *Handler) SendJson(v Message) error {
 		return nil
 	}
 
-	if j := h.GetJwt(); j != nil {
-		// If we're sending installation output but the user does not have the required
-		// permissions to see the output, don't send it down the line.
-		if v.Event == server.InstallOutputEvent {
-			if !j.HasPermission(PermissionReceiveInstall) {
-				return nil
-			}
-		}
+	// Drop any server events that the client did not subscribe to when connecting.
+	if !h.isSubscribed(v.Event) {
+		return nil
+	}
 
-		// If the user does not have permission to see backup events, do not emit
-		// them over the socket.
-		if strings.HasPrefix(v.Event, server.BackupCompletedEvent) {
-			if !j.HasPermission(PermissionReceiveBackups) {
-				return nil
-			}
-		}
+	if !h.canReceive(v.Event) {
+		return nil
+	}
 
-		// If we are sending transfer output, only send it to the user if they have the required permissions.
-		if v.Event == server.TransferLogsEvent {
-			if !j.HasPermission(PermissionReceiveTransfer) {
-				return nil
-			}
-		}
+	if v.Event == server.StatsEvent && len(v.Args) > 0 {
+		v.Args = append([]string{h.formatStats(v.Args[0])}, v.Args[1:]...)
 	}
 
-	if err := h.unsafeSendJson(v); err != nil {
+	if err := h.unsafeSendJsonFor(s, v); err != nil {
 		// Not entirely sure how this happens (likely just when there is a ton of console spam)
 		// but I don't care to fix it right now, so just mask the error and throw a warning into
 		// the logs for us to look into later.
@@ -187,19 +368,110 @@ func (h *Handler) SendJson(v Message) error {
 // socket user. Do not call this directly unless you are positive a response should be
 // sent back to the client!
 func (h *Handler) unsafeSendJson(v interface{}) error {
+	return h.unsafeSendJsonFor(h.server, v)
+}
+
+func (h *Handler) unsafeSendJsonFor(s *server.Server, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if s != nil {
+		s.WebsocketBandwidth().Record(len(b))
+	}
+
 	h.Lock()
 	defer h.Unlock()
 
-	return h.Connection.WriteJSON(v)
+	// Only compress messages that are large enough to actually benefit from it. This
+	// is a no-op if compression was not negotiated with the client.
+	h.Connection.EnableWriteCompression(h.shouldCompress(v, len(b)))
+
+	cfg := config.Get().System.Websocket
+	if cfg.WriteTimeout > 0 {
+		_ = h.Connection.SetWriteDeadline(time.Now().Add(time.Duration(cfg.WriteTimeout) * time.Second))
+	}
+	err = h.Connection.WriteMessage(websocket.TextMessage, b)
+
+	// The connection cannot be written to again once a write has timed out, so
+	// there is no point in keeping it open.
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		h.dropSlowClient()
+	}
+	return err
+}
+
+// shouldCompress returns true if a message of the given size should be sent
+// compressed, either because all messages that large are compressed or because
+// it contains past log output and is large enough for that.
+func (h *Handler) shouldCompress(v interface{}, size int) bool {
+	if h.compressionThreshold >= 0 && size >= h.compressionThreshold {
+		return true
+	}
+	if m, ok := v.(Message); ok && h.logCompressionThreshold >= 0 && size >= h.logCompressionThreshold {
+		return m.Event == InstallLogEvent || m.Event == server.LogFileOutputEvent
+	}
+	return false
+}
+
+// dropSlowClient closes the connection of a client that has stopped reading the
+// messages sent to it. A close frame is not sent since it would not be read.
+func (h *Handler) dropSlowClient() {
+	atomic.AddUint64(&slowClientsDropped, 1)
+	h.Logger().Warn("dropping websocket connection that is not reading messages")
+	if err := h.Connection.Close(); err != nil {
+		h.Logger().WithField("error", errors.WithStack(err)).Debug("error closing websocket connection")
+	}
+}
+
+// SlowClientsDropped returns the number of websocket connections that have been
+// dropped since Wings started because they stopped reading messages.
+func SlowClientsDropped() uint64 {
+	return atomic.LoadUint64(&slowClientsDropped)
+}
+
+// SendBadMessage notifies the client that a message it sent could not be parsed.
+// This is rate limited so that a client sending a flood of malformed data does
+// not also receive a flood of errors in response.
+func (h *Handler) SendBadMessage(err error) {
+	if !h.badMessages.Try() {
+		return
+	}
+	m, _ := h.GetErrorMessage("could not parse websocket message: " + err.Error())
+	_ = h.unsafeSendJson(Message{
+		Event: BadMessageEvent,
+		Args:  []string{m},
+	})
 }
 
 // TokenValid checks if the JWT is still valid.
 func (h *Handler) TokenValid() error {
-	j := h.GetJwt()
+	return h.validateToken(h.GetJwt())
+}
+
+// validateToken checks that the given token is valid for use with this
+// connection's server.
+func (h *Handler) validateToken(j *tokens.WebsocketPayload) error {
+	return ValidateToken(h.server, j)
+}
+
+// ValidateToken checks that the given token is valid for use with the server.
+// This is checked again while a token is in use, since it can expire or be
+// denied, and any permissions sent by the Panel for its user since it was
+// issued are applied to it first.
+func ValidateToken(s *server.Server, j *tokens.WebsocketPayload) error {
 	if j == nil {
 		return ErrJwtNotPresent
 	}
 
+	if err := requireTimeClaims(j); err != nil {
+		return err
+	}
+
+	ApplyPermissionOverrides(s, j)
+
 	if err := jwt.ExpirationTimeValidator(time.Now())(&j.Payload); err != nil {
 		return err
 	}
@@ -212,7 +484,7 @@ func (h *Handler) TokenValid() error {
 		return ErrJwtNoConnectPerm
 	}
 
-	if h.server.ID() != j.GetServerUuid() {
+	if s.ID() != j.GetServerUuid() {
 		return ErrJwtUuidMismatch
 	}
 
@@ -273,11 +545,276 @@ func (h *Handler) setJwt(token *tokens.WebsocketPayload) {
 	h.ra = h.ra.SetUser(token.UserUUID)
 	h.jwt = token
 	h.Unlock()
+	// A token issued before the permissions of its user were last updated still
+	// has the old permissions in it, so they are replaced again.
+	ApplyPermissionOverrides(h.server, token)
+
+	h.server.Websockets().SetUser(h.uuid, token.UserUUID)
+}
+
+// subscribe limits the server events sent over this connection to the ones
+// provided. This must be called before the connection is authenticated since
+// the server listeners are registered at that point.
+func (h *Handler) subscribe(events []string) error {
+	if h.GetJwt() != nil {
+		return ErrSubscribeAfterAuth
+	}
+
+	subscriptions := make(map[string]struct{}, len(events))
+	for _, evt := range events {
+		if !isServerEvent(evt) {
+			return errors.WithMessage(ErrUnknownSubscription, evt)
+		}
+		subscriptions[evt] = struct{}{}
+	}
+
+	h.Lock()
+	h.subscriptions = subscriptions
+	h.Unlock()
+
+	return nil
+}
+
+// canReceive returns true if the connection's token has the permissions needed
+// to receive the given event.
+func (h *Handler) canReceive(event string) bool {
+	if j := h.GetJwt(); j != nil {
+		// If we're sending installation output but the user does not have the required
+		// permissions to see the output, don't send it down the line.
+		if event == server.InstallOutputEvent {
+			if !j.HasPermission(PermissionReceiveInstall) {
+				return false
+			}
+		}
+
+		// If the user does not have permission to see backup events, do not emit
+		// them over the socket.
+		if strings.HasPrefix(event, server.BackupCompletedEvent) || strings.HasPrefix(event, server.BackupProgressEvent) {
+			if !j.HasPermission(PermissionReceiveBackups) {
+				return false
+			}
+		}
+
+		// If we are sending transfer output, only send it to the user if they have the required permissions.
+		if event == server.TransferLogsEvent {
+			if !j.HasPermission(PermissionReceiveTransfer) {
+				return false
+			}
+		}
+
+		if !h.hasEventPermission(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSubscribed returns true if the given event should be sent to the client
+// based on the subscriptions it declared. Events that are not server events,
+// such as authentication or error responses, are always sent.
+func (h *Handler) isSubscribed(event string) bool {
+	h.RLock()
+	defer h.RUnlock()
+
+	if h.subscriptions == nil || !isServerEvent(event) {
+		return true
+	}
+	_, ok := h.subscriptions[event]
+	return ok
+}
+
+// Subscriptions is sent to a client that asks which events its connection is
+// receiving.
+type Subscriptions struct {
+	// The server events the connection receives, taking into account both the
+	// subscriptions it declared and the permissions of its token.
+	Events []string `json:"events"`
+	// Set if the connection declared the events it wants to receive when it
+	// connected, otherwise it receives every event it has permission to see.
+	Selective bool `json:"selective"`
+}
+
+// activeSubscriptions returns the server events that are sent to this connection.
+func (h *Handler) activeSubscriptions() Subscriptions {
+	h.RLock()
+	selective := h.subscriptions != nil
+	h.RUnlock()
+
+	events := []string{}
+	for _, evt := range e {
+		if h.isSubscribed(evt) && h.canReceive(evt) {
+			events = append(events, evt)
+		}
+	}
+	return Subscriptions{Events: events, Selective: selective}
+}
+
+// setStatsInterval sets the interval, in seconds, that stats should be sent to
+// this connection at. Stats are still collected at the same rate internally, only
+// every Nth sample is sent along. Intervals faster than the collection rate are
+// raised to match it.
+func (h *Handler) setStatsInterval(seconds string) error {
+	v, err := strconv.Atoi(seconds)
+	if err != nil || v < 0 {
+		return errors.WithMessage(ErrInvalidInterval, seconds)
+	}
+
+	interval := time.Duration(v) * time.Second
+	if interval < statsCollectionInterval {
+		interval = statsCollectionInterval
+	}
+
+	h.Lock()
+	h.statsInterval = interval
+	h.statsSkipped = 0
+	h.Unlock()
+
+	return nil
+}
+
+// shouldSendStats returns true if the current stats sample should be sent to
+// the client based on the interval it requested.
+func (h *Handler) shouldSendStats() bool {
+	h.Lock()
+	defer h.Unlock()
+
+	every := int(h.statsInterval / statsCollectionInterval)
+	if every <= 1 {
+		return true
+	}
+
+	send := h.statsSkipped == 0
+	h.statsSkipped = (h.statsSkipped + 1) % every
+	return send
+}
+
+// hasEventPermission returns true if the token for this connection has all the
+// additional permissions configured for the given event. Events that have no
+// additional permissions configured are always allowed.
+func (h *Handler) hasEventPermission(event string) bool {
+	return HasEventPermission(h.GetJwt(), event)
+}
+
+// HasEventPermission returns true if the token has all the additional
+// permissions configured for an event. Events without any configured
+// permissions are always allowed, even without a token.
+func HasEventPermission(j *tokens.WebsocketPayload, event string) bool {
+	// Namespaced events such as "backup completed:<uuid>" share the permissions
+	// of the base event.
+	event = strings.SplitN(event, ":", 2)[0]
+
+	permissions := config.Get().System.Websocket.EventPermissions[event]
+	if len(permissions) == 0 {
+		return true
+	}
+
+	if j == nil {
+		return false
+	}
+	for _, p := range permissions {
+		if !j.HasPermission(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// authenticate sets the token for the connection once it has been validated. If
+// this is the first time the connection has authenticated the server listeners
+// are registered and the current state of the server is sent to the client.
+func (h *Handler) authenticate(ctx context.Context, token *tokens.WebsocketPayload) error {
+	// Check if the user has previously authenticated successfully.
+	newConnection := h.GetJwt() == nil
+
+	// Previously there was a HasPermission(PermissionConnect) check around this,
+	// however NewTokenPayload will return an error if it doesn't have the connect
+	// permission meaning that it was a redundant function call.
+	h.setJwt(token)
+
+	// Tell the client they authenticated successfully.
+	_ = h.unsafeSendJson(Message{Event: AuthenticationSuccessEvent})
+
+	// Give the client a token it can use to quickly reconnect if the connection
+	// drops, without the full token needing to be verified again.
+	if rt := h.issueReconnectToken(token); rt != "" {
+		_ = h.unsafeSendJson(Message{Event: ReconnectTokenEvent, Args: []string{rt}})
+	}
+
+	// Check if the client was refreshing their authentication token
+	// instead of authenticating for the first time.
+	if !newConnection {
+		// This prevents duplicate status messages as outlined in
+		// https://github.com/pterodactyl/panel/issues/2077
+		return nil
+	}
+
+	// Now that we've authenticated with the token and confirmed that we're not
+	// reconnecting to the socket, register the event listeners for the server and
+	// the token expiration.
+	h.registerListenerEvents(ctx)
+
+	// On every authentication event, send the current server status back
+	// to the client. :)
+	_ = h.SendJson(Message{
+		Event: server.StatusEvent,
+		Args:  h.server.StatusArgs(),
+	})
+
+	// Send along the recent stats for the server so that the client is able
+	// to populate any graphs without waiting on new samples.
+	if h.isSubscribed(server.StatsEvent) {
+		if samples := h.server.StatsHistory().Samples(); len(samples) > 0 {
+			b, _ := json.Marshal(samples)
+			_ = h.SendJson(Message{
+				Event: server.StatsHistoryEvent,
+				Args:  []string{string(b)},
+			})
+		}
+	}
+
+	// Let the client know straight away if Docker is down, since the status sent
+	// above is just the last known state of the server.
+	if !environment.DockerAvailable() {
+		_ = h.SendJson(Message{Event: server.BackendStatusEvent, Args: []string{server.BackendUnavailable}})
+	}
+
+	// Let the client know up front if commands cannot be sent so that the input
+	// can be hidden.
+	if h.server.CommandsDisabled() {
+		_ = h.SendJson(Message{Event: server.CommandsDisabledEvent})
+	}
+
+	// Only send the current disk usage if the server is offline, if docker container is running,
+	// Environment#EnableResourcePolling() will send this data to all clients.
+	if h.server.Environment.State() == environment.ProcessOfflineState {
+		if !h.server.IsInstalling() && !h.server.IsTransferring() {
+			_ = h.server.Filesystem().HasSpaceAvailable(false)
+
+			b, _ := json.Marshal(h.server.Proc())
+			_ = h.SendJson(Message{
+				Event: server.StatsEvent,
+				Args:  []string{string(b)},
+			})
+		}
+	}
+
+	return nil
 }
 
 // HandleInbound handles an inbound socket request and route it to the proper action.
 func (h *Handler) HandleInbound(ctx context.Context, m Message) error {
-	if m.Event != AuthenticationEvent {
+	// Subscriptions are declared before authenticating, so there is no token to
+	// validate at this point. The only thing a subscription can do is limit the
+	// events sent to the client.
+	if m.Event == SubscribeEvent {
+		if err := h.subscribe(m.Args); err != nil {
+			msg, _ := h.GetErrorMessage(err.Error())
+			_ = h.unsafeSendJson(Message{Event: ErrorEvent, Args: []string{msg}})
+		}
+		return nil
+	}
+
+	if m.Event != AuthenticationEvent && m.Event != ReconnectEvent {
 		if err := h.TokenValid(); err != nil {
 			h.unsafeSendJson(Message{
 				Event: JwtErrorEvent,
@@ -285,6 +822,17 @@ func (h *Handler) HandleInbound(ctx context.Context, m Message) error {
 			})
 			return nil
 		}
+
+		if !h.hasEventPermission(m.Event) {
+			return nil
+		}
+
+		// Nothing is queued up while Docker is unreachable, the client is told why
+		// the event was dropped and can send it again once Docker returns.
+		if requiresBackend(m.Event) && !environment.DockerAvailable() {
+			_ = h.SendJson(Message{Event: server.BackendStatusEvent, Args: []string{server.BackendUnavailable}})
+			return nil
+		}
 	}
 
 	switch m.Event {
@@ -295,72 +843,61 @@ func (h *Handler) HandleInbound(ctx context.Context, m Message) error {
 				return err
 			}
 
-			// Check if the user has previously authenticated successfully.
-			newConnection := h.GetJwt() == nil
-
-			// Previously there was a HasPermission(PermissionConnect) check around this,
-			// however NewTokenPayload will return an error if it doesn't have the connect
-			// permission meaning that it was a redundant function call.
-			h.setJwt(token)
-
-			// Tell the client they authenticated successfully.
-			_ = h.unsafeSendJson(Message{Event: AuthenticationSuccessEvent})
-
-			// Check if the client was refreshing their authentication token
-			// instead of authenticating for the first time.
-			if !newConnection {
-				// This prevents duplicate status messages as outlined in
-				// https://github.com/pterodactyl/panel/issues/2077
-				return nil
-			}
-
-			// Now that we've authenticated with the token and confirmed that we're not
-			// reconnecting to the socket, register the event listeners for the server and
-			// the token expiration.
-			h.registerListenerEvents(ctx)
-
-			// On every authentication event, send the current server status back
-			// to the client. :)
-			state := h.server.Environment.State()
-			_ = h.SendJson(Message{
-				Event: server.StatusEvent,
-				Args:  []string{state},
-			})
-
-			// Only send the current disk usage if the server is offline, if docker container is running,
-			// Environment#EnableResourcePolling() will send this data to all clients.
-			if state == environment.ProcessOfflineState {
-				if !h.server.IsInstalling() && !h.server.IsTransferring() {
-					_ = h.server.Filesystem().HasSpaceAvailable(false)
-
-					b, _ := json.Marshal(h.server.Proc())
-					_ = h.SendJson(Message{
-						Event: server.StatsEvent,
-						Args:  []string{string(b)},
-					})
-				}
+			return h.authenticate(ctx, token)
+		}
+	case PingEvent:
+		{
+			// The arguments are sent back untouched so that the client can match the
+			// response to its request and work out the round trip time itself. This is
+			// answered straight away without touching the server.
+			return h.SendJson(Message{Event: PongEvent, Args: m.Args})
+		}
+	case ReconnectEvent:
+		{
+			token, err := h.redeemReconnectToken(strings.Join(m.Args, ""))
+			if err != nil {
+				return err
 			}
 
-			return nil
+			return h.authenticate(ctx, token)
 		}
 	case SetStateEvent:
 		{
-			action := server.PowerAction(strings.Join(m.Args, ""))
-
-			actions := make(map[server.PowerAction]string)
-			actions[server.PowerActionStart] = PermissionSendPowerStart
-			actions[server.PowerActionStop] = PermissionSendPowerStop
-			actions[server.PowerActionRestart] = PermissionSendPowerRestart
-			actions[server.PowerActionTerminate] = PermissionSendPowerStop
+			action, update := parsePowerArgs(m.Args)
 
 			// Check that they have permission to perform this action if it is needed.
-			if permission, exists := actions[action]; exists {
+			if permission, exists := powerActionPermissions[action]; exists {
 				if !h.GetJwt().HasPermission(permission) {
 					return nil
 				}
 			}
 
-			err := h.server.HandlePowerAction(action)
+			if h.server.PowerActionDisabled(action) {
+				_ = h.SendJson(Message{
+					Event: PowerActionDisabledEvent,
+					Args:  []string{string(action), server.ErrPowerActionDisabled.Error()},
+				})
+
+				return nil
+			}
+
+			var err error
+			if update {
+				if !h.GetJwt().HasPermission(PermissionUpdateImage) {
+					return nil
+				}
+				err = h.server.RestartWithImagePull()
+			} else {
+				err = h.server.HandlePowerAction(action)
+			}
+			if errors.Is(err, server.ErrPowerActionInProgress) {
+				_ = h.SendJson(Message{
+					Event: PowerActionInProgressEvent,
+					Args:  []string{string(action), err.Error()},
+				})
+
+				return nil
+			}
 			if errors.Is(err, system.ErrLockerLocked) {
 				m, _ := h.GetErrorMessage("another power action is currently being processed for this server, please try again later")
 
@@ -372,12 +909,39 @@ func (h *Handler) HandleInbound(ctx context.Context, m Message) error {
 				return nil
 			}
 
+			// Let the client know the action was rejected, rather than it looking like
+			// nothing happened.
+			if errors.Is(err, server.ErrIsRunning) || errors.Is(err, server.ErrInvalidPowerAction) {
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+
+				return nil
+			}
+
 			if err == nil {
 				h.server.SaveActivity(h.ra, models.Event(server.ActivityPowerPrefix+action), nil)
 			}
 
 			return err
 		}
+	case SendPowerActionsEvent:
+		{
+			actions := []string{}
+			for _, action := range h.server.AvailablePowerActions() {
+				if h.GetJwt().HasPermission(powerActionPermissions[action]) {
+					actions = append(actions, string(action))
+				}
+			}
+			_ = h.SendJson(Message{
+				Event: server.PowerActionsEvent,
+				Args:  actions,
+			})
+
+			return nil
+		}
 	case SendServerLogsEvent:
 		{
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
@@ -386,17 +950,109 @@ func (h *Handler) HandleInbound(ctx context.Context, m Message) error {
 				return nil
 			}
 
-			logs, err := h.server.Environment.Readlog(config.Get().System.WebsocketLogCount)
+			lines, err := parseLogCount(m.Args)
 			if err != nil {
 				return err
 			}
 
+			// Any lines read before an error are still sent, and the error is reported
+			// without closing the connection so that live output carries on.
+			logs, err := h.server.RecentLogs(lines)
 			for _, line := range logs {
+				if !h.shouldSendLine([]byte(line)) {
+					continue
+				}
 				_ = h.SendJson(Message{
 					Event: server.ConsoleOutputEvent,
 					Args:  []string{line},
 				})
 			}
+			if err != nil {
+				h.sendLogsFailed(err, len(logs))
+			}
+
+			return nil
+		}
+	case SendContainerLogsEvent:
+		{
+			if err := h.sendContainerLogs(ctx, m.Args); err != nil {
+				if !errors.Is(err, ErrInvalidLogTime) && !errors.Is(err, server.ErrInvalidLogRange) {
+					h.Logger().WithField("error", err).Warn("failed to read container logs")
+				}
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+			}
+			return nil
+		}
+	case SendLogFileEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionReadFile) {
+				return nil
+			}
+			if len(m.Args) == 0 {
+				return nil
+			}
+
+			lines, err := h.server.Filesystem().Tail(m.Args[0], config.Get().System.WebsocketLogCount)
+			if err != nil {
+				return err
+			}
+
+			_ = h.SendJson(Message{
+				Event: server.LogFileOutputEvent,
+				Args:  lines,
+			})
+
+			return nil
+		}
+	case SendInstallLogEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionReceiveInstall) {
+				return nil
+			}
+
+			n, err := parseLogCount(m.Args)
+			if err != nil {
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+
+				return nil
+			}
+
+			lines, err := h.server.InstallLog(n)
+			if err != nil {
+				return err
+			}
+
+			_ = h.SendJson(Message{
+				Event: InstallLogEvent,
+				Args:  lines,
+			})
+
+			return nil
+		}
+	case WatchFilesEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionReadFiles) {
+				return nil
+			}
+
+			dir := "/"
+			if len(m.Args) > 0 && m.Args[0] != "" {
+				dir = m.Args[0]
+			}
+
+			return h.watchFiles(ctx, dir)
+		}
+	case UnwatchFilesEvent:
+		{
+			h.unwatchFiles()
 
 			return nil
 		}
@@ -410,37 +1066,523 @@ func (h *Handler) HandleInbound(ctx context.Context, m Message) error {
 
 			return nil
 		}
-	case SendCommandEvent:
+	case SendStatsSnapshotEvent:
 		{
-			if !h.GetJwt().HasPermission(PermissionSendCommand) {
+			// This is sent as a normal stats event, with the ID the client sent so
+			// that it can tell the response apart from the ongoing stats.
+			b, _ := json.Marshal(h.server.StatsSnapshot(ctx))
+			args := []string{string(b)}
+			if len(m.Args) > 0 && m.Args[0] != "" {
+				args = append(args, m.Args[0])
+			}
+			_ = h.SendJson(Message{
+				Event: server.StatsEvent,
+				Args:  args,
+			})
+
+			return nil
+		}
+	case SendStatsTrendEvent:
+		{
+			b, _ := json.Marshal(h.server.ResourceTrend())
+			_ = h.SendJson(Message{
+				Event: server.StatsTrendEvent,
+				Args:  []string{string(b)},
+			})
+
+			return nil
+		}
+	case SubscribeBatchStatsEvent:
+		{
+			return h.subscribeBatchStats(ctx, m.Args)
+		}
+	case SubscribeConsolesEvent:
+		{
+			return h.subscribeConsoles(ctx, m.Args)
+		}
+	case SetStatsIntervalEvent:
+		{
+			if len(m.Args) == 0 {
+				return errors.WithStack(ErrInvalidInterval)
+			}
+			return h.setStatsInterval(m.Args[0])
+		}
+	case SendSubscriptionsEvent:
+		{
+			// The arguments are an optional ID used by the client to match up the
+			// response, which is sent back untouched.
+			var id string
+			if len(m.Args) > 0 {
+				id = m.Args[0]
+			}
+			b, err := json.Marshal(h.activeSubscriptions())
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return h.SendJson(Message{Event: SubscriptionsEvent, Args: []string{id, string(b)}})
+		}
+	case SetStatsUnitEvent:
+		{
+			if len(m.Args) == 0 {
+				return errors.WithStack(ErrInvalidStatsUnit)
+			}
+			return h.setStatsUnit(m.Args[0])
+		}
+	case SetCommandEchoEvent:
+		{
+			if len(m.Args) == 0 {
+				return ErrInvalidCommandEcho
+			}
+			return h.setCommandEcho(m.Args[0])
+		}
+	case SetConsoleTimestampsEvent:
+		{
+			return h.setConsoleTimestamps(m.Args)
+		}
+	case SetLogLevelEvent:
+		{
+			var level string
+			if len(m.Args) > 0 {
+				level = m.Args[0]
+			}
+			return h.setLogLevel(level)
+		}
+	case SendCrashDetailsEvent:
+		{
+			b, _ := json.Marshal(h.server.LastCrash())
+			_ = h.SendJson(Message{
+				Event: server.CrashDetailsEvent,
+				Args:  []string{string(b)},
+			})
+
+			return nil
+		}
+	case SendStartupCommandEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionReadStartup) {
 				return nil
 			}
 
-			if h.server.Environment.State() == environment.ProcessOfflineState {
+			_ = h.SendJson(Message{
+				Event: server.StartupCommandEvent,
+				Args:  []string{h.server.StartupCommand()},
+			})
+
+			return nil
+		}
+	case SendAutoStartEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionReadStartup) {
 				return nil
 			}
 
-			// TODO(dane): should probably add a new process state that is "booting environment" or something
-			//  so that we can better handle this and only set the environment to booted once we're attached.
-			//
-			//  Or maybe just an IsBooted function?
-			if h.server.Environment.State() == environment.ProcessStartingState {
-				if e, ok := h.server.Environment.(*docker.Environment); ok {
-					if !e.IsAttached() {
-						return nil
-					}
+			a, err := h.server.AutoStart()
+			if err != nil {
+				return err
+			}
+			_ = h.SendJson(Message{
+				Event: server.AutoStartEvent,
+				Args:  []string{string(a)},
+			})
+
+			return nil
+		}
+	case SetAutoStartEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionUpdateStartup) {
+				return nil
+			}
+			if len(m.Args) == 0 {
+				return errors.WithStack(server.ErrInvalidAutoStart)
+			}
+
+			// Every connected client, including this one, is sent the new setting
+			// once it has been saved.
+			return h.server.SetAutoStart(server.AutoStart(m.Args[0]))
+		}
+	case ReloadConfigurationEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionUpdateStartup) {
+				return nil
+			}
+
+			// Every connected client, including this one, is sent the configuration
+			// reloaded event once the new configuration is in use.
+			return h.server.Reload(ctx)
+		}
+	case StartBackupEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionCreateBackup) {
+				return nil
+			}
+			if len(m.Args) == 0 {
+				return nil
+			}
+			// The backup must already exist on the Panel, so only accept a backup
+			// token signed by the Panel for this server rather than a UUID picked
+			// by the client.
+			var token tokens.BackupPayload
+			if err := tokens.ParseToken([]byte(m.Args[0]), &token); err != nil {
+				return errors.WithStack(ErrInvalidBackup)
+			}
+			if token.ServerUuid != h.server.ID() || !token.IsUniqueRequest() {
+				return errors.WithStack(ErrInvalidBackup)
+			}
+			// The UUID is used as the name of the archive on the disk, so it must
+			// be an actual UUID and not a path.
+			if _, err := uuid.Parse(token.BackupUuid); err != nil {
+				return errors.WithStack(ErrInvalidBackup)
+			}
+
+			adapter := backup.LocalBackupAdapter
+			if len(m.Args) > 1 {
+				adapter = backup.AdapterType(m.Args[1])
+			}
+
+			return h.server.StartBackup(token.BackupUuid, adapter)
+		}
+	case CancelBackupEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionCreateBackup) {
+				return nil
+			}
+			if len(m.Args) == 0 {
+				return nil
+			}
+
+			h.server.CancelBackup(m.Args[0])
+
+			return nil
+		}
+	case SendLimitsEvent:
+		{
+			b, _ := json.Marshal(h.server.ResourceLimits())
+			_ = h.SendJson(Message{
+				Event: server.LimitsEvent,
+				Args:  []string{string(b)},
+			})
+
+			return nil
+		}
+	case SetDebugLoggingEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionDebugLogging) {
+				return nil
+			}
+			if err := h.setDebugLogging(m.Args); err != nil {
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+			}
+			return nil
+		}
+	case SendIdleStatusEvent:
+		{
+			b, err := json.Marshal(h.server.IdleStatus())
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return h.SendJson(Message{Event: IdleStatusEvent, Args: []string{string(b)}})
+		}
+	case SendPlayerCountEvent:
+		{
+			// Checking the player count sends a command to the server, so the same
+			// checks are made as for any other command sent by the user.
+			if !h.GetJwt().HasPermission(PermissionSendCommand) {
+				return nil
+			}
+			count, err := h.server.PlayerCount(ctx, h.checkPlayerCountCommand)
+			if err != nil {
+				if !errors.Is(err, server.ErrPlayerCountDisabled) && !errors.Is(err, server.ErrPlayerCountUnavailable) &&
+					!errors.Is(err, server.ErrNotRunning) && !errors.Is(err, ErrCommandRateLimited) {
+					h.Logger().WithField("error", err).Warn("failed to check server player count")
 				}
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+				return nil
+			}
+			b, err := json.Marshal(count)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return h.SendJson(Message{Event: PlayerCountEvent, Args: []string{string(b)}})
+		}
+	case SendNodeResourcesEvent:
+		{
+			// This is only needed to decide if the server can be started, so anyone
+			// that cannot start it has no need to see the resources on the node.
+			if !h.GetJwt().HasPermission(PermissionSendPowerStart) {
+				return nil
+			}
+			res, err := h.manager.NodeResources()
+			if err != nil {
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+				return nil
+			}
+			b, err := json.Marshal(res)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return h.SendJson(Message{Event: NodeResourcesEvent, Args: []string{string(b)}})
+		}
+	case ResizeEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionSendCommand) {
+				return nil
 			}
 
-			if err := h.server.Environment.SendCommand(strings.Join(m.Args, "")); err != nil {
+			rows, cols, err := parseDimensions(m.Args)
+			if err != nil {
 				return err
 			}
-			h.server.SaveActivity(h.ra, server.ActivityConsoleCommand, models.ActivityMeta{
-				"command": strings.Join(m.Args, ""),
-			})
+
+			if e, ok := h.server.Environment.(*docker.Environment); ok && h.server.IsRunning() {
+				return e.Resize(ctx, rows, cols)
+			}
+
+			return nil
+		}
+	case ExecEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionExec) {
+				return nil
+			}
+			if err := h.execCommand(ctx, m.Args); err != nil {
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+			}
+			return nil
+		}
+	case SendProcessesEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionProcesses) {
+				return nil
+			}
+			list, err := h.server.Processes(ctx)
+			if err != nil {
+				if !errors.Is(err, server.ErrProcessesDisabled) && !errors.Is(err, server.ErrNotRunning) {
+					h.Logger().WithField("error", err).Warn("failed to list processes in container")
+				}
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+				return nil
+			}
+			b, err := json.Marshal(list)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			return h.SendJson(Message{Event: ProcessesEvent, Args: []string{string(b)}, Metadata: h.metadata})
+		}
+	case CaptureCommandEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionSendCommand) {
+				return nil
+			}
+			if h.server.CommandsDisabled() {
+				_ = h.SendJson(Message{Event: server.CommandsDisabledEvent})
+				return nil
+			}
+			if !h.allowCommand() {
+				m, _ := h.GetErrorMessage(ErrCommandRateLimited.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+				return nil
+			}
+
+			if err := h.captureCommand(ctx, m.Args); err != nil {
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+			}
+
+			return nil
+		}
+	case AwaitCommandResponseEvent:
+		{
+			if !h.GetJwt().HasPermission(PermissionSendCommand) {
+				return nil
+			}
+			if h.server.CommandsDisabled() {
+				_ = h.SendJson(Message{Event: server.CommandsDisabledEvent})
+				return nil
+			}
+			if !h.allowCommand() {
+				m, _ := h.GetErrorMessage(ErrCommandRateLimited.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+				return nil
+			}
+
+			if err := h.awaitCommandResponse(ctx, m.Args); err != nil {
+				m, _ := h.GetErrorMessage(err.Error())
+				_ = h.SendJson(Message{
+					Event: ErrorEvent,
+					Args:  []string{m},
+				})
+			}
+
 			return nil
 		}
+	case SendCommandEvent:
+		{
+			command := strings.Join(m.Args, "")
+			if err := CheckCommand(h.server, h.GetJwt(), command, h.allowCommand); err != nil {
+				switch {
+				case errors.Is(err, ErrCommandPermission):
+				case errors.Is(err, ErrCommandsDisabled):
+					_ = h.SendJson(Message{Event: server.CommandsDisabledEvent})
+				default:
+					m, _ := h.GetErrorMessage(err.Error())
+					_ = h.SendJson(Message{
+						Event: ErrorEvent,
+						Args:  []string{m},
+					})
+				}
+
+				return nil
+			}
+
+			res, err := SendCommand(h.server, h.ra, command)
+			if err != nil {
+				// A command that cannot be sent to a stopped server is rejected so that
+				// the client is not left wondering why nothing happened.
+				if errors.Is(err, server.ErrNotRunning) || errors.Is(err, server.ErrCommandQueueFull) {
+					m, _ := h.GetErrorMessage(err.Error())
+					return h.SendJson(Message{Event: ErrorEvent, Args: []string{m}})
+				}
+				return err
+			}
+			if res.Queued {
+				return h.SendJson(Message{Event: CommandQueuedEvent, Args: []string{command}})
+			}
+			return h.EchoCommand(command)
+		}
 	}
 
 	return nil
 }
+
+// CloseWithReason sends a close frame with the given code and reason to the
+// client and then closes the underlying connection.
+func (h *Handler) CloseWithReason(code int, reason string) {
+	h.Logger().WithField("code", code).WithField("reason", reason).Debug("closing websocket connection")
+
+	msg := websocket.FormatCloseMessage(code, reason)
+	if err := h.Connection.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second*5)); err != nil {
+		h.Logger().WithField("error", err).Debug("failed to write close message to websocket connection")
+	}
+	if err := h.Connection.Close(); err != nil {
+		h.Logger().WithField("error", errors.WithStack(err)).Error("error closing websocket connection")
+	}
+}
+
+// The permission needed to perform each of the power actions.
+var powerActionPermissions = map[server.PowerAction]string{
+	server.PowerActionStart:     PermissionSendPowerStart,
+	server.PowerActionStop:      PermissionSendPowerStop,
+	server.PowerActionRestart:   PermissionSendPowerRestart,
+	server.PowerActionTerminate: PermissionSendPowerStop,
+}
+
+// parsePowerArgs returns the power action from the arguments of a set state
+// event, and if the image should also be updated. An update can only be requested
+// for a restart by passing "update" as the final argument.
+func parsePowerArgs(args []string) (server.PowerAction, bool) {
+	if len(args) > 1 && args[len(args)-1] == "update" {
+		action := server.PowerAction(strings.Join(args[:len(args)-1], ""))
+		if action == server.PowerActionRestart {
+			return action, true
+		}
+	}
+	return server.PowerAction(strings.Join(args, "")), false
+}
+
+// The largest terminal dimensions that can be sent in a resize event.
+const (
+	maxTerminalRows = 500
+	maxTerminalCols = 1000
+)
+
+// parseDimensions parses the rows and columns sent in a resize event, ensuring
+// that both are within a reasonable range.
+func parseDimensions(args []string) (uint, uint, error) {
+	if len(args) != 2 {
+		return 0, 0, ErrInvalidDimensions
+	}
+	rows, err := strconv.Atoi(args[0])
+	if err != nil || rows < 1 || rows > maxTerminalRows {
+		return 0, 0, errors.WithMessage(ErrInvalidDimensions, "rows")
+	}
+	cols, err := strconv.Atoi(args[1])
+	if err != nil || cols < 1 || cols > maxTerminalCols {
+		return 0, 0, errors.WithMessage(ErrInvalidDimensions, "cols")
+	}
+	return uint(rows), uint(cols), nil
+}
+
+// requiresBackend returns true if handling the event needs the Docker daemon to
+// be reachable.
+func requiresBackend(event string) bool {
+	switch event {
+	case SetStateEvent, SendServerLogsEvent, SendContainerLogsEvent, SendCommandEvent, CaptureCommandEvent, AwaitCommandResponseEvent, SendPlayerCountEvent, ExecEvent, SendProcessesEvent, ResizeEvent, ReloadConfigurationEvent:
+		return true
+	}
+	return false
+}
+
+// parseLogCount returns the number of console lines requested in a logs event,
+// falling back to the configured default when no count is sent. The count is
+// clamped between one line and the configured maximum.
+func parseLogCount(args []string) (int, error) {
+	cfg := config.Get().System
+	if len(args) == 0 || args[0] == "" {
+		return cfg.WebsocketLogCount, nil
+	}
+	lines, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, errors.WithMessage(ErrInvalidLogCount, args[0])
+	}
+	if lines < 1 {
+		lines = 1
+	}
+	if max := cfg.Websocket.MaxLogCount; max > 0 && lines > max {
+		lines = max
+	}
+	return lines, nil
+}
+
+// ValidateCommand returns an error if the command cannot be sent to a server.
+// These are the same checks applied to commands sent over the websocket.
+func ValidateCommand(command string) error {
+	return checkCommandLength(command, config.Get().System.Websocket.MaxCommandLength)
+}
+
+// checkCommandLength returns an error if the command exceeds the maximum length
+// provided. A maximum of zero (or less) disables the check entirely.
+func checkCommandLength(command string, max int) error {
+	if max > 0 && len(command) > max {
+		return errors.WithMessagef(ErrCommandTooLong, "%d > %d bytes", len(command), max)
+	}
+	return nil
+}
@@ -0,0 +1,830 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+	"github.com/gbrlsnchs/jwt/v3"
+	ws "github.com/gorilla/websocket"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/router/tokens"
+	"github.com/pterodactyl/wings/server"
+)
+
+func TestCheckCommandLength(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("checkCommandLength", func() {
+		g.It("allows commands within the limit", func() {
+			g.Assert(checkCommandLength("say hello", 16)).IsNil()
+			g.Assert(checkCommandLength(strings.Repeat("a", 16), 16)).IsNil()
+		})
+
+		g.It("rejects an oversized command", func() {
+			err := checkCommandLength(strings.Repeat("a", 4097), 4096)
+			g.Assert(err == nil).IsFalse()
+			g.Assert(errors.Is(err, ErrCommandTooLong)).IsTrue()
+		})
+
+		g.It("does not check the length when the limit is disabled", func() {
+			g.Assert(checkCommandLength(strings.Repeat("a", 1<<16), 0)).IsNil()
+		})
+	})
+}
+
+func TestParsePowerArgs(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("parsePowerArgs", func() {
+		g.It("parses a plain power action", func() {
+			action, update := parsePowerArgs([]string{"restart"})
+			g.Assert(string(action)).Equal(server.PowerActionRestart)
+			g.Assert(update).IsFalse()
+		})
+
+		g.It("parses a restart with an update", func() {
+			action, update := parsePowerArgs([]string{"restart", "update"})
+			g.Assert(string(action)).Equal(server.PowerActionRestart)
+			g.Assert(update).IsTrue()
+		})
+
+		g.It("only allows an update for restarts", func() {
+			action, update := parsePowerArgs([]string{"start", "update"})
+			g.Assert(string(action)).Equal("startupdate")
+			g.Assert(update).IsFalse()
+		})
+	})
+}
+
+func TestHandler_StatsInterval(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#shouldSendStats", func() {
+		g.It("sends every sample by default", func() {
+			h := &Handler{}
+			for i := 0; i < 3; i++ {
+				g.Assert(h.shouldSendStats()).IsTrue()
+			}
+		})
+
+		g.It("sends every Nth sample when an interval is set", func() {
+			h := &Handler{}
+			g.Assert(h.setStatsInterval("3")).IsNil()
+
+			var sent []bool
+			for i := 0; i < 6; i++ {
+				sent = append(sent, h.shouldSendStats())
+			}
+			g.Assert(sent).Equal([]bool{true, false, false, true, false, false})
+		})
+
+		g.It("does not allow an interval below the collection rate", func() {
+			h := &Handler{}
+			g.Assert(h.setStatsInterval("0")).IsNil()
+			g.Assert(h.statsInterval).Equal(statsCollectionInterval)
+		})
+
+		g.It("rejects an invalid interval", func() {
+			h := &Handler{}
+			err := h.setStatsInterval("soon")
+			g.Assert(errors.Is(err, ErrInvalidInterval)).IsTrue()
+		})
+	})
+}
+
+func TestHandler_EventPermissions(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#hasEventPermission", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.EventPermissions = map[string][]string{
+				server.StatsEvent:           {"websocket.stats"},
+				server.BackupCompletedEvent: {"backup.read", "backup.notify"},
+			}
+			config.Set(c)
+		})
+
+		token := func(permissions ...string) *tokens.WebsocketPayload {
+			return &tokens.WebsocketPayload{
+				Payload:     jwt.Payload{IssuedAt: jwt.NumericDate(time.Now().Add(time.Minute))},
+				Permissions: permissions,
+			}
+		}
+
+		g.It("allows events without any configured permissions", func() {
+			h := &Handler{jwt: token()}
+			g.Assert(h.hasEventPermission(server.ConsoleOutputEvent)).IsTrue()
+		})
+
+		g.It("requires the configured permissions", func() {
+			h := &Handler{jwt: token()}
+			g.Assert(h.hasEventPermission(server.StatsEvent)).IsFalse()
+
+			h = &Handler{jwt: token("websocket.stats")}
+			g.Assert(h.hasEventPermission(server.StatsEvent)).IsTrue()
+		})
+
+		g.It("requires every configured permission", func() {
+			h := &Handler{jwt: token("backup.read")}
+			g.Assert(h.hasEventPermission(server.BackupCompletedEvent)).IsFalse()
+		})
+
+		g.It("uses the base event for namespaced events", func() {
+			h := &Handler{jwt: token("backup.read", "backup.notify")}
+			g.Assert(h.hasEventPermission(server.BackupCompletedEvent + ":1234")).IsTrue()
+		})
+	})
+}
+
+func TestParseDimensions(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("parseDimensions", func() {
+		g.It("parses valid dimensions", func() {
+			rows, cols, err := parseDimensions([]string{"24", "80"})
+			g.Assert(err).IsNil()
+			g.Assert(rows).Equal(uint(24))
+			g.Assert(cols).Equal(uint(80))
+		})
+
+		g.It("rejects dimensions that are out of range", func() {
+			for _, args := range [][]string{{"0", "80"}, {"24", "0"}, {"501", "80"}, {"24", "1001"}, {"-1", "80"}} {
+				_, _, err := parseDimensions(args)
+				g.Assert(errors.Is(err, ErrInvalidDimensions)).IsTrue()
+			}
+		})
+
+		g.It("rejects malformed arguments", func() {
+			for _, args := range [][]string{{}, {"24"}, {"24", "80", "1"}, {"tall", "80"}} {
+				_, _, err := parseDimensions(args)
+				g.Assert(errors.Is(err, ErrInvalidDimensions)).IsTrue()
+			}
+		})
+	})
+}
+
+func TestParseLogCount(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("parseLogCount", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.WebsocketLogCount = 150
+			c.System.Websocket.MaxLogCount = 1000
+			config.Set(c)
+		})
+
+		g.It("uses the configured count when none is requested", func() {
+			lines, err := parseLogCount(nil)
+			g.Assert(err).IsNil()
+			g.Assert(lines).Equal(150)
+		})
+
+		g.It("uses the requested count", func() {
+			lines, err := parseLogCount([]string{"500"})
+			g.Assert(err).IsNil()
+			g.Assert(lines).Equal(500)
+		})
+
+		g.It("clamps the requested count", func() {
+			lines, _ := parseLogCount([]string{"5000"})
+			g.Assert(lines).Equal(1000)
+
+			lines, _ = parseLogCount([]string{"-10"})
+			g.Assert(lines).Equal(1)
+		})
+
+		g.It("rejects counts that are not numbers", func() {
+			_, err := parseLogCount([]string{"lots"})
+			g.Assert(errors.Is(err, ErrInvalidLogCount)).IsTrue()
+		})
+	})
+}
+
+func TestStringArgs(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("stringArgs", func() {
+		g.It("converts a decoded slice of strings", func() {
+			args, ok := stringArgs([]interface{}{"offline", "crashed"})
+			g.Assert(ok).IsTrue()
+			g.Assert(args).Equal([]string{"offline", "crashed"})
+		})
+
+		g.It("rejects slices containing other types", func() {
+			_, ok := stringArgs([]interface{}{"offline", float64(1)})
+			g.Assert(ok).IsFalse()
+
+			_, ok = stringArgs(map[string]interface{}{})
+			g.Assert(ok).IsFalse()
+		})
+	})
+}
+
+func TestHandler_Subscribe(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#subscribe", func() {
+		g.It("receives all events without a subscription", func() {
+			h := &Handler{}
+			g.Assert(h.isSubscribed(server.ConsoleOutputEvent)).IsTrue()
+			g.Assert(h.isSubscribed(server.StatsEvent)).IsTrue()
+		})
+
+		g.It("only receives subscribed server events", func() {
+			h := &Handler{}
+			g.Assert(h.subscribe([]string{server.StatsEvent, server.StatusEvent})).IsNil()
+			g.Assert(h.isSubscribed(server.StatsEvent)).IsTrue()
+			g.Assert(h.isSubscribed(server.StatusEvent)).IsTrue()
+			g.Assert(h.isSubscribed(server.ConsoleOutputEvent)).IsFalse()
+			g.Assert(h.isSubscribed(AuthenticationSuccessEvent)).IsTrue()
+		})
+
+		g.It("rejects unknown events", func() {
+			h := &Handler{}
+			err := h.subscribe([]string{"not an event"})
+			g.Assert(errors.Is(err, ErrUnknownSubscription)).IsTrue()
+			g.Assert(h.isSubscribed(server.ConsoleOutputEvent)).IsTrue()
+		})
+
+		g.It("reports the events the connection receives", func() {
+			config.Set(&config.Configuration{AuthenticationToken: "test"})
+			h := &Handler{}
+			g.Assert(h.subscribe([]string{server.StatsEvent, server.InstallOutputEvent})).IsNil()
+
+			h.jwt = &tokens.WebsocketPayload{
+				Payload:     jwt.Payload{IssuedAt: jwt.NumericDate(time.Now().Add(time.Minute))},
+				Permissions: []string{PermissionConnect},
+			}
+			g.Assert(h.activeSubscriptions()).Equal(Subscriptions{Events: []string{server.StatsEvent}, Selective: true})
+
+			h.jwt.Permissions = append(h.jwt.Permissions, PermissionReceiveInstall)
+			g.Assert(h.activeSubscriptions().Events).Equal([]string{server.StatsEvent, server.InstallOutputEvent})
+		})
+	})
+}
+
+func TestHandler_ConsoleOutput(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#consoleOutput", func() {
+		g.It("only sends the line to clients that did not ask for the stream", func() {
+			h := &Handler{}
+			m := h.consoleOutput([]byte("error"), environment.Stderr)
+			g.Assert(m.Event).Equal(server.ConsoleOutputEvent)
+			g.Assert(m.Args).Equal([]string{"error"})
+		})
+
+		g.It("includes the stream for clients using the subprotocol", func() {
+			h := &Handler{consoleStreams: true}
+			g.Assert(h.consoleOutput([]byte("hello"), environment.Stdout).Args).Equal([]string{"hello", "stdout"})
+			g.Assert(h.consoleOutput([]byte("error"), environment.Stderr).Args).Equal([]string{"error", "stderr"})
+		})
+
+		g.It("includes a timestamp for clients using the subprotocol when enabled", func() {
+			h := &Handler{consoleStreams: true}
+			g.Assert(h.setConsoleTimestamps([]string{"true", "", TimestampFormatUnix})).IsNil()
+			args := h.consoleOutput([]byte("hello"), environment.Stdout).Args
+			g.Assert(len(args)).Equal(3)
+			sent, err := strconv.ParseInt(args[2], 10, 64)
+			g.Assert(err).IsNil()
+			g.Assert(time.Now().Unix()-sent <= 1).IsTrue()
+
+			h = &Handler{timestamps: consoleTimestamps{enabled: true}}
+			g.Assert(h.consoleOutput([]byte("hello"), environment.Stdout).Args).Equal([]string{"hello"})
+		})
+	})
+}
+
+func TestConsoleTimestamps(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("consoleTimestamps", func() {
+		now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+		g.It("defaults to RFC3339 in UTC", func() {
+			g.Assert(consoleTimestamps{}.stamp(now)).Equal("2024-01-02T15:04:05Z")
+		})
+
+		g.It("uses the timezone and layout", func() {
+			loc := time.FixedZone("test", 2*60*60)
+			g.Assert(consoleTimestamps{format: "15:04:05", location: loc}.stamp(now)).Equal("17:04:05")
+			g.Assert(consoleTimestamps{format: TimestampFormatRFC3339, location: loc}.stamp(now)).Equal("2024-01-02T17:04:05+02:00")
+		})
+
+		g.It("falls back to UTC for unknown timezones", func() {
+			loc, err := loadTimezone("Not/AZone")
+			g.Assert(err == nil).IsFalse()
+			g.Assert(loc).Equal(time.UTC)
+
+			loc, err = loadTimezone("")
+			g.Assert(err).IsNil()
+			g.Assert(loc).Equal(time.UTC)
+		})
+
+		g.It("rejects invalid values", func() {
+			h := &Handler{}
+			g.Assert(errors.Is(h.setConsoleTimestamps(nil), ErrInvalidConsoleTimestamps)).IsTrue()
+			g.Assert(errors.Is(h.setConsoleTimestamps([]string{"maybe"}), ErrInvalidConsoleTimestamps)).IsTrue()
+		})
+	})
+}
+
+func TestHandler_SetCommandEcho(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#setCommandEcho", func() {
+		g.It("is disabled by default", func() {
+			g.Assert((&Handler{}).echoCommands).IsFalse()
+		})
+
+		g.It("can be enabled and disabled", func() {
+			h := &Handler{}
+			g.Assert(h.setCommandEcho("true")).IsNil()
+			g.Assert(h.echoCommands).IsTrue()
+			g.Assert(h.setCommandEcho("false")).IsNil()
+			g.Assert(h.echoCommands).IsFalse()
+		})
+
+		g.It("rejects invalid values", func() {
+			h := &Handler{}
+			g.Assert(errors.Is(h.setCommandEcho("sure"), ErrInvalidCommandEcho)).IsTrue()
+			g.Assert(h.echoCommands).IsFalse()
+		})
+	})
+}
+
+func TestHandler_UpdatePermissions(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#UpdatePermissions", func() {
+		g.It("replaces the permissions of tokens issued before the update", func() {
+			h := &Handler{server: &server.Server{}}
+			old := &tokens.WebsocketPayload{
+				Payload:     jwt.Payload{IssuedAt: jwt.NumericDate(time.Now().Add(-time.Minute))},
+				UserUUID:    "user",
+				Permissions: []string{PermissionConnect, PermissionSendCommand},
+			}
+
+			h.server.UpdateUserPermissions("user", []string{PermissionConnect})
+			h.setJwt(old)
+			g.Assert(old.Permissions).Equal([]string{PermissionConnect})
+		})
+
+		g.It("keeps the permissions of tokens issued after the update", func() {
+			h := &Handler{server: &server.Server{}}
+			h.server.UpdateUserPermissions("user", []string{PermissionConnect})
+
+			token := &tokens.WebsocketPayload{
+				Payload:     jwt.Payload{IssuedAt: jwt.NumericDate(time.Now().Add(time.Minute))},
+				UserUUID:    "user",
+				Permissions: []string{PermissionConnect, PermissionSendCommand},
+			}
+			h.setJwt(token)
+			g.Assert(token.Permissions).Equal([]string{PermissionConnect, PermissionSendCommand})
+		})
+
+		g.It("does not change the permissions of other users", func() {
+			s := &server.Server{}
+			s.UpdateUserPermissions("user", []string{PermissionConnect})
+
+			token := &tokens.WebsocketPayload{
+				Payload:     jwt.Payload{IssuedAt: jwt.NumericDate(time.Now().Add(-time.Minute))},
+				UserUUID:    "other",
+				Permissions: []string{PermissionConnect, PermissionSendCommand},
+			}
+			ApplyPermissionOverrides(s, token)
+			g.Assert(token.Permissions).Equal([]string{PermissionConnect, PermissionSendCommand})
+		})
+	})
+}
+
+func TestHandler_SubscribeConsoles(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#subscribeConsoles", func() {
+		g.It("rejects more servers than the configured limit", func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.MaxConsoleServers = 1
+			config.Set(c)
+
+			h := &Handler{server: &server.Server{}}
+			err := h.subscribeConsoles(context.Background(), []string{"one", "two"})
+			g.Assert(errors.Is(err, ErrTooManyConsoles)).IsTrue()
+			g.Assert(h.consolesCancel == nil).IsTrue()
+		})
+	})
+}
+
+func TestHandler_SessionLimit(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#sessionLimit", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.MaxSessionDuration = 3600
+			config.Set(c)
+		})
+
+		g.It("uses the configured limit without a token", func() {
+			h := &Handler{}
+			g.Assert(h.sessionLimit()).Equal(time.Hour)
+		})
+
+		g.It("uses the token limit when it is shorter", func() {
+			h := &Handler{jwt: &tokens.WebsocketPayload{MaxSessionDuration: 60}}
+			g.Assert(h.sessionLimit()).Equal(time.Minute)
+
+			h = &Handler{jwt: &tokens.WebsocketPayload{MaxSessionDuration: 7200}}
+			g.Assert(h.sessionLimit()).Equal(time.Hour)
+		})
+
+		g.It("uses the token limit when none is configured", func() {
+			config.Set(&config.Configuration{AuthenticationToken: "test"})
+			h := &Handler{jwt: &tokens.WebsocketPayload{MaxSessionDuration: 60}}
+			g.Assert(h.sessionLimit()).Equal(time.Minute)
+
+			h = &Handler{}
+			g.Assert(h.sessionLimit()).Equal(time.Duration(0))
+		})
+
+		g.It("does not extend the limit when a new token is sent", func() {
+			h := &Handler{jwt: &tokens.WebsocketPayload{MaxSessionDuration: 60}}
+			g.Assert(h.sessionLimit()).Equal(time.Minute)
+
+			h.jwt = &tokens.WebsocketPayload{MaxSessionDuration: 1800}
+			g.Assert(h.sessionLimit()).Equal(time.Minute)
+
+			h.jwt = &tokens.WebsocketPayload{}
+			g.Assert(h.sessionLimit()).Equal(time.Minute)
+
+			h.jwt = &tokens.WebsocketPayload{MaxSessionDuration: 30}
+			g.Assert(h.sessionLimit()).Equal(time.Second * 30)
+		})
+	})
+}
+
+func TestHandler_ShouldCompress(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#shouldCompress", func() {
+		g.It("compresses large log messages when other messages are not compressed", func() {
+			h := &Handler{compressionThreshold: -1, logCompressionThreshold: 1024}
+			g.Assert(h.shouldCompress(Message{Event: InstallLogEvent}, 2048)).IsTrue()
+			g.Assert(h.shouldCompress(Message{Event: server.LogFileOutputEvent}, 2048)).IsTrue()
+			g.Assert(h.shouldCompress(Message{Event: InstallLogEvent}, 512)).IsFalse()
+			g.Assert(h.shouldCompress(Message{Event: server.StatsEvent}, 2048)).IsFalse()
+		})
+
+		g.It("does not compress anything when compression is disabled", func() {
+			h := &Handler{compressionThreshold: -1, logCompressionThreshold: -1}
+			g.Assert(h.shouldCompress(Message{Event: InstallLogEvent}, 1<<20)).IsFalse()
+		})
+	})
+}
+
+func TestCompressionDisabledByClient(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("compressionDisabledByClient", func() {
+		g.It("uses the node setting by default", func() {
+			g.Assert(compressionDisabledByClient(httptest.NewRequest("GET", "/ws", nil))).IsFalse()
+		})
+
+		g.It("allows the client to opt out", func() {
+			g.Assert(compressionDisabledByClient(httptest.NewRequest("GET", "/ws?compression=false", nil))).IsTrue()
+			g.Assert(compressionDisabledByClient(httptest.NewRequest("GET", "/ws?compression=0", nil))).IsTrue()
+			g.Assert(compressionDisabledByClient(httptest.NewRequest("GET", "/ws?compression=true", nil))).IsFalse()
+		})
+
+		g.It("ignores invalid values", func() {
+			g.Assert(compressionDisabledByClient(httptest.NewRequest("GET", "/ws?compression=maybe", nil))).IsFalse()
+		})
+	})
+}
+
+// BenchmarkConsoleCompression compares the CPU cost and resulting size of
+// compressing console output at different compression levels. This uses the
+// same flate implementation that the websocket library uses for per-message
+// compression.
+func BenchmarkConsoleCompression(b *testing.B) {
+	line := `{"event":"console output","args":["[12:34:56] [Server thread/INFO]: Preparing spawn area: 42%"]}`
+	messages := map[string][]byte{
+		"short": []byte(line),
+		"long":  []byte(strings.Repeat(line, 50)),
+	}
+
+	for name, msg := range messages {
+		for _, level := range []int{flate.BestSpeed, 5, flate.BestCompression} {
+			b.Run(fmt.Sprintf("%s/level-%d", name, level), func(b *testing.B) {
+				var buf bytes.Buffer
+				w, _ := flate.NewWriter(&buf, level)
+
+				b.ReportAllocs()
+				b.SetBytes(int64(len(msg)))
+				for i := 0; i < b.N; i++ {
+					buf.Reset()
+					w.Reset(&buf)
+					_, _ = w.Write(msg)
+					_ = w.Flush()
+				}
+				b.ReportMetric(float64(buf.Len()), "wire-bytes/op")
+			})
+		}
+	}
+}
+
+func TestNewTokenPayload(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("NewTokenPayload", func() {
+		g.BeforeEach(func() {
+			config.Set(&config.Configuration{AuthenticationToken: "test"})
+		})
+
+		sign := func(p jwt.Payload) []byte {
+			b, err := jwt.Sign(&tokens.WebsocketPayload{
+				Payload:     p,
+				ServerUUID:  "uuid",
+				Permissions: []string{PermissionConnect},
+			}, config.GetJwtAlgorithm())
+			g.Assert(err).IsNil()
+			return b
+		}
+		// The issued at time must come after Wings booted for the token to not be
+		// denylisted, while the not before time must already have passed.
+		now := time.Now().Add(time.Minute)
+		nbf := jwt.NumericDate(time.Now().Add(-time.Minute))
+
+		g.It("accepts a token with every time claim", func() {
+			b := sign(jwt.Payload{
+				IssuedAt:       jwt.NumericDate(now),
+				NotBefore:      nbf,
+				ExpirationTime: jwt.NumericDate(now.Add(time.Hour)),
+			})
+			token, err := NewTokenPayload(b)
+			g.Assert(err).IsNil()
+			g.Assert(token.GetServerUuid()).Equal("uuid")
+		})
+
+		g.It("rejects a token without an expiration time", func() {
+			b := sign(jwt.Payload{IssuedAt: jwt.NumericDate(now), NotBefore: nbf})
+			_, err := NewTokenPayload(b)
+			g.Assert(errors.Is(err, ErrJwtNoExpiration)).IsTrue()
+			g.Assert(IsJwtError(err)).IsTrue()
+		})
+
+		g.It("rejects a token without a not before time", func() {
+			b := sign(jwt.Payload{IssuedAt: jwt.NumericDate(now), ExpirationTime: jwt.NumericDate(now.Add(time.Hour))})
+			_, err := NewTokenPayload(b)
+			g.Assert(errors.Is(err, ErrJwtNoNotBefore)).IsTrue()
+			g.Assert(IsJwtError(err)).IsTrue()
+		})
+	})
+}
+
+func TestHandler_AwaitCommandResponse(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#awaitCommandResponse", func() {
+		g.It("requires an id, command, and pattern", func() {
+			h := &Handler{}
+			g.Assert(errors.Is(h.awaitCommandResponse(context.Background(), []string{"one", "list"}), ErrInvalidCommandResponse)).IsTrue()
+			g.Assert(errors.Is(h.awaitCommandResponse(context.Background(), []string{"", "list", "players"}), ErrInvalidCommandResponse)).IsTrue()
+			g.Assert(errors.Is(h.awaitCommandResponse(context.Background(), []string{"one", "list", "players", "soon"}), ErrInvalidCommandResponse)).IsTrue()
+		})
+
+		g.It("rejects the capture ids reserved for wings", func() {
+			h := &Handler{}
+			g.Assert(errors.Is(h.awaitCommandResponse(context.Background(), []string{"wings:player-count", "list", "players"}), ErrInvalidCommandResponse)).IsTrue()
+			g.Assert(errors.Is(h.captureCommand(context.Background(), []string{"wings:player-count", "list"}), ErrInvalidCapture)).IsTrue()
+		})
+
+		g.It("rejects patterns that cannot be compiled", func() {
+			h := &Handler{}
+			g.Assert(errors.Is(h.awaitCommandResponse(context.Background(), []string{"one", "list", "players: (\\d+"}), ErrInvalidResponsePattern)).IsTrue()
+			g.Assert(errors.Is(h.awaitCommandResponse(context.Background(), []string{"one", "list", strings.Repeat("a", maxResponsePatternLength+1)}), ErrInvalidResponsePattern)).IsTrue()
+		})
+	})
+}
+
+func TestSetKeepalive(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("setKeepalive", func() {
+		cfg := config.WebsocketKeepalive{Enabled: true, Idle: 30, Interval: 10}
+
+		g.It("enables keepalive on TCP connections", func() {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			g.Assert(err).IsNil()
+			defer l.Close()
+
+			conn, err := net.Dial("tcp", l.Addr().String())
+			g.Assert(err).IsNil()
+			defer conn.Close()
+
+			g.Assert(setKeepalive(conn, cfg)).IsNil()
+		})
+
+		g.It("ignores connections that are not TCP", func() {
+			a, b := net.Pipe()
+			defer a.Close()
+			defer b.Close()
+
+			g.Assert(setKeepalive(a, cfg)).IsNil()
+		})
+	})
+}
+
+func TestHandler_ReadMessage(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Handler#ReadMessage", func() {
+		const limit = 256
+
+		// connect opens a websocket connection to a handler with the limit applied,
+		// returning the client side of the connection and a channel that receives
+		// the result of reading a single message on the server side.
+		connect := func(compress bool) (*ws.Conn, <-chan error) {
+			result := make(chan error, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upgrader := ws.Upgrader{EnableCompression: compress}
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					result <- err
+					return
+				}
+				defer conn.Close()
+				conn.SetReadLimit(limit)
+				h := &Handler{Connection: conn, maxMessageSize: limit}
+				_, err = h.ReadMessage()
+				result <- err
+			}))
+			t.Cleanup(srv.Close)
+
+			// A small write buffer splits a large message into many frames.
+			dialer := ws.Dialer{WriteBufferSize: 64, EnableCompression: compress}
+			conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+			g.Assert(err).IsNil()
+			t.Cleanup(func() { conn.Close() })
+			return conn, result
+		}
+
+		expectClosed := func(conn *ws.Conn) {
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+			_, _, err := conn.ReadMessage()
+			g.Assert(ws.IsCloseError(err, ws.CloseMessageTooBig)).IsTrue()
+		}
+
+		g.It("reads messages within the limit", func() {
+			conn, result := connect(false)
+			g.Assert(conn.WriteMessage(ws.TextMessage, []byte(strings.Repeat("a", limit)))).IsNil()
+			g.Assert(<-result).IsNil()
+		})
+
+		g.It("rejects a message split over many frames that exceeds the limit", func() {
+			conn, result := connect(false)
+			g.Assert(conn.WriteMessage(ws.TextMessage, []byte(strings.Repeat("a", limit*4)))).IsNil()
+			g.Assert(errors.Is(<-result, ErrMessageTooLarge)).IsTrue()
+			expectClosed(conn)
+		})
+
+		g.It("rejects a compressed message that exceeds the limit once inflated", func() {
+			conn, result := connect(true)
+			g.Assert(conn.WriteMessage(ws.TextMessage, []byte(strings.Repeat("a", limit*40)))).IsNil()
+			g.Assert(errors.Is(<-result, ErrMessageTooLarge)).IsTrue()
+			expectClosed(conn)
+		})
+	})
+}
+
+func TestCloseHandling(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("closeHandling", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.CloseCodes = map[int]config.WebsocketCloseHandling{
+				ws.CloseServiceRestart: {Log: "info", Event: true},
+			}
+			config.Set(c)
+		})
+
+		g.It("uses the configuration for the close code", func() {
+			g.Assert(closeHandling(ws.CloseServiceRestart)).Equal(config.WebsocketCloseHandling{Log: "info", Event: true})
+		})
+
+		g.It("logs expected close codes at the debug level", func() {
+			g.Assert(closeHandling(ws.CloseNormalClosure)).Equal(config.WebsocketCloseHandling{Log: "debug"})
+		})
+
+		g.It("logs unexpected close codes as a warning", func() {
+			g.Assert(closeHandling(ws.CloseProtocolError)).Equal(config.WebsocketCloseHandling{Log: "warn"})
+		})
+	})
+
+	g.Describe("closeCode", func() {
+		g.It("uses the code the connection was closed with", func() {
+			code, reason, ok := closeCode(&ws.CloseError{Code: ws.CloseGoingAway, Text: "bye"})
+			g.Assert(ok).IsTrue()
+			g.Assert(code).Equal(ws.CloseGoingAway)
+			g.Assert(reason).Equal("bye")
+		})
+
+		g.It("treats closed and timed out connections as an abnormal closure", func() {
+			code, _, ok := closeCode(errors.WithStack(net.ErrClosed))
+			g.Assert(ok).IsTrue()
+			g.Assert(code).Equal(ws.CloseAbnormalClosure)
+
+			code, _, ok = closeCode(&net.OpError{Op: "read", Err: timeoutError{}})
+			g.Assert(ok).IsTrue()
+			g.Assert(code).Equal(ws.CloseAbnormalClosure)
+		})
+
+		g.It("returns false for any other error", func() {
+			_, _, ok := closeCode(errors.New("something else"))
+			g.Assert(ok).IsFalse()
+		})
+	})
+}
+
+// timeoutError is a net.Error that reports it was caused by a timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestParseLogTime(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("parseLogTime", func() {
+		g.It("parses RFC 3339 timestamps", func() {
+			v, err := parseLogTime("2024-01-02T10:05:00+01:00")
+			g.Assert(err).IsNil()
+			g.Assert(v.Equal(time.Date(2024, 1, 2, 9, 5, 0, 0, time.UTC))).IsTrue()
+		})
+
+		g.It("parses unix timestamps", func() {
+			v, err := parseLogTime(" 1704189600 ")
+			g.Assert(err).IsNil()
+			g.Assert(v.Equal(time.Unix(1704189600, 0))).IsTrue()
+		})
+
+		g.It("rejects anything else", func() {
+			_, err := parseLogTime("10:00")
+			g.Assert(errors.Is(err, ErrInvalidLogTime)).IsTrue()
+		})
+	})
+}
+
+func TestCheckCommand(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("CheckCommand", func() {
+		allow := func() bool { return true }
+		token := func(permissions ...string) *tokens.WebsocketPayload {
+			return &tokens.WebsocketPayload{
+				Payload:     jwt.Payload{IssuedAt: jwt.NumericDate(time.Now().Add(time.Minute))},
+				Permissions: permissions,
+			}
+		}
+
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.MaxCommandLength = 8
+			config.Set(c)
+		})
+
+		g.It("requires the send command permission", func() {
+			s := &server.Server{}
+			g.Assert(CheckCommand(s, nil, "say hi", allow)).Equal(ErrCommandPermission)
+			g.Assert(CheckCommand(s, token(PermissionConnect), "say hi", allow)).Equal(ErrCommandPermission)
+			g.Assert(CheckCommand(s, token(PermissionSendCommand), "say hi", allow)).IsNil()
+		})
+
+		g.It("applies the rate limit", func() {
+			err := CheckCommand(&server.Server{}, token(PermissionSendCommand), "say hi", func() bool { return false })
+			g.Assert(err).Equal(ErrCommandRateLimited)
+		})
+
+		g.It("checks the length of the command", func() {
+			err := CheckCommand(&server.Server{}, token(PermissionSendCommand), "say hello world", allow)
+			g.Assert(errors.Is(err, ErrCommandTooLong)).IsTrue()
+		})
+	})
+}
@@ -20,6 +20,7 @@ const (
 	ActivitySftpRename          = models.Event("server:sftp.rename")
 	ActivitySftpDelete          = models.Event("server:sftp.delete")
 	ActivityFileUploaded        = models.Event("server:file.uploaded")
+	ActivityContainerExec       = models.Event("server:container.exec")
 )
 
 // RequestActivity is a wrapper around a LoggedEvent that is able to track additional request
@@ -54,6 +55,9 @@ func (s *Server) NewRequestActivity(user string, ip string) RequestActivity {
 // SaveActivity saves an activity entry to the database in a background routine. If an error is
 // encountered it is logged but not returned to the caller.
 func (s *Server) SaveActivity(a RequestActivity, event models.Event, metadata models.ActivityMeta) {
+	if event == ActivityConsoleCommand {
+		s.auditCommand(a, metadata)
+	}
 	ctx, cancel := context.WithTimeout(s.Context(), time.Second*3)
 	go func() {
 		defer cancel()
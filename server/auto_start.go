@@ -0,0 +1,142 @@
+package server
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// AutoStart controls what happens to a server when Wings boots.
+type AutoStart string
+
+const (
+	// AutoStartPrevious returns the server to the state it was in when Wings
+	// stopped, this is the default for every server.
+	AutoStartPrevious AutoStart = "previous"
+	// AutoStartAlways starts the server when Wings boots, even if it was offline.
+	AutoStartAlways AutoStart = "always"
+	// AutoStartNever leaves the server offline when Wings boots, even if it was
+	// running.
+	AutoStartNever AutoStart = "never"
+)
+
+var ErrInvalidAutoStart = errors.New("auto start must be one of \"previous\", \"always\", or \"never\"")
+
+// Guards the file storing the auto start setting of every server, since each
+// server updates it separately.
+var autoStartMu sync.Mutex
+
+// Valid returns true if the value is a known auto start setting.
+func (a AutoStart) Valid() bool {
+	return a == AutoStartPrevious || a == AutoStartAlways || a == AutoStartNever
+}
+
+// ShouldStart returns true if a server should be started when Wings boots given
+// the state it was last known to be in.
+func (a AutoStart) ShouldStart(wasRunning bool) bool {
+	switch a {
+	case AutoStartAlways:
+		return true
+	case AutoStartNever:
+		return false
+	default:
+		return wasRunning
+	}
+}
+
+// AutoStart returns the setting controlling whether the server is started when
+// Wings boots.
+func (s *Server) AutoStart() (AutoStart, error) {
+	autoStartMu.Lock()
+	defer autoStartMu.Unlock()
+
+	settings, err := readAutoStart()
+	if err != nil {
+		return AutoStartPrevious, err
+	}
+	if a, ok := settings[s.ID()]; ok {
+		return a, nil
+	}
+	return AutoStartPrevious, nil
+}
+
+// SetAutoStart updates the setting controlling whether the server is started
+// when Wings boots, and writes it to the disk so that it is kept when Wings
+// restarts. Connected clients are notified of the new setting.
+func (s *Server) SetAutoStart(a AutoStart) error {
+	if !a.Valid() {
+		return ErrInvalidAutoStart
+	}
+
+	autoStartMu.Lock()
+	settings, err := readAutoStart()
+	if err == nil {
+		// Only servers that do not use the default are stored in the file.
+		if a == AutoStartPrevious {
+			delete(settings, s.ID())
+		} else {
+			settings[s.ID()] = a
+		}
+		err = writeAutoStart(settings)
+	}
+	autoStartMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.Events().Publish(AutoStartEvent, string(a))
+	return nil
+}
+
+// ReadAutoStart returns the auto start setting of every server on the node
+// that does not use the default.
+func (m *Manager) ReadAutoStart() (map[string]AutoStart, error) {
+	autoStartMu.Lock()
+	defer autoStartMu.Unlock()
+
+	settings, err := readAutoStart()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]AutoStart, len(settings))
+	// Only return settings for servers that we're currently tracking in the system.
+	for id, a := range settings {
+		if _, ok := m.Get(id); ok && a.Valid() {
+			out[id] = a
+		}
+	}
+	return out, nil
+}
+
+func readAutoStart() (map[string]AutoStart, error) {
+	f, err := os.Open(config.Get().System.GetAutoStartPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]AutoStart{}, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	settings := map[string]AutoStart{}
+	if err := json.NewDecoder(f).Decode(&settings); err != nil && err != io.EOF {
+		return nil, errors.WithStack(err)
+	}
+	return settings, nil
+}
+
+func writeAutoStart(settings map[string]AutoStart) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(config.Get().System.GetAutoStartPath(), data, 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
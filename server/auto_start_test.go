@@ -0,0 +1,47 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+func TestAutoStart(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("AutoStart#ShouldStart", func() {
+		g.It("returns the server to its previous state by default", func() {
+			g.Assert(AutoStartPrevious.ShouldStart(true)).IsTrue()
+			g.Assert(AutoStartPrevious.ShouldStart(false)).IsFalse()
+		})
+
+		g.It("ignores the previous state when set", func() {
+			g.Assert(AutoStartAlways.ShouldStart(false)).IsTrue()
+			g.Assert(AutoStartNever.ShouldStart(true)).IsFalse()
+		})
+	})
+
+	g.Describe("readAutoStart", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.RootDirectory = t.TempDir()
+			config.Set(c)
+		})
+
+		g.It("returns no settings when the file does not exist", func() {
+			settings, err := readAutoStart()
+			g.Assert(err).IsNil()
+			g.Assert(len(settings)).Equal(0)
+		})
+
+		g.It("reads the settings that were written", func() {
+			g.Assert(writeAutoStart(map[string]AutoStart{"one": AutoStartNever})).IsNil()
+
+			settings, err := readAutoStart()
+			g.Assert(err).IsNil()
+			g.Assert(settings["one"]).Equal(AutoStartNever)
+		})
+	})
+}
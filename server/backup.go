@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"io"
 	"io/fs"
 	"os"
@@ -58,6 +59,12 @@ func (s *Server) getServerwideIgnoredFiles() (string, error) {
 // websocket. We let the actual backup system handle notifying the panel of the
 // status, but that won't emit a websocket event.
 func (s *Server) Backup(b backup.BackupInterface) error {
+	return s.backup(s.Context(), b)
+}
+
+// backup generates the backup using the given context, allowing the backup to
+// be cancelled without cancelling the server context.
+func (s *Server) backup(ctx context.Context, b backup.BackupInterface) error {
 	ignored := b.Ignored()
 	if b.Ignored() == "" {
 		if i, err := s.getServerwideIgnoredFiles(); err != nil {
@@ -67,7 +74,7 @@ func (s *Server) Backup(b backup.BackupInterface) error {
 		}
 	}
 
-	ad, err := b.Generate(s.Context(), s.Filesystem().Path(), ignored)
+	ad, err := b.Generate(ctx, s.Filesystem().Path(), ignored)
 	if err != nil {
 		if err := s.notifyPanelOfBackup(b.Identifier(), &backup.ArchiveDetails{}, false); err != nil {
 			s.Log().WithFields(log.Fields{
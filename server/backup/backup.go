@@ -15,6 +15,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/internal/progress"
 	"github.com/pterodactyl/wings/remote"
 )
 
@@ -79,12 +80,19 @@ type Backup struct {
 	client     remote.Client
 	adapter    AdapterType
 	logContext map[string]interface{}
+	progress   *progress.Progress
 }
 
 func (b *Backup) SetClient(c remote.Client) {
 	b.client = c
 }
 
+// SetProgress sets the tracker that is updated with the number of bytes written
+// to the archive while the backup is being generated.
+func (b *Backup) SetProgress(p *progress.Progress) {
+	b.progress = p
+}
+
 func (b *Backup) Identifier() string {
 	return b.Uuid
 }
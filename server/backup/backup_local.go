@@ -63,6 +63,7 @@ func (b *LocalBackup) Generate(ctx context.Context, basePath, ignore string) (*A
 	a := &filesystem.Archive{
 		BasePath: basePath,
 		Ignore:   ignore,
+		Progress: b.progress,
 	}
 
 	b.log().WithField("path", b.Path()).Info("creating backup for server")
@@ -54,6 +54,7 @@ func (s *S3Backup) Generate(ctx context.Context, basePath, ignore string) (*Arch
 	a := &filesystem.Archive{
 		BasePath: basePath,
 		Ignore:   ignore,
+		Progress: s.progress,
 	}
 
 	s.log().WithField("path", s.Path()).Info("creating backup for server")
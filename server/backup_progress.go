@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/pterodactyl/wings/internal/progress"
+	"github.com/pterodactyl/wings/server/backup"
+)
+
+// The interval at which the progress of a running backup is published.
+const backupProgressInterval = time.Second
+
+// StartBackup generates a new backup for the server using the given adapter in
+// the background, publishing its progress over the server's event bus until it
+// is completed. The backup can be stopped at any point by calling CancelBackup
+// with the same UUID.
+//
+// The UUID must belong to a backup that has already been created on the Panel,
+// callers are expected to take it from a Panel signed token rather than trusting
+// the value provided by a client. Only one backup can be running for a server at
+// a time, ErrBackupInProgress is returned if another backup is still running.
+func (s *Server) StartBackup(uuid string, adapter backup.AdapterType) error {
+	s.backupsLock.Lock()
+	if len(s.backups) > 0 {
+		s.backupsLock.Unlock()
+		return ErrBackupInProgress
+	}
+	ctx, cancel := context.WithCancel(s.Context())
+	s.backups[uuid] = cancel
+	s.backupsLock.Unlock()
+
+	// The size of the data directory is used as the total for the progress. The
+	// files are counted as they are written to the archive before compression, so
+	// this lines up with the bytes processed.
+	size, err := s.Filesystem().DiskUsage(true)
+	if err != nil {
+		s.Log().WithField("error", err).Warn("failed to get disk usage for backup progress")
+	}
+	p := progress.NewProgress(uint64(size))
+
+	var b backup.BackupInterface
+	switch adapter {
+	case backup.S3BackupAdapter:
+		s3 := backup.NewS3(s.client, uuid, "")
+		s3.SetProgress(p)
+		b = s3
+	default:
+		local := backup.NewLocal(s.client, uuid, "")
+		local.SetProgress(p)
+		b = local
+	}
+
+	go func() {
+		defer func() {
+			s.backupsLock.Lock()
+			delete(s.backups, uuid)
+			s.backupsLock.Unlock()
+			cancel()
+		}()
+
+		done := make(chan struct{})
+		go s.publishBackupProgress(uuid, p, done)
+		err := s.backup(ctx, b)
+		close(done)
+		if err != nil {
+			// Don't leave a partially written archive on the disk when the backup
+			// fails or is cancelled.
+			if adapter != backup.S3BackupAdapter {
+				_ = b.Remove()
+			}
+			s.Log().WithFields(log.Fields{"backup": uuid, "error": err}).Error("failed to generate backup for server")
+		}
+	}()
+
+	return nil
+}
+
+// CancelBackup stops a backup that was started with StartBackup. Returns false
+// if there is no backup with the given UUID running.
+func (s *Server) CancelBackup(uuid string) bool {
+	s.backupsLock.Lock()
+	defer s.backupsLock.Unlock()
+
+	cancel, ok := s.backups[uuid]
+	if ok {
+		cancel()
+		delete(s.backups, uuid)
+	}
+	return ok
+}
+
+// publishBackupProgress publishes the progress of a backup at a fixed interval
+// until the done channel is closed.
+func (s *Server) publishBackupProgress(uuid string, p *progress.Progress, done <-chan struct{}) {
+	ticker := time.NewTicker(backupProgressInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.Events().Publish(BackupProgressEvent+":"+uuid, backupProgress(uuid, p))
+		}
+	}
+}
+
+// backupProgress returns the progress payload that is published for a backup.
+func backupProgress(uuid string, p *progress.Progress) map[string]interface{} {
+	written, total := p.Written(), p.Total()
+	percentage := float64(0)
+	if total > 0 {
+		percentage = float64(written) / float64(total) * 100
+		// The disk usage is cached, so files written since it was last calculated
+		// can push the progress past the total.
+		if percentage > 100 {
+			percentage = 100
+		}
+	}
+	return map[string]interface{}{
+		"uuid":            uuid,
+		"bytes_processed": written,
+		"total_bytes":     total,
+		"percentage":      percentage,
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// The codes sent to clients in a CloseReason.
+const (
+	CloseReasonShutdown           = "shutdown"
+	CloseReasonDrain              = "drain"
+	CloseReasonSessionExpired     = "session_expired"
+	CloseReasonTooManyConnections = "too_many_connections"
+	CloseReasonServerDeleted      = "server_deleted"
+	CloseReasonPermissionsRevoked = "permissions_revoked"
+)
+
+// The longest reason that fits in a close frame, since control frames are
+// limited to 125 bytes and two of them are used by the close code.
+const maxCloseReasonLength = 123
+
+// CloseReason tells a websocket client why Wings closed its connection, and if
+// and when it should reconnect.
+type CloseReason struct {
+	Code       string `json:"code"`
+	Reconnect  bool   `json:"reconnect"`
+	RetryAfter int    `json:"retry_after"`
+	Message    string `json:"message,omitempty"`
+}
+
+// NewCloseReason returns the reason sent to a client when its connection is
+// closed for the given code, with the reconnect advice for that code.
+func NewCloseReason(code string, message string) CloseReason {
+	cfg := config.Get().System.Websocket.ReconnectHints
+	r := CloseReason{Code: code, Reconnect: true, Message: message}
+	switch code {
+	case CloseReasonShutdown:
+		r.RetryAfter = cfg.RestartDelay
+	case CloseReasonDrain:
+		r.RetryAfter = cfg.MaintenanceDelay
+	case CloseReasonTooManyConnections:
+		r.RetryAfter = cfg.BusyDelay
+	case CloseReasonServerDeleted, CloseReasonPermissionsRevoked:
+		r.Reconnect = false
+	}
+	return r
+}
+
+// String returns the reason as it is sent in the close frame. This is the JSON
+// encoded reason when reconnect hints are enabled, and just the message when
+// they are not. The message is left out of the JSON if it would not fit.
+func (r CloseReason) String() string {
+	if !config.Get().System.Websocket.ReconnectHints.Enabled {
+		return r.Message
+	}
+	b, err := json.Marshal(r)
+	if err == nil && len(b) > maxCloseReasonLength {
+		r.Message = ""
+		b, err = json.Marshal(r)
+	}
+	if err != nil {
+		return r.Message
+	}
+	return string(b)
+}
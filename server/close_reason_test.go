@@ -0,0 +1,54 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+func TestCloseReason(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("CloseReason#String", func() {
+		setHints := func(enabled bool) {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.ReconnectHints = config.WebsocketReconnectHints{Enabled: enabled, RestartDelay: 15, MaintenanceDelay: 60, BusyDelay: 30}
+			config.Set(c)
+		}
+
+		g.It("only sends the message when hints are disabled", func() {
+			setHints(false)
+			g.Assert(NewCloseReason(CloseReasonShutdown, "wings is shutting down").String()).Equal("wings is shutting down")
+		})
+
+		g.It("sends the reconnect advice as json", func() {
+			setHints(true)
+			var r CloseReason
+			g.Assert(json.Unmarshal([]byte(NewCloseReason(CloseReasonDrain, "maintenance").String()), &r)).IsNil()
+			g.Assert(r.Code).Equal(CloseReasonDrain)
+			g.Assert(r.Reconnect).IsTrue()
+			g.Assert(r.RetryAfter).Equal(60)
+			g.Assert(r.Message).Equal("maintenance")
+		})
+
+		g.It("tells clients not to reconnect to a deleted server", func() {
+			setHints(true)
+			g.Assert(NewCloseReason(CloseReasonServerDeleted, "").Reconnect).IsFalse()
+		})
+
+		g.It("leaves out the message if it does not fit in a close frame", func() {
+			setHints(true)
+			s := NewCloseReason(CloseReasonShutdown, strings.Repeat("a", 200)).String()
+			g.Assert(len(s) <= maxCloseReasonLength).IsTrue()
+
+			var r CloseReason
+			g.Assert(json.Unmarshal([]byte(s), &r)).IsNil()
+			g.Assert(r.Message).Equal("")
+			g.Assert(r.Code).Equal(CloseReasonShutdown)
+		})
+	})
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/apex/log"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/internal/models"
+)
+
+// The format used for the name of each day's audit file, times are always UTC.
+const commandAuditFileFormat = "2006-01-02"
+
+// The number of entries that can be waiting to be written to the disk before
+// any new entries are dropped from the file.
+const commandAuditQueueSize = 256
+
+// CommandAuditEntry is a single console command that was sent to a server.
+type CommandAuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	User      string    `json:"user,omitempty"`
+	IP        string    `json:"ip"`
+	Command   string    `json:"command"`
+	// The commands that were actually sent if the command was a macro.
+	Commands []string `json:"commands,omitempty"`
+}
+
+// CommandAuditLog keeps the most recent commands sent to a server in memory,
+// and can optionally append every command to a file on the disk.
+type CommandAuditLog struct {
+	mu      sync.Mutex
+	entries []CommandAuditEntry
+	max     int
+
+	// Only set when the log is persisted to the disk.
+	dir       string
+	retention time.Duration
+	queue     chan CommandAuditEntry
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewCommandAuditLog returns an audit log that only keeps up to max entries in
+// memory.
+func NewCommandAuditLog(max int) *CommandAuditLog {
+	if max < 0 {
+		max = 0
+	}
+	return &CommandAuditLog{max: max}
+}
+
+// OpenCommandAuditLog returns an audit log that also appends every entry to a
+// file in the given directory, starting a new file each day. The most recent
+// entries are loaded from the existing files, and any files older than the
+// retention period (in days) are removed. A retention of 0 keeps every file.
+//
+// Entries are written to the disk in the background so that recording them
+// never blocks. Call Close, or cancel the context, to stop writing entries.
+func OpenCommandAuditLog(ctx context.Context, dir string, max int, retention int) (*CommandAuditLog, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "server/audit: failed to create directory")
+	}
+
+	a := NewCommandAuditLog(max)
+	a.dir = dir
+	a.retention = time.Duration(retention) * time.Hour * 24
+	a.queue = make(chan CommandAuditEntry, commandAuditQueueSize)
+	a.done = make(chan struct{})
+
+	a.prune(time.Now())
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+
+	ctx, a.cancel = context.WithCancel(ctx)
+	go a.write(ctx)
+
+	return a, nil
+}
+
+// Record adds an entry to the audit log. If the log is persisted the entry is
+// queued to be written to the disk, if the queue is full because the disk is not
+// keeping up the entry is only kept in memory.
+func (a *CommandAuditLog) Record(entry CommandAuditEntry) {
+	a.push(entry)
+	if a.queue == nil {
+		return
+	}
+	select {
+	case a.queue <- entry:
+	default:
+		log.WithField("directory", a.dir).Warn("server/audit: write queue is full, entry was not written to disk")
+	}
+}
+
+// Entries returns a copy of the entries in the audit log, oldest first.
+func (a *CommandAuditLog) Entries() []CommandAuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]CommandAuditEntry{}, a.entries...)
+}
+
+// Close stops the audit log from writing to the disk once any queued entries
+// have been written. This is a no-op for logs that are not persisted.
+func (a *CommandAuditLog) Close() {
+	if a.cancel == nil {
+		return
+	}
+	a.cancel()
+	<-a.done
+}
+
+// push adds an entry to the in memory log, removing the oldest entry if the log
+// is full.
+func (a *CommandAuditLog) push(entry CommandAuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.max == 0 {
+		return
+	}
+	if len(a.entries) >= a.max {
+		n := copy(a.entries, a.entries[len(a.entries)-a.max+1:])
+		a.entries = a.entries[:n]
+	}
+	a.entries = append(a.entries, entry)
+}
+
+// write appends queued entries to the file for the current day until the
+// context is cancelled.
+func (a *CommandAuditLog) write(ctx context.Context) {
+	defer close(a.done)
+
+	var f *os.File
+	var w *bufio.Writer
+	var day string
+	defer func() {
+		if f != nil {
+			_ = w.Flush()
+			_ = f.Close()
+		}
+	}()
+
+	writeEntry := func(entry CommandAuditEntry) {
+		now := time.Now().UTC()
+		if d := now.Format(commandAuditFileFormat); d != day || f == nil {
+			if f != nil {
+				_ = w.Flush()
+				_ = f.Close()
+				f = nil
+				a.prune(now)
+			}
+			var err error
+			f, err = os.OpenFile(filepath.Join(a.dir, d+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+			if err != nil {
+				log.WithFields(log.Fields{"directory": a.dir, "error": err}).Error("server/audit: failed to open file")
+				return
+			}
+			w = bufio.NewWriter(f)
+			day = d
+		}
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		_, _ = w.Write(append(b, '\n'))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Write anything still waiting in the queue before stopping.
+			for {
+				select {
+				case entry := <-a.queue:
+					writeEntry(entry)
+				default:
+					return
+				}
+			}
+		case entry := <-a.queue:
+			writeEntry(entry)
+			// Only flush to the disk once the queue has been emptied so that a burst
+			// of commands is written in one go.
+			if len(a.queue) == 0 && f != nil {
+				if err := w.Flush(); err != nil {
+					log.WithFields(log.Fields{"directory": a.dir, "error": err}).Error("server/audit: failed to write to file")
+				}
+			}
+		}
+	}
+}
+
+// files returns the names of the audit files in the directory, oldest first.
+func (a *CommandAuditLog) files() ([]string, error) {
+	dirents, err := os.ReadDir(a.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "server/audit: failed to read directory")
+	}
+	var names []string
+	for _, d := range dirents {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".log") {
+			continue
+		}
+		if _, err := time.Parse(commandAuditFileFormat, strings.TrimSuffix(d.Name(), ".log")); err != nil {
+			continue
+		}
+		names = append(names, d.Name())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// load reads the most recent entries from the files in the directory into
+// memory, starting with the newest file.
+func (a *CommandAuditLog) load() error {
+	names, err := a.files()
+	if err != nil {
+		return err
+	}
+	var entries []CommandAuditEntry
+	for i := len(names) - 1; i >= 0 && len(entries) < a.max; i-- {
+		f, err := os.Open(filepath.Join(a.dir, names[i]))
+		if err != nil {
+			return errors.Wrap(err, "server/audit: failed to open file")
+		}
+		var day []CommandAuditEntry
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry CommandAuditEntry
+			// A line can be partially written if Wings stopped while writing it, so
+			// anything that cannot be parsed is skipped.
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+				day = append(day, entry)
+			}
+		}
+		f.Close()
+		entries = append(day, entries...)
+	}
+	if len(entries) > a.max {
+		entries = entries[len(entries)-a.max:]
+	}
+	a.mu.Lock()
+	a.entries = entries
+	a.mu.Unlock()
+	return nil
+}
+
+// prune removes any files older than the retention period.
+func (a *CommandAuditLog) prune(now time.Time) {
+	if a.retention <= 0 {
+		return
+	}
+	names, err := a.files()
+	if err != nil {
+		return
+	}
+	cutoff := now.UTC().Add(-a.retention).Format(commandAuditFileFormat)
+	for _, name := range names {
+		if strings.TrimSuffix(name, ".log") >= cutoff {
+			break
+		}
+		if err := os.Remove(filepath.Join(a.dir, name)); err != nil {
+			log.WithFields(log.Fields{"file": name, "error": err}).Warn("server/audit: failed to remove expired file")
+		}
+	}
+}
+
+// CommandAudit returns the log of console commands sent to the server. When the
+// log is persisted its recent entries are loaded from the disk the first time
+// this is called.
+func (s *Server) CommandAudit() *CommandAuditLog {
+	s.auditOnce.Do(func() {
+		cfg := config.Get().System.CommandAudit
+		if !cfg.Persist {
+			s.audit = NewCommandAuditLog(cfg.Entries)
+			return
+		}
+		dir := cfg.Directory
+		if dir == "" {
+			dir = filepath.Join(config.Get().System.LogDirectory, "audit")
+		}
+		a, err := OpenCommandAuditLog(s.Context(), filepath.Join(dir, s.ID()), cfg.Entries, cfg.Retention)
+		if err != nil {
+			s.Log().WithField("error", err).Error("failed to open command audit log, commands will only be kept in memory")
+			a = NewCommandAuditLog(cfg.Entries)
+		}
+		s.audit = a
+	})
+	return s.audit
+}
+
+// auditCommand records a console command sent to the server in its audit log.
+func (s *Server) auditCommand(a RequestActivity, metadata models.ActivityMeta) {
+	entry := CommandAuditEntry{Timestamp: time.Now().UTC(), User: a.user, IP: a.ip}
+	entry.Command, _ = metadata["command"].(string)
+	entry.Commands, _ = metadata["commands"].([]string)
+	s.CommandAudit().Record(entry)
+}
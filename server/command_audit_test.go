@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestCommandAuditLog(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("CommandAuditLog", func() {
+		g.It("keeps only the most recent entries in memory", func() {
+			a := NewCommandAuditLog(2)
+			a.Record(CommandAuditEntry{Command: "one"})
+			a.Record(CommandAuditEntry{Command: "two"})
+			a.Record(CommandAuditEntry{Command: "three"})
+
+			entries := a.Entries()
+			g.Assert(len(entries)).Equal(2)
+			g.Assert(entries[0].Command).Equal("two")
+			g.Assert(entries[1].Command).Equal("three")
+		})
+
+		g.It("loads persisted entries when opened again", func() {
+			dir := t.TempDir()
+			a, err := OpenCommandAuditLog(context.Background(), dir, 10, 0)
+			g.Assert(err).IsNil()
+			a.Record(CommandAuditEntry{Command: "say hello", User: "user"})
+			a.Record(CommandAuditEntry{Command: "backup", Commands: []string{"save-off", "save-on"}})
+			a.Close()
+
+			a, err = OpenCommandAuditLog(context.Background(), dir, 10, 0)
+			g.Assert(err).IsNil()
+			defer a.Close()
+
+			entries := a.Entries()
+			g.Assert(len(entries)).Equal(2)
+			g.Assert(entries[0].Command).Equal("say hello")
+			g.Assert(entries[0].User).Equal("user")
+			g.Assert(entries[1].Commands).Equal([]string{"save-off", "save-on"})
+		})
+
+		g.It("removes files older than the retention period", func() {
+			dir := t.TempDir()
+			old := filepath.Join(dir, time.Now().UTC().AddDate(0, 0, -5).Format(commandAuditFileFormat)+".log")
+			recent := filepath.Join(dir, time.Now().UTC().AddDate(0, 0, -1).Format(commandAuditFileFormat)+".log")
+			g.Assert(os.WriteFile(old, []byte(`{"command":"old"}`+"\n"), 0o600)).IsNil()
+			g.Assert(os.WriteFile(recent, []byte(`{"command":"recent"}`+"\n"), 0o600)).IsNil()
+
+			a, err := OpenCommandAuditLog(context.Background(), dir, 10, 3)
+			g.Assert(err).IsNil()
+			defer a.Close()
+
+			_, err = os.Stat(old)
+			g.Assert(os.IsNotExist(err)).IsTrue()
+			_, err = os.Stat(recent)
+			g.Assert(err).IsNil()
+
+			entries := a.Entries()
+			g.Assert(len(entries)).Equal(1)
+			g.Assert(entries[0].Command).Equal("recent")
+		})
+	})
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/system"
+)
+
+// The maximum number of lines collected by a single capture, any output after
+// this is discarded and the capture is marked as truncated.
+const maxCaptureLines = 500
+
+// The longest a single capture can collect output for.
+const maxCaptureWindow = time.Second * 30
+
+// Capture IDs starting with this are used by Wings itself, and cannot be used by
+// clients so that they cannot take an ID Wings needs.
+const ReservedCaptureIDPrefix = "wings:"
+
+var (
+	ErrTooManyCaptures = errors.New("too many commands are already being captured for this server")
+	ErrCaptureExists   = errors.New("a capture with that id is already running")
+)
+
+// CommandCapture is the console output collected after a command was sent to
+// a server.
+type CommandCapture struct {
+	ID        string   `json:"id"`
+	Command   string   `json:"command"`
+	Lines     []string `json:"lines"`
+	Truncated bool     `json:"truncated"`
+}
+
+// CaptureCommand sends a command to the server and collects all the console
+// output from the server until the window has passed or the context is
+// cancelled, returning the output that was collected.
+//
+// The console does not say which command a line of output belongs to, so every
+// capture receives all the output from the server while it is running. If two
+// captures overlap, such as when multiple people run commands at the same time,
+// each of them will include the output from both commands, as will any output
+// the server writes on its own during the window. Captures are otherwise kept
+// entirely separate, and finishing one has no effect on any other.
+func (s *Server) CaptureCommand(ctx context.Context, id string, command string, window time.Duration) (*CommandCapture, error) {
+	if window <= 0 || window > maxCaptureWindow {
+		window = maxCaptureWindow
+	}
+
+	if err := s.startCapture(id); err != nil {
+		return nil, err
+	}
+	defer s.stopCapture(id)
+
+	stdout, stderr, unsubscribe, err := s.subscribeConsole()
+	if err != nil {
+		return nil, err
+	}
+	defer unsubscribe()
+
+	if err := s.Environment.SendCommand(command); err != nil {
+		return nil, err
+	}
+
+	capture := &CommandCapture{ID: id, Command: command, Lines: []string{}}
+	ctx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+	for {
+		var line []byte
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return capture, nil
+		case line, ok = <-stdout:
+		case line, ok = <-stderr:
+		}
+		// The channels are closed if the server is deleted while capturing.
+		if !ok {
+			return capture, nil
+		}
+		if len(capture.Lines) >= maxCaptureLines {
+			capture.Truncated = true
+			continue
+		}
+		capture.Lines = append(capture.Lines, string(line))
+	}
+}
+
+// subscribeConsole returns channels receiving the output the server writes to
+// stdout and stderr, along with a function that must be called once the output
+// is no longer needed.
+func (s *Server) subscribeConsole() (chan []byte, chan []byte, func(), error) {
+	// Each sink pool closes the channel when it is removed, so a separate channel
+	// is needed for each of them.
+	stdout, stderr := make(chan []byte, 64), make(chan []byte, 64)
+	if err := s.Sink(system.LogSink).On(stdout); err != nil {
+		return nil, nil, nil, errors.WithStack(err)
+	}
+	if err := s.Sink(system.ErrorSink).On(stderr); err != nil {
+		s.Sink(system.LogSink).Off(stdout)
+		return nil, nil, nil, errors.WithStack(err)
+	}
+	return stdout, stderr, func() {
+		s.Sink(system.LogSink).Off(stdout)
+		s.Sink(system.ErrorSink).Off(stderr)
+	}, nil
+}
+
+// startCapture reserves a capture slot for the server, returning an error if
+// too many captures are running or one with the same ID already is.
+func (s *Server) startCapture(id string) error {
+	s.capturesMu.Lock()
+	defer s.capturesMu.Unlock()
+
+	if _, ok := s.captures[id]; ok {
+		return ErrCaptureExists
+	}
+	if max := config.Get().System.Websocket.MaxCommandCaptures; len(s.captures) >= max {
+		return ErrTooManyCaptures
+	}
+	if s.captures == nil {
+		s.captures = make(map[string]struct{})
+	}
+	s.captures[id] = struct{}{}
+	return nil
+}
+
+func (s *Server) stopCapture(id string) {
+	s.capturesMu.Lock()
+	defer s.capturesMu.Unlock()
+	delete(s.captures, id)
+}
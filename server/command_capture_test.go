@@ -0,0 +1,37 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+func TestServer_StartCapture(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#startCapture", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.MaxCommandCaptures = 2
+			config.Set(c)
+		})
+
+		g.It("allows separate captures up to the limit", func() {
+			s := &Server{}
+			g.Assert(s.startCapture("one")).IsNil()
+			g.Assert(s.startCapture("two")).IsNil()
+			g.Assert(s.startCapture("three")).Equal(ErrTooManyCaptures)
+
+			s.stopCapture("one")
+			g.Assert(s.startCapture("three")).IsNil()
+		})
+
+		g.It("rejects a capture with an id that is already running", func() {
+			s := &Server{}
+			g.Assert(s.startCapture("one")).IsNil()
+			g.Assert(s.startCapture("one")).Equal(ErrCaptureExists)
+		})
+	})
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pterodactyl/wings/system"
+)
+
+// CommandLimits holds the command rate limiters shared by everything sending
+// commands to a server on behalf of a user, which is their websocket connections
+// and the API.
+type CommandLimits struct {
+	mu    sync.Mutex
+	users map[string]*userCommandLimit
+}
+
+type userCommandLimit struct {
+	rate *system.Rate
+	refs int
+	// When the limiter was last used by something that does not hold a reference
+	// to it, and the period it was used with.
+	used   time.Time
+	period time.Duration
+}
+
+// get returns the rate limiter for the user, creating it if needed, and updates
+// its limit to the one given. This must be called with the lock held.
+func (l *CommandLimits) get(user string, limit uint64, period time.Duration) *userCommandLimit {
+	if l.users == nil {
+		l.users = make(map[string]*userCommandLimit)
+	}
+	u, ok := l.users[user]
+	if !ok {
+		u = &userCommandLimit{rate: system.NewRate(limit, period)}
+		l.users[user] = u
+	}
+	u.rate.SetLimit(limit, period)
+	return u
+}
+
+// Acquire returns the rate limiter for the user, creating it with the given
+// limit if the user does not already have one. The limit of an existing limiter
+// is updated if it has changed. Every call must be matched by a call to Release
+// once the connection is closed.
+func (l *CommandLimits) Acquire(user string, limit uint64, period time.Duration) *system.Rate {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u := l.get(user, limit, period)
+	u.refs++
+	return u.rate
+}
+
+// Allow returns true if the user can send another command under the given
+// limit, using the same limiter as the user's connections. This is used when
+// a command is sent without a connection holding the limiter, in which case it
+// is kept until the period has passed so that the next command still counts.
+func (l *CommandLimits) Allow(user string, limit uint64, period time.Duration) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune()
+	u := l.get(user, limit, period)
+	u.used, u.period = time.Now(), period
+	return u.rate.Try()
+}
+
+// Release removes a connection from the user's rate limiter, the limiter is
+// removed once the user's last connection has released it unless it was used
+// to send a command without a connection recently.
+func (l *CommandLimits) Release(user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if u, ok := l.users[user]; ok {
+		u.refs--
+		if u.refs <= 0 && time.Since(u.used) > u.period {
+			delete(l.users, user)
+		}
+	}
+}
+
+// prune removes the limiters that no connection holds and that have not been
+// used within their period. This must be called with the lock held.
+func (l *CommandLimits) prune() {
+	for user, u := range l.users {
+		if u.refs <= 0 && time.Since(u.used) > u.period {
+			delete(l.users, user)
+		}
+	}
+}
+
+// Len returns the number of users with a rate limiter.
+func (l *CommandLimits) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.users)
+}
+
+// CommandLimits returns the command rate limiters shared between the websocket
+// connections of each user connected to the server and the commands they send
+// through the API.
+func (s *Server) CommandLimits() *CommandLimits {
+	return &s.commandLimits
+}
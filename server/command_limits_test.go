@@ -0,0 +1,81 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestCommandLimits(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("CommandLimits", func() {
+		g.It("shares a limiter between a user's connections", func() {
+			var l CommandLimits
+			a := l.Acquire("user", 2, time.Minute)
+			b := l.Acquire("user", 2, time.Minute)
+			g.Assert(a == b).IsTrue()
+
+			g.Assert(a.Try()).IsTrue()
+			g.Assert(b.Try()).IsTrue()
+			g.Assert(a.Try()).IsFalse()
+			g.Assert(b.Try()).IsFalse()
+		})
+
+		g.It("gives each user their own limiter", func() {
+			var l CommandLimits
+			a := l.Acquire("one", 1, time.Minute)
+			b := l.Acquire("two", 1, time.Minute)
+			g.Assert(a.Try()).IsTrue()
+			g.Assert(b.Try()).IsTrue()
+		})
+
+		g.It("removes the limiter once the last connection releases it", func() {
+			var l CommandLimits
+			a := l.Acquire("user", 1, time.Minute)
+			l.Acquire("user", 1, time.Minute)
+			g.Assert(a.Try()).IsTrue()
+
+			l.Release("user")
+			g.Assert(l.Len()).Equal(1)
+			l.Release("user")
+			g.Assert(l.Len()).Equal(0)
+
+			g.Assert(l.Acquire("user", 1, time.Minute).Try()).IsTrue()
+		})
+
+		g.It("updates the limit of an existing limiter", func() {
+			var l CommandLimits
+			a := l.Acquire("user", 1, time.Minute)
+			g.Assert(a.Try()).IsTrue()
+			g.Assert(a.Try()).IsFalse()
+
+			b := l.Acquire("user", 2, time.Minute)
+			g.Assert(a == b).IsTrue()
+			g.Assert(b.Try()).IsTrue()
+			g.Assert(b.Try()).IsTrue()
+			g.Assert(b.Try()).IsFalse()
+		})
+
+		g.It("shares the limiter with commands sent without a connection", func() {
+			var l CommandLimits
+			a := l.Acquire("user", 2, time.Minute)
+			g.Assert(l.Allow("user", 2, time.Minute)).IsTrue()
+			g.Assert(a.Try()).IsTrue()
+			g.Assert(l.Allow("user", 2, time.Minute)).IsFalse()
+		})
+
+		g.It("keeps a limiter used without a connection until its period passes", func() {
+			var l CommandLimits
+			g.Assert(l.Allow("user", 1, time.Minute)).IsTrue()
+			g.Assert(l.Len()).Equal(1)
+			g.Assert(l.Allow("user", 1, time.Minute)).IsFalse()
+
+			g.Assert(l.Allow("other", 1, time.Nanosecond)).IsTrue()
+			time.Sleep(time.Millisecond)
+			g.Assert(l.Allow("user", 1, time.Minute)).IsFalse()
+			g.Assert(l.Len()).Equal(1)
+		})
+	})
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"github.com/apex/log"
+)
+
+// The most commands that can be waiting for a server to start.
+const maxQueuedCommands = 20
+
+// QueueCommands stores commands to be sent to the server once it is running,
+// used when a command is sent while the server is offline. The commands are
+// sent in the order they were queued, and are dropped if the server goes
+// offline again before it finishes starting.
+func (s *Server) QueueCommands(commands ...string) error {
+	s.queuedCommandsMu.Lock()
+	defer s.queuedCommandsMu.Unlock()
+
+	if len(s.queuedCommands)+len(commands) > maxQueuedCommands {
+		return ErrCommandQueueFull
+	}
+	s.queuedCommands = append(s.queuedCommands, commands...)
+	return nil
+}
+
+// QueuedCommands returns the commands waiting to be sent to the server.
+func (s *Server) QueuedCommands() []string {
+	s.queuedCommandsMu.Lock()
+	defer s.queuedCommandsMu.Unlock()
+	return append([]string{}, s.queuedCommands...)
+}
+
+// sendQueuedCommands sends any queued commands to the server.
+func (s *Server) sendQueuedCommands() {
+	s.queuedCommandsMu.Lock()
+	commands := s.queuedCommands
+	s.queuedCommands = nil
+	s.queuedCommandsMu.Unlock()
+
+	if len(commands) == 0 {
+		return
+	}
+	s.Log().WithField("commands", len(commands)).Debug("sending queued commands to server")
+	for _, command := range commands {
+		if err := s.Environment.SendCommand(command); err != nil {
+			s.Log().WithFields(log.Fields{"command": command, "error": err}).Warn("failed to send queued command to server instance")
+		}
+	}
+}
+
+// clearQueuedCommands drops any commands waiting to be sent to the server.
+func (s *Server) clearQueuedCommands() {
+	s.queuedCommandsMu.Lock()
+	defer s.queuedCommandsMu.Unlock()
+	if len(s.queuedCommands) > 0 {
+		s.Log().WithField("commands", len(s.queuedCommands)).Debug("dropping queued commands as server did not start")
+	}
+	s.queuedCommands = nil
+}
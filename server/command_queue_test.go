@@ -0,0 +1,37 @@
+package server
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+)
+
+func TestCommandQueue(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#QueueCommands", func() {
+		g.It("keeps commands in the order they were queued", func() {
+			s := &Server{}
+			g.Assert(s.QueueCommands("say one")).IsNil()
+			g.Assert(s.QueueCommands("say two", "say three")).IsNil()
+			g.Assert(s.QueuedCommands()).Equal([]string{"say one", "say two", "say three"})
+		})
+
+		g.It("rejects commands once the queue is full", func() {
+			s := &Server{}
+			for i := 0; i < maxQueuedCommands; i++ {
+				g.Assert(s.QueueCommands("say hello")).IsNil()
+			}
+			g.Assert(errors.Is(s.QueueCommands("say hello"), ErrCommandQueueFull)).IsTrue()
+			g.Assert(len(s.QueuedCommands())).Equal(maxQueuedCommands)
+		})
+
+		g.It("drops queued commands when cleared", func() {
+			s := &Server{}
+			g.Assert(s.QueueCommands("say hello")).IsNil()
+			s.clearQueuedCommands()
+			g.Assert(len(s.QueuedCommands())).Equal(0)
+		})
+	})
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+// CommandResponse is the first line of console output matching the response
+// pattern after a command was sent to a server.
+type CommandResponse struct {
+	ID      string `json:"id"`
+	Command string `json:"command"`
+	Matched bool   `json:"matched"`
+	Line    string `json:"line,omitempty"`
+	// The text matched by each capture group in the pattern, in order.
+	Groups []string `json:"groups,omitempty"`
+}
+
+// AwaitCommandResponse sends a command to the server and waits for the first
+// line of console output matching the pattern, returning it along with any text
+// matched by the groups in the pattern. If no line matches before the timeout
+// passes or the context is cancelled the response is returned without a match.
+//
+// This is best-effort in the same way as CaptureCommand. The console does not
+// say which command a line belongs to, so a matching line written by the server
+// for any other reason, or in response to another command sent at the same time,
+// is returned just the same. Waiting for a response uses one of the server's
+// capture slots, and the ID cannot be the same as a running capture.
+func (s *Server) AwaitCommandResponse(ctx context.Context, id string, command string, pattern *regexp.Regexp, timeout time.Duration) (*CommandResponse, error) {
+	if timeout <= 0 || timeout > maxCaptureWindow {
+		timeout = maxCaptureWindow
+	}
+
+	if err := s.startCapture(id); err != nil {
+		return nil, err
+	}
+	defer s.stopCapture(id)
+
+	stdout, stderr, unsubscribe, err := s.subscribeConsole()
+	if err != nil {
+		return nil, err
+	}
+	defer unsubscribe()
+
+	if err := s.Environment.SendCommand(command); err != nil {
+		return nil, err
+	}
+
+	res := &CommandResponse{ID: id, Command: command}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		var line []byte
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return res, nil
+		case line, ok = <-stdout:
+		case line, ok = <-stderr:
+		}
+		// The channels are closed if the server is deleted while waiting.
+		if !ok {
+			return res, nil
+		}
+		if match := pattern.FindSubmatch(line); match != nil {
+			res.Matched = true
+			res.Line = string(line)
+			for _, g := range match[1:] {
+				res.Groups = append(res.Groups, string(g))
+			}
+			return res, nil
+		}
+	}
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"sync"
 
+	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/environment"
 )
 
@@ -38,6 +39,32 @@ type Configuration struct {
 	// The command that should be used when booting up the server instance.
 	Invocation string `json:"invocation"`
 
+	// Prevents console commands from being sent to the server by anyone, regardless of
+	// their permissions. This is used for servers that either have no stdin, or where
+	// sending commands could be dangerous.
+	CommandsDisabled bool `json:"commands_disabled"`
+
+	// Power actions that cannot be sent to the server by anyone, regardless of
+	// their permissions. Wings itself can still perform these actions, such as when
+	// stopping servers that exceed their disk space.
+	DisabledPowerActions []PowerAction `json:"disabled_power_actions"`
+
+	// Named sequences of console commands. Sending the name of a macro as a console
+	// command sends each of its commands to the server in order instead.
+	Macros map[string][]string `json:"macros"`
+
+	// Watches for the server going quiet while it is running.
+	OutputWatchdog OutputWatchdogConfiguration `json:"output_watchdog"`
+
+	// Overrides the node's startup timeout for this server.
+	StartupTimeout StartupTimeoutConfiguration `json:"startup_timeout"`
+
+	// Stops the server once it has been idle for a while.
+	IdleStop IdleStopConfiguration `json:"idle_stop"`
+
+	// Finds the number of players connected to the server.
+	PlayerCount PlayerCountConfiguration `json:"player_count"`
+
 	// By default this is false, however if selected within the Panel while installing or re-installing a
 	// server, specific installation scripts will be skipped for the server process.
 	SkipEggScripts bool `json:"skip_egg_scripts"`
@@ -80,6 +107,47 @@ func (s *Server) MemoryLimit() int64 {
 	return s.cfg.Build.MemoryLimit
 }
 
+// CommandsDisabled returns true if console commands cannot be sent to the server.
+func (s *Server) CommandsDisabled() bool {
+	s.cfg.mu.RLock()
+	defer s.cfg.mu.RUnlock()
+	return s.cfg.CommandsDisabled
+}
+
+// PowerActionDisabled returns true if the power action cannot be sent to the
+// server by users.
+func (s *Server) PowerActionDisabled(action PowerAction) bool {
+	s.cfg.mu.RLock()
+	defer s.cfg.mu.RUnlock()
+	for _, a := range s.cfg.DisabledPowerActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceLimits defines the resource limits assigned to a server, along with
+// the node level limits that are applied to every server container.
+type ResourceLimits struct {
+	environment.Limits
+
+	// The maximum number of processes that can be running in the server container.
+	ProcessLimit int64 `json:"process_limit"`
+}
+
+// ResourceLimits returns the configured resource limits for the server. These
+// are kept up to date whenever the server is synced with the Panel, so this does
+// not require inspecting the server container.
+func (s *Server) ResourceLimits() ResourceLimits {
+	s.cfg.mu.RLock()
+	defer s.cfg.mu.RUnlock()
+	return ResourceLimits{
+		Limits:       s.cfg.Build,
+		ProcessLimit: config.Get().Docker.ContainerPidLimit,
+	}
+}
+
 func (c *Configuration) GetUuid() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -0,0 +1,370 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// ConsoleBacklog holds the most recent lines of console output for a server,
+// limited by both the number of lines and their total size in bytes.
+type ConsoleBacklog struct {
+	mu       sync.Mutex
+	lines    [][]byte
+	size     int
+	maxLines int
+	maxBytes int
+	// Set once the backlog has been reset when the server started, at which point
+	// it holds all the recent output. Until then Wings may have been started part
+	// way through the server running, and the older output is missing.
+	complete bool
+	// When a line was last added, used to choose which backlogs to shrink first
+	// when the node wide budget is exceeded.
+	lastActive time.Time
+	// The budget this backlog counts towards, if any.
+	budget *BacklogBudget
+	// The limits used instead while the server is starting, when servers tend to
+	// write a lot of output at once. A limit of 0 uses the normal limit.
+	startupLines int
+	startupBytes int
+	starting     bool
+}
+
+// NewConsoleBacklog returns a backlog that holds at most maxLines lines and
+// maxBytes bytes. A limit of 0 or less disables the backlog entirely.
+func NewConsoleBacklog(maxLines int, maxBytes int) *ConsoleBacklog {
+	return &ConsoleBacklog{maxLines: maxLines, maxBytes: maxBytes}
+}
+
+// Push adds a line to the backlog, removing the oldest lines until the backlog
+// is back within its limits. Lines longer than the byte limit are truncated.
+func (b *ConsoleBacklog) Push(line []byte) {
+	if b.maxLines <= 0 || b.maxBytes <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	maxLines, maxBytes := b.limits()
+	if len(line) > maxBytes {
+		line = line[:maxBytes]
+	}
+	// The line passed in is reused by the caller, so a copy has to be kept.
+	line = append([]byte{}, line...)
+
+	before := b.size
+	b.lines = append(b.lines, line)
+	b.size += len(line)
+	b.lastActive = time.Now()
+	b.trim(maxLines, maxBytes)
+	budget := b.budget
+	if budget != nil {
+		atomic.AddInt64(&budget.size, int64(b.size-before))
+	}
+	b.mu.Unlock()
+
+	if budget != nil {
+		budget.enforce()
+	}
+}
+
+// SetStartupLimits sets the limits used while the server is starting, a limit
+// of 0 uses the normal limit.
+func (b *ConsoleBacklog) SetStartupLimits(maxLines int, maxBytes int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.startupLines = maxLines
+	b.startupBytes = maxBytes
+}
+
+// SetStarting switches the backlog between the startup and normal limits. Once
+// the server is no longer starting the backlog is shrunk back down to the normal
+// limits, keeping the most recent lines.
+func (b *ConsoleBacklog) SetStarting(starting bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.starting = starting
+	if starting {
+		return
+	}
+	before := b.size
+	b.trim(b.maxLines, b.maxBytes)
+	if b.budget != nil {
+		atomic.AddInt64(&b.budget.size, int64(b.size-before))
+	}
+}
+
+// limits returns the limits currently in use for the backlog. The caller must
+// hold the lock.
+func (b *ConsoleBacklog) limits() (int, int) {
+	maxLines, maxBytes := b.maxLines, b.maxBytes
+	if b.starting {
+		if b.startupLines > 0 {
+			maxLines = b.startupLines
+		}
+		if b.startupBytes > 0 {
+			maxBytes = b.startupBytes
+		}
+	}
+	return maxLines, maxBytes
+}
+
+// trim removes the oldest lines until the backlog is within the limits. The
+// caller must hold the lock.
+func (b *ConsoleBacklog) trim(maxLines int, maxBytes int) {
+	drop := 0
+	for len(b.lines)-drop > maxLines || b.size > maxBytes {
+		b.size -= len(b.lines[drop])
+		drop++
+	}
+	if drop > 0 {
+		// Copy the remaining lines down so that the array backing the slice does
+		// not keep growing.
+		n := copy(b.lines, b.lines[drop:])
+		for i := n; i < len(b.lines); i++ {
+			b.lines[i] = nil
+		}
+		b.lines = b.lines[:n]
+	}
+}
+
+// evict removes the oldest lines from the backlog until at least n bytes have
+// been freed or the backlog is empty. The backlog no longer holds all the recent
+// output afterwards, so logs are read from Docker instead.
+func (b *ConsoleBacklog) evict(n int64) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var freed int64
+	drop := 0
+	for drop < len(b.lines) && freed < n {
+		freed += int64(len(b.lines[drop]))
+		drop++
+	}
+	if drop == 0 {
+		return 0
+	}
+	k := copy(b.lines, b.lines[drop:])
+	for i := k; i < len(b.lines); i++ {
+		b.lines[i] = nil
+	}
+	b.lines = b.lines[:k]
+	b.size -= int(freed)
+	b.complete = false
+	if b.budget != nil {
+		atomic.AddInt64(&b.budget.size, -freed)
+	}
+	return freed
+}
+
+// Lines returns up to the last n lines in the backlog, oldest first.
+func (b *ConsoleBacklog) Lines(n int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	start := 0
+	if n >= 0 && len(b.lines) > n {
+		start = len(b.lines) - n
+	}
+	out := make([]string, 0, len(b.lines)-start)
+	for _, l := range b.lines[start:] {
+		out = append(out, string(l))
+	}
+	return out
+}
+
+// Len returns the number of lines in the backlog.
+func (b *ConsoleBacklog) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.lines)
+}
+
+// Size returns the total size in bytes of the lines in the backlog.
+func (b *ConsoleBacklog) Size() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Reset removes every line from the backlog. This is called when the server
+// starts, after which the backlog is considered complete.
+func (b *ConsoleBacklog) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.budget != nil {
+		atomic.AddInt64(&b.budget.size, -int64(b.size))
+	}
+	b.lines = nil
+	b.size = 0
+	b.complete = true
+}
+
+// Complete returns true if the backlog contains all the recent output for
+// the server.
+func (b *ConsoleBacklog) Complete() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.complete && b.maxLines > 0 && b.maxBytes > 0
+}
+
+// RecentLogs returns up to the last n lines of console output for the server.
+// These come from the console backlog when possible, and are otherwise read from
+// the Docker logs for the container, in which case any secrets in them are masked.
+func (s *Server) RecentLogs(n int) ([]string, error) {
+	if b := s.ConsoleBacklog(); b.Complete() {
+		return b.Lines(n), nil
+	}
+	lines, err := s.Environment.Readlog(n)
+	for i, line := range lines {
+		lines[i] = string(MaskSecrets([]byte(line)))
+	}
+	return lines, err
+}
+
+// IsTransientLogError returns true if an error returned by RecentLogs may not
+// happen again if the logs are requested again, such as the connection to Docker
+// being interrupted while reading. Errors caused by the container no longer
+// existing, or by a log driver Docker cannot read logs back from, are not.
+func IsTransientLogError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !errdefs.IsNotFound(err) && !errdefs.IsNotImplemented(err) && !errdefs.IsInvalidParameter(err) && !errdefs.IsForbidden(err)
+}
+
+// ConsoleBacklog returns the recent console output for the server.
+func (s *Server) ConsoleBacklog() *ConsoleBacklog {
+	s.backlogOnce.Do(func() {
+		cfg := config.Get().System.ConsoleBacklog
+		s.backlog = NewConsoleBacklog(cfg.Lines, cfg.Bytes)
+		s.backlog.SetStartupLimits(cfg.StartupLines, cfg.StartupBytes)
+		ConsoleBacklogBudget().Add(s.backlog)
+	})
+	return s.backlog
+}
+
+// BacklogBudget limits the total size of a group of console backlogs. Once the
+// limit is exceeded lines are removed from the backlogs of the servers that have
+// gone the longest without any output, since those are the least likely to be
+// looked at.
+type BacklogBudget struct {
+	// Accessed atomically, kept first so that it is aligned on 32-bit platforms.
+	size int64
+	max  int64
+
+	mu       sync.Mutex
+	backlogs map[*ConsoleBacklog]struct{}
+}
+
+// NewBacklogBudget returns a budget limiting the backlogs added to it to a total
+// of max bytes. A limit of 0 or less only tracks their size.
+func NewBacklogBudget(max int64) *BacklogBudget {
+	return &BacklogBudget{max: max, backlogs: make(map[*ConsoleBacklog]struct{})}
+}
+
+var (
+	backlogBudget     *BacklogBudget
+	backlogBudgetOnce sync.Once
+)
+
+// ConsoleBacklogBudget returns the budget shared by the console backlogs of
+// every server on the node.
+func ConsoleBacklogBudget() *BacklogBudget {
+	backlogBudgetOnce.Do(func() {
+		backlogBudget = NewBacklogBudget(config.Get().System.ConsoleBacklog.NodeBytes)
+	})
+	return backlogBudget
+}
+
+// Add counts the backlog towards the budget.
+func (bb *BacklogBudget) Add(b *ConsoleBacklog) {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+
+	b.mu.Lock()
+	if b.budget == nil {
+		b.budget = bb
+		bb.backlogs[b] = struct{}{}
+		atomic.AddInt64(&bb.size, int64(b.size))
+	}
+	b.mu.Unlock()
+}
+
+// Remove stops counting the backlog towards the budget, this is called when a
+// server is deleted.
+func (bb *BacklogBudget) Remove(b *ConsoleBacklog) {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+
+	b.mu.Lock()
+	if b.budget == bb {
+		b.budget = nil
+		delete(bb.backlogs, b)
+		atomic.AddInt64(&bb.size, -int64(b.size))
+	}
+	b.mu.Unlock()
+}
+
+// Size returns the total size in bytes of the backlogs in the budget.
+func (bb *BacklogBudget) Size() int64 {
+	return atomic.LoadInt64(&bb.size)
+}
+
+// Max returns the limit of the budget in bytes, or 0 if it is unlimited.
+func (bb *BacklogBudget) Max() int64 {
+	if bb.max < 0 {
+		return 0
+	}
+	return bb.max
+}
+
+// Len returns the number of backlogs in the budget.
+func (bb *BacklogBudget) Len() int {
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+	return len(bb.backlogs)
+}
+
+// The fraction of its limit a budget is brought back down to once the limit is
+// exceeded, so that lines do not need to be removed on every push while output
+// is being written.
+const backlogBudgetLowWater = 0.9
+
+// enforce removes lines from the least recently active backlogs once the budget
+// is exceeded, until it is back down to the low-water mark.
+func (bb *BacklogBudget) enforce() {
+	if bb.max <= 0 || atomic.LoadInt64(&bb.size) <= bb.max {
+		return
+	}
+	target := int64(float64(bb.max) * backlogBudgetLowWater)
+
+	bb.mu.Lock()
+	defer bb.mu.Unlock()
+
+	type entry struct {
+		b          *ConsoleBacklog
+		lastActive time.Time
+	}
+	entries := make([]entry, 0, len(bb.backlogs))
+	for b := range bb.backlogs {
+		b.mu.Lock()
+		entries = append(entries, entry{b: b, lastActive: b.lastActive})
+		b.mu.Unlock()
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastActive.Before(entries[j].lastActive)
+	})
+	for _, e := range entries {
+		over := atomic.LoadInt64(&bb.size) - target
+		if over <= 0 {
+			return
+		}
+		e.b.evict(over)
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	"github.com/docker/docker/errdefs"
+	. "github.com/franela/goblin"
+)
+
+func TestConsoleBacklog(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("ConsoleBacklog", func() {
+		g.It("returns the most recent lines", func() {
+			b := NewConsoleBacklog(10, 1024)
+			for _, l := range []string{"one", "two", "three"} {
+				b.Push([]byte(l))
+			}
+			g.Assert(b.Lines(2)).Equal([]string{"two", "three"})
+			g.Assert(b.Lines(10)).Equal([]string{"one", "two", "three"})
+		})
+
+		g.It("removes the oldest lines once the line limit is reached", func() {
+			b := NewConsoleBacklog(2, 1024)
+			for _, l := range []string{"one", "two", "three"} {
+				b.Push([]byte(l))
+			}
+			g.Assert(b.Lines(10)).Equal([]string{"two", "three"})
+			g.Assert(b.Size()).Equal(8)
+		})
+
+		g.It("removes the oldest lines once the byte limit is reached", func() {
+			b := NewConsoleBacklog(10, 10)
+			for _, l := range []string{"aaaa", "bbbb", "cccc"} {
+				b.Push([]byte(l))
+			}
+			g.Assert(b.Lines(10)).Equal([]string{"bbbb", "cccc"})
+			g.Assert(b.Size()).Equal(8)
+		})
+
+		g.It("truncates lines larger than the byte limit", func() {
+			b := NewConsoleBacklog(10, 10)
+			b.Push([]byte("one"))
+			b.Push([]byte(strings.Repeat("x", 50)))
+			g.Assert(b.Lines(10)).Equal([]string{strings.Repeat("x", 10)})
+		})
+
+		g.It("copies the lines that are pushed", func() {
+			b := NewConsoleBacklog(10, 1024)
+			line := []byte("one")
+			b.Push(line)
+			line[0] = 'x'
+			g.Assert(b.Lines(1)).Equal([]string{"one"})
+		})
+
+		g.It("uses the startup limits while the server is starting", func() {
+			b := NewConsoleBacklog(2, 1024)
+			b.SetStartupLimits(4, 0)
+			b.SetStarting(true)
+			for _, l := range []string{"one", "two", "three", "four", "five"} {
+				b.Push([]byte(l))
+			}
+			g.Assert(b.Lines(10)).Equal([]string{"two", "three", "four", "five"})
+
+			b.SetStarting(false)
+			g.Assert(b.Lines(10)).Equal([]string{"four", "five"})
+			g.Assert(b.Size()).Equal(8)
+		})
+
+		g.It("is only complete once it has been reset", func() {
+			b := NewConsoleBacklog(10, 1024)
+			g.Assert(b.Complete()).IsFalse()
+			b.Reset()
+			g.Assert(b.Complete()).IsTrue()
+
+			g.Assert(NewConsoleBacklog(0, 1024).Complete()).IsFalse()
+		})
+	})
+
+	g.Describe("BacklogBudget", func() {
+		g.It("tracks the size of the backlogs added to it", func() {
+			bb := NewBacklogBudget(0)
+			one, two := NewConsoleBacklog(10, 1024), NewConsoleBacklog(10, 1024)
+			one.Push([]byte("aaaa"))
+			bb.Add(one)
+			bb.Add(two)
+			two.Push([]byte("bbbb"))
+			g.Assert(bb.Size()).Equal(int64(8))
+
+			one.Reset()
+			g.Assert(bb.Size()).Equal(int64(4))
+			bb.Remove(two)
+			g.Assert(bb.Size()).Equal(int64(0))
+			g.Assert(bb.Len()).Equal(1)
+		})
+
+		g.It("removes lines from the least recently active backlog first", func() {
+			bb := NewBacklogBudget(20)
+			idle, busy := NewConsoleBacklog(10, 1024), NewConsoleBacklog(10, 1024)
+			bb.Add(idle)
+			bb.Add(busy)
+			idle.Reset()
+			busy.Reset()
+
+			idle.Push([]byte("aaaa"))
+			idle.Push([]byte("bbbb"))
+			idle.Push([]byte("cccc"))
+			busy.Push([]byte("dddd"))
+			busy.Push([]byte("eeee"))
+			busy.Push([]byte("ffff"))
+
+			// Lines are removed until the budget is below 90% of its limit rather
+			// than just under it.
+			g.Assert(bb.Size()).Equal(int64(16))
+			g.Assert(idle.Lines(10)).Equal([]string{"cccc"})
+			g.Assert(busy.Lines(10)).Equal([]string{"dddd", "eeee", "ffff"})
+			// The idle backlog is missing output now, so it is no longer complete.
+			g.Assert(idle.Complete()).IsFalse()
+			g.Assert(busy.Complete()).IsTrue()
+		})
+	})
+}
+
+func TestIsTransientLogError(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("IsTransientLogError", func() {
+		g.It("treats interrupted reads as transient", func() {
+			g.Assert(IsTransientLogError(errors.WithStack(io.ErrUnexpectedEOF))).IsTrue()
+		})
+
+		g.It("does not treat a missing container as transient", func() {
+			g.Assert(IsTransientLogError(errors.WithStack(errdefs.NotFound(errors.New("no such container"))))).IsFalse()
+		})
+
+		g.It("does not treat an unreadable log driver as transient", func() {
+			g.Assert(IsTransientLogError(errdefs.NotImplemented(errors.New("configured logging driver does not support reading")))).IsFalse()
+		})
+
+		g.It("returns false without an error", func() {
+			g.Assert(IsTransientLogError(nil)).IsFalse()
+		})
+	})
+}
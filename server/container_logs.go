@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+var ErrInvalidLogRange = errors.New("the end of the log range must be after the start")
+
+// containerLogReader is implemented by environments that can read past output
+// from the log kept for the container.
+type containerLogReader interface {
+	Logs(ctx context.Context, since, until time.Time, fn func(environment.LogEntry) bool) error
+}
+
+// ContainerLogs passes each line the server's container wrote between since and
+// until to fn, oldest first, along with when it was written and the stream it was
+// written to. A zero until reads up to the latest line. At most max lines are
+// read if max is above zero, and true is returned if there were more lines in
+// the range than that.
+//
+// This reads from the log kept by Docker rather than the server's console
+// history, so lines from before Wings was last restarted are included. Any
+// configured secrets are masked in every line, the same as the console output.
+func (s *Server) ContainerLogs(ctx context.Context, since, until time.Time, max int, fn func(environment.LogEntry)) (bool, error) {
+	if !until.IsZero() && !until.After(since) {
+		return false, ErrInvalidLogRange
+	}
+	e, ok := s.Environment.(containerLogReader)
+	if !ok {
+		return false, errors.New("server: environment does not support reading container logs")
+	}
+
+	var n int
+	var truncated bool
+	err := e.Logs(ctx, since, until, func(entry environment.LogEntry) bool {
+		if max > 0 && n >= max {
+			truncated = true
+			return false
+		}
+		n++
+		entry.Line = string(MaskSecrets([]byte(entry.Line)))
+		fn(entry)
+		return true
+	})
+	return truncated, err
+}
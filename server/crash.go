@@ -12,11 +12,29 @@ import (
 	"github.com/pterodactyl/wings/environment"
 )
 
+// CrashDetails contains the information about the last time a server process
+// was detected as having crashed.
+type CrashDetails struct {
+	ExitCode  uint32    `json:"exit_code"`
+	OOMKilled bool      `json:"oom_killed"`
+	Timestamp time.Time `json:"timestamp"`
+	// The number of times in a row the server has been restarted automatically
+	// after crashing, this is reset along with the rest of the details.
+	Restarts int `json:"restarts"`
+}
+
 type CrashHandler struct {
 	mu sync.RWMutex
 
 	// Tracks the time of the last server crash event.
 	lastCrash time.Time
+
+	// The details of the last crash event, this is cleared once the server has been
+	// running without crashing for long enough.
+	details *CrashDetails
+
+	// The number of automatic restarts since the details were last cleared.
+	restarts int
 }
 
 // Returns the time of the last crash for this server instance.
@@ -34,6 +52,69 @@ func (cd *CrashHandler) SetLastCrash(t time.Time) {
 	cd.mu.Unlock()
 }
 
+// LastCrash returns a copy of the details of the last crash, or nil if there
+// has not been one since the details were last cleared.
+func (cd *CrashHandler) LastCrash() *CrashDetails {
+	cd.mu.RLock()
+	defer cd.mu.RUnlock()
+
+	if cd.details == nil {
+		return nil
+	}
+	d := *cd.details
+	d.Restarts = cd.restarts
+	return &d
+}
+
+// Counts an automatic restart of the server after a crash.
+func (cd *CrashHandler) countRestart() {
+	cd.mu.Lock()
+	cd.restarts++
+	cd.mu.Unlock()
+}
+
+// Sets the details of the last crash for a server.
+func (cd *CrashHandler) setLastCrashDetails(d CrashDetails) {
+	cd.mu.Lock()
+	cd.details = &d
+	cd.mu.Unlock()
+}
+
+// Clears the last crash details if the crash occurred before the given time.
+func (cd *CrashHandler) clearLastCrashDetailsBefore(t time.Time) {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	if cd.details != nil && cd.details.Timestamp.Before(t) {
+		cd.details = nil
+		cd.restarts = 0
+	}
+}
+
+// LastCrash returns the details of the last detected crash for the server, or
+// nil if the server has not crashed recently.
+func (s *Server) LastCrash() *CrashDetails {
+	return s.crasher.LastCrash()
+}
+
+// Clears the last crash details for the server once it has remained in the
+// running state for the configured amount of time. If the server crashes again
+// in that window the new crash details are left in place.
+func (s *Server) clearCrashDetailsWhenStable() {
+	d := time.Duration(config.Get().System.CrashDetection.ClearDetailsAfter) * time.Second
+	if d <= 0 || s.crasher.LastCrash() == nil {
+		return
+	}
+
+	started := time.Now()
+	time.AfterFunc(d, func() {
+		if s.Environment.State() != environment.ProcessRunningState {
+			return
+		}
+		s.crasher.clearLastCrashDetailsBefore(started)
+	})
+}
+
 // Looks at the environment exit state to determine if the process exited cleanly or
 // if it was the result of an event that we should try to recover from.
 //
@@ -69,6 +150,12 @@ func (s *Server) handleServerCrash() error {
 		return nil
 	}
 
+	s.crasher.setLastCrashDetails(CrashDetails{
+		ExitCode:  exitCode,
+		OOMKilled: oomKilled,
+		Timestamp: time.Now(),
+	})
+
 	s.PublishConsoleOutputFromDaemon("---------- Detected server process in a crashed state! ----------")
 	s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Exit code: %d", exitCode))
 	s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Out of memory: %t", oomKilled))
@@ -86,6 +173,7 @@ func (s *Server) handleServerCrash() error {
 	}
 
 	s.crasher.SetLastCrash(time.Now())
+	s.crasher.countRestart()
 
 	return errors.Wrap(s.HandlePowerAction(PowerActionStart), "failed to start server after crash detection")
 }
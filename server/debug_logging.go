@@ -0,0 +1,64 @@
+package server
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// DebugLogging is whether debug logging is turned on for a server, and when it
+// turns itself off again.
+type DebugLogging struct {
+	Enabled   bool       `json:"enabled"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SetDebugLogging turns debug logging on or off for the server, without changing
+// the logging for any other server on the node. Debug logging turns itself off
+// once the duration has passed, which is capped at the configured maximum so
+// that it is never left on by accident. The maximum is used if the duration is
+// 0.
+func (s *Server) SetDebugLogging(enabled bool, d time.Duration) DebugLogging {
+	if !enabled {
+		atomic.StoreInt64(&s.debugUntil, 0)
+		s.Log().Info("debug logging disabled for server")
+		return s.DebugLogging()
+	}
+	max := time.Duration(config.Get().System.DebugLoggingDuration) * time.Second
+	if d <= 0 || d > max {
+		d = max
+	}
+	atomic.StoreInt64(&s.debugUntil, time.Now().Add(d).UnixNano())
+	s.Log().WithField("duration", d.String()).Info("debug logging enabled for server")
+	return s.DebugLogging()
+}
+
+// DebugLogging returns whether debug logging is currently turned on for the
+// server.
+func (s *Server) DebugLogging() DebugLogging {
+	until := atomic.LoadInt64(&s.debugUntil)
+	if until == 0 || time.Now().UnixNano() >= until {
+		return DebugLogging{}
+	}
+	t := time.Unix(0, until).UTC()
+	return DebugLogging{Enabled: true, ExpiresAt: &t}
+}
+
+// logger returns the logger used for the server's log entries. While debug
+// logging is turned on for the server this writes to the same place as the
+// default logger, but includes debug entries no matter the level set for the
+// rest of Wings.
+func (s *Server) logger() log.Interface {
+	until := atomic.LoadInt64(&s.debugUntil)
+	if until == 0 || time.Now().UnixNano() >= until {
+		return log.Log
+	}
+	l, ok := log.Log.(*log.Logger)
+	if !ok {
+		return log.Log
+	}
+	return &log.Logger{Handler: l.Handler, Level: log.DebugLevel}
+}
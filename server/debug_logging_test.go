@@ -0,0 +1,55 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+func TestServer_DebugLogging(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#SetDebugLogging", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.DebugLoggingDuration = 60
+			config.Set(c)
+		})
+
+		g.It("logs debug entries for the server while enabled", func() {
+			s := &Server{}
+			g.Assert(s.DebugLogging().Enabled).IsFalse()
+
+			st := s.SetDebugLogging(true, time.Second*30)
+			g.Assert(st.Enabled).IsTrue()
+			g.Assert(time.Until(*st.ExpiresAt) <= time.Second*30).IsTrue()
+
+			l, ok := s.logger().(*log.Logger)
+			g.Assert(ok).IsTrue()
+			g.Assert(l.Level).Equal(log.DebugLevel)
+
+			g.Assert(s.SetDebugLogging(false, 0).Enabled).IsFalse()
+			g.Assert(s.logger() == log.Log).IsTrue()
+		})
+
+		g.It("caps the duration at the configured maximum", func() {
+			s := &Server{}
+			st := s.SetDebugLogging(true, time.Hour)
+			g.Assert(time.Until(*st.ExpiresAt) <= time.Minute).IsTrue()
+
+			st = s.SetDebugLogging(true, 0)
+			g.Assert(time.Until(*st.ExpiresAt) > time.Second*55).IsTrue()
+		})
+
+		g.It("turns itself off once expired", func() {
+			s := &Server{}
+			s.debugUntil = time.Now().Add(-time.Second).UnixNano()
+			g.Assert(s.DebugLogging().Enabled).IsFalse()
+			g.Assert(s.logger() == log.Log).IsTrue()
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/gorilla/websocket"
+)
+
+// drain tracks the progress of closing all the websocket connections on the
+// node.
+type drain struct {
+	cancel    context.CancelFunc
+	startedAt time.Time
+	window    time.Duration
+	total     int
+	closed    int
+}
+
+// DrainStatus is the progress of draining the websocket connections on the node.
+type DrainStatus struct {
+	Draining  bool       `json:"draining"`
+	StartedAt *time.Time `json:"started_at"`
+	Window    int        `json:"window"`
+	// The number of connections that were open when draining started, and the
+	// number of those that have been closed so far.
+	Total  int `json:"total"`
+	Closed int `json:"closed"`
+	// The number of connections still open on the node.
+	Remaining int `json:"remaining"`
+}
+
+// Drain stops new websocket connections from being made to any server on the node
+// and closes the open connections, spread evenly across the window. The node
+// remains draining until StopDrain is called, even once every connection has
+// been closed. Calling this while the node is already draining has no effect.
+func (m *Manager) Drain(window time.Duration) DrainStatus {
+	m.drainMu.Lock()
+	if m.drain == nil {
+		var closers []WebsocketCloser
+		for _, s := range m.All() {
+			closers = append(closers, s.Websockets().Closers()...)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		m.drain = &drain{cancel: cancel, startedAt: time.Now(), window: window, total: len(closers)}
+		log.WithField("connections", len(closers)).WithField("window", window).Info("draining websocket connections from node")
+		go m.closeDrained(ctx, m.drain, closers)
+	}
+	m.drainMu.Unlock()
+
+	return m.DrainStatus()
+}
+
+// StopDrain allows new websocket connections to be made to the node again. Any
+// connections that have not been closed yet are left open.
+func (m *Manager) StopDrain() {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+
+	if m.drain != nil {
+		m.drain.cancel()
+		m.drain = nil
+		log.Info("stopped draining websocket connections from node")
+	}
+}
+
+// Draining returns true if the node is being drained, and new websocket
+// connections should be refused.
+func (m *Manager) Draining() bool {
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+	return m.drain != nil
+}
+
+// DrainStatus returns the progress of draining the node.
+func (m *Manager) DrainStatus() DrainStatus {
+	remaining := 0
+	for _, s := range m.All() {
+		remaining += s.Websockets().Len()
+	}
+
+	m.drainMu.Lock()
+	defer m.drainMu.Unlock()
+
+	status := DrainStatus{Remaining: remaining}
+	if d := m.drain; d != nil {
+		startedAt := d.startedAt
+		status.Draining = true
+		status.StartedAt = &startedAt
+		status.Window = int(d.window.Seconds())
+		status.Total = d.total
+		status.Closed = d.closed
+	}
+	return status
+}
+
+// closeDrained closes each of the connections in turn, spread evenly across the
+// window for the drain, until they are all closed or the drain is stopped.
+func (m *Manager) closeDrained(ctx context.Context, d *drain, closers []WebsocketCloser) {
+	if len(closers) == 0 {
+		return
+	}
+	interval := d.window / time.Duration(len(closers))
+
+	for _, fn := range closers {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		fn(websocket.CloseServiceRestart, NewCloseReason(CloseReasonDrain, "node is going down for maintenance").String())
+
+		m.drainMu.Lock()
+		d.closed++
+		m.drainMu.Unlock()
+
+		if interval > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}
+}
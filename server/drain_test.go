@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+func TestManager_Drain(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Manager#Drain", func() {
+		var (
+			mu     sync.Mutex
+			closed []int
+		)
+		newManager := func(connections int) *Manager {
+			closed = nil
+			s := &Server{}
+			for i := 0; i < connections; i++ {
+				u := uuid.New()
+				_, cancel := context.WithCancel(context.Background())
+				s.Websockets().Push(u, &cancel, func(code int, _ string) {
+					mu.Lock()
+					closed = append(closed, code)
+					mu.Unlock()
+					s.Websockets().Remove(u)
+				})
+			}
+			m := NewEmptyManager(nil)
+			m.Add(s)
+			return m
+		}
+
+		g.It("closes every open connection", func() {
+			m := newManager(3)
+			defer m.StopDrain()
+
+			status := m.Drain(0)
+			g.Assert(status.Draining).IsTrue()
+			g.Assert(status.Total).Equal(3)
+			g.Assert(m.Draining()).IsTrue()
+
+			g.Timeout(time.Second * 2)
+			for m.DrainStatus().Closed < 3 {
+				time.Sleep(time.Millisecond * 10)
+			}
+			mu.Lock()
+			g.Assert(closed).Equal([]int{websocket.CloseServiceRestart, websocket.CloseServiceRestart, websocket.CloseServiceRestart})
+			mu.Unlock()
+			g.Assert(m.DrainStatus().Remaining).Equal(0)
+		})
+
+		g.It("stops closing connections once the drain is stopped", func() {
+			m := newManager(3)
+
+			// The first connection is closed straight away, and the rest are spread
+			// across the window.
+			m.Drain(time.Hour * 3)
+			g.Timeout(time.Second * 2)
+			for m.DrainStatus().Closed < 1 {
+				time.Sleep(time.Millisecond * 10)
+			}
+			m.StopDrain()
+
+			g.Assert(m.Draining()).IsFalse()
+			g.Assert(m.DrainStatus().Remaining).Equal(2)
+		})
+	})
+}
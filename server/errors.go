@@ -5,11 +5,19 @@ import (
 )
 
 var (
-	ErrIsRunning            = errors.New("server is running")
-	ErrSuspended            = errors.New("server is currently in a suspended state")
-	ErrServerIsInstalling   = errors.New("server is currently installing")
-	ErrServerIsTransferring = errors.New("server is currently being transferred")
-	ErrServerIsRestoring    = errors.New("server is currently being restored")
+	ErrIsRunning             = errors.New("server is running")
+	ErrSuspended             = errors.New("server is currently in a suspended state")
+	ErrServerIsInstalling    = errors.New("server is currently installing")
+	ErrServerIsTransferring  = errors.New("server is currently being transferred")
+	ErrServerIsRestoring     = errors.New("server is currently being restored")
+	ErrImageMissing          = errors.New("server image is not present on this node")
+	ErrNotEnoughMemory       = errors.New("server memory limit is larger than the memory available on this node")
+	ErrBackupInProgress      = errors.New("backup is already in progress")
+	ErrInvalidPowerAction    = errors.New("power action is not valid for the current server state")
+	ErrPowerActionInProgress = errors.New("action already in progress")
+	ErrPowerActionDisabled   = errors.New("this power action has been disabled for the server")
+	ErrNotRunning            = errors.New("server is not running")
+	ErrCommandQueueFull      = errors.New("too many commands are already waiting for the server to start")
 )
 
 type crashTooFrequent struct{}
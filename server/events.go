@@ -1,6 +1,7 @@
 package server
 
 import (
+	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/events"
 	"github.com/pterodactyl/wings/system"
 )
@@ -16,9 +17,38 @@ const (
 	StatsEvent                  = "stats"
 	BackupRestoreCompletedEvent = "backup restore completed"
 	BackupCompletedEvent        = "backup completed"
+	BackupProgressEvent         = "backup progress"
 	TransferLogsEvent           = "transfer logs"
 	TransferStatusEvent         = "transfer status"
 	DeletedEvent                = "deleted"
+	CrashDetailsEvent           = "crash details"
+	LimitsEvent                 = "limits"
+	StartupCommandEvent         = "startup command"
+	StatsHistoryEvent           = "stats history"
+	StatsTrendEvent             = "stats trend"
+	StartupTimeoutEvent         = "startup timeout"
+	LogFileOutputEvent          = "log file output"
+	CommandsDisabledEvent       = "commands disabled"
+	BackendStatusEvent          = "backend status"
+	OutputStalledEvent          = "output stalled"
+	PowerActionsEvent           = "power actions"
+	AutoStartEvent              = "auto start"
+	ConfigurationReloadedEvent  = "configuration reloaded"
+	ResourceLimitEvent          = "resource limit"
+	ImagePullProgressEvent      = "image pull progress"
+	ImagePullFailedEvent        = "image pull failed"
+	StatusSummaryEvent          = "status summary"
+	RestartPendingEvent         = "restart pending"
+	ContainerRecreateEvent      = "container recreate"
+	IdleStoppedEvent            = "idle stopped"
+	DiskUsageCalculatedEvent    = "disk usage calculated"
+	WebsocketClosedEvent        = "websocket closed"
+)
+
+// The values sent with a BackendStatusEvent.
+const (
+	BackendAvailable   = "available"
+	BackendUnavailable = "unavailable"
 )
 
 // Events returns the server's emitter instance.
@@ -28,6 +58,7 @@ func (s *Server) Events() *events.Bus {
 
 	if s.emitter == nil {
 		s.emitter = events.NewBus()
+		s.emitter.SetLimit(config.Get().System.Websocket.MaxListeners)
 	}
 
 	return s.emitter
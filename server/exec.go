@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/environment/docker"
+)
+
+var (
+	ErrExecDisabled   = errors.New("running commands in the container is disabled on this node")
+	ErrExecNotAllowed = errors.New("the command is not in the list of commands that can be run")
+	ErrExecNotRunning = errors.New("commands can only be run while the server is running")
+)
+
+// ExecResult is the outcome of a command run inside the server's container.
+type ExecResult struct {
+	ExitCode int `json:"exit_code"`
+	// Set if the command output more than the configured limit and some of it was
+	// not sent.
+	Truncated bool `json:"truncated"`
+	// Set if the command did not finish before the timeout, in which case the exit
+	// code is not known.
+	TimedOut bool `json:"timed_out"`
+}
+
+// ExecAllowed returns true if the binary run by a command is in the configured
+// allowlist.
+func ExecAllowed(cmd []string) bool {
+	if len(cmd) == 0 {
+		return false
+	}
+	for _, v := range config.Get().System.Websocket.Exec.Allowlist {
+		if v == cmd[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckExec returns an error if running commands in the container is disabled,
+// or if the binary run by the command is not in the configured allowlist.
+func CheckExec(command string) error {
+	if !config.Get().System.Websocket.Exec.Enabled {
+		return ErrExecDisabled
+	}
+	if !ExecAllowed(strings.Fields(command)) {
+		return ErrExecNotAllowed
+	}
+	return nil
+}
+
+// Exec runs a one-off command inside the server's container, separate from the
+// server process and its console, passing each line of output to the callback.
+// This is intended for debugging, such as checking what is using the disk. The
+// command is split on whitespace and run directly without a shell, so pipes and
+// variables are not supported.
+//
+// Output past the configured limit is discarded, and the command is abandoned if
+// it does not finish within the configured timeout.
+func (s *Server) Exec(ctx context.Context, command string, fn func(line []byte, stream environment.OutputStream)) (*ExecResult, error) {
+	if err := CheckExec(command); err != nil {
+		return nil, err
+	}
+	cfg := config.Get().System.Websocket.Exec
+	cmd := strings.Fields(command)
+	e, ok := s.Environment.(*docker.Environment)
+	if !ok {
+		return nil, errors.New("server: environment does not support running commands")
+	}
+	if !s.IsRunning() {
+		return nil, ErrExecNotRunning
+	}
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	result := &ExecResult{}
+	// The callback is called for stdout and stderr at the same time, so the amount
+	// written so far is guarded by a lock.
+	var mu sync.Mutex
+	var written int
+	code, err := e.Exec(ctx, cmd, func(line []byte, stream environment.OutputStream) {
+		mu.Lock()
+		defer mu.Unlock()
+		if result.Truncated {
+			return
+		}
+		if cfg.MaxOutput > 0 && written+len(line) > cfg.MaxOutput {
+			result.Truncated = true
+			return
+		}
+		written += len(line)
+		fn(line, stream)
+	})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.TimedOut = true
+			return result, nil
+		}
+		return nil, err
+	}
+	result.ExitCode = code
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+)
+
+func TestExec(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#Exec", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.Websocket.Exec.Enabled = true
+			c.System.Websocket.Exec.Allowlist = []string{"ls", "df"}
+			config.Set(c)
+		})
+
+		noop := func([]byte, environment.OutputStream) {}
+
+		g.It("only allows binaries in the allowlist", func() {
+			g.Assert(ExecAllowed([]string{"ls", "-la"})).IsTrue()
+			g.Assert(ExecAllowed([]string{"rm", "-rf", "/"})).IsFalse()
+			g.Assert(ExecAllowed([]string{"/bin/ls"})).IsFalse()
+			g.Assert(ExecAllowed(nil)).IsFalse()
+		})
+
+		g.It("rejects commands that are not allowed", func() {
+			_, err := (&Server{}).Exec(context.Background(), "rm -rf /", noop)
+			g.Assert(errors.Is(err, ErrExecNotAllowed)).IsTrue()
+		})
+
+		g.It("rejects every command when disabled", func() {
+			config.Update(func(c *config.Configuration) {
+				c.System.Websocket.Exec.Enabled = false
+			})
+			_, err := (&Server{}).Exec(context.Background(), "ls", noop)
+			g.Assert(errors.Is(err, ErrExecDisabled)).IsTrue()
+		})
+
+		g.It("checks a command before it is run", func() {
+			g.Assert(CheckExec("df -h")).IsNil()
+			g.Assert(errors.Is(CheckExec("rm -rf /"), ErrExecNotAllowed)).IsTrue()
+
+			config.Update(func(c *config.Configuration) {
+				c.System.Websocket.Exec.Enabled = false
+			})
+			g.Assert(errors.Is(CheckExec("df -h"), ErrExecDisabled)).IsTrue()
+		})
+	})
+}
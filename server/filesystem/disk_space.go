@@ -1,14 +1,12 @@
 package filesystem
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
-	"syscall"
 	"time"
 
-	"emperror.dev/errors"
 	"github.com/apex/log"
-	"github.com/karrick/godirwalk"
 )
 
 type SpaceCheckingOpts struct {
@@ -147,13 +145,31 @@ func (fs *Filesystem) updateCachedDiskUsage() (int64, error) {
 	// Always cache the size, even if there is an error. We want to always return that value
 	// so that we don't cause an endless loop of determining the disk size if there is a temporary
 	// error encountered.
-	fs.lastLookupTime.Set(time.Now())
+	now := time.Now()
+	fs.lastLookupTime.Set(now)
 
 	atomic.StoreInt64(&fs.diskUsed, size)
 
+	if fs.onDiskUsage != nil {
+		fs.onDiskUsage(size, now)
+	}
+
 	return size, err
 }
 
+// LastCalculated returns the time the disk usage was last calculated, the cached
+// usage is not updated in between other than for files written through Wings.
+// This is the zero time if the disk usage has not been calculated yet.
+func (fs *Filesystem) LastCalculated() time.Time {
+	return fs.lastLookupTime.Get()
+}
+
+// OnDiskUsageCalculated sets a function that is called with the disk usage each
+// time it is calculated. This must be set before the filesystem is used.
+func (fs *Filesystem) OnDiskUsageCalculated(fn func(size int64, at time.Time)) {
+	fs.onDiskUsage = fn
+}
+
 // Determines the directory size of a given location by running parallel tasks to iterate
 // through all of the folders. Returns the size in bytes. This can be a fairly taxing operation
 // on locations with tons of files, so it is recommended that you cache the output.
@@ -163,35 +179,7 @@ func (fs *Filesystem) DirectorySize(dir string) (int64, error) {
 		return 0, err
 	}
 
-	var size int64
-	var st syscall.Stat_t
-
-	err = godirwalk.Walk(d, &godirwalk.Options{
-		Unsorted: true,
-		Callback: func(p string, e *godirwalk.Dirent) error {
-			// If this is a symlink then resolve the final destination of it before trying to continue walking
-			// over its contents. If it resolves outside the server data directory just skip everything else for
-			// it. Otherwise, allow it to continue.
-			if e.IsSymlink() {
-				if _, err := fs.SafePath(p); err != nil {
-					if IsErrorCode(err, ErrCodePathResolution) {
-						return godirwalk.SkipThis
-					}
-
-					return err
-				}
-			}
-
-			if !e.IsDir() {
-				_ = syscall.Lstat(p, &st)
-				atomic.AddInt64(&size, st.Size)
-			}
-
-			return nil
-		},
-	})
-
-	return size, errors.WrapIf(err, "server/filesystem: directorysize: failed to walk directory")
+	return fs.directorySize(context.Background(), d)
 }
 
 // Helper function to determine if a server has space available for a file of a given size.
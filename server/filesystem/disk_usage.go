@@ -0,0 +1,164 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/karrick/godirwalk"
+)
+
+// How long a breakdown of the disk usage of a directory is cached for.
+const diskUsageCacheTTL = time.Second * 30
+
+// The longest a breakdown of disk usage can take before the scan is stopped.
+const diskUsageTimeout = time.Second * 30
+
+// DirectoryUsage is the amount of disk space used by a directory.
+type DirectoryUsage struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// DiskUsageBreakdown is the disk space used by each directory within a
+// directory of the server.
+type DiskUsageBreakdown struct {
+	Directory string `json:"directory"`
+	// The directories within the directory, largest first.
+	Directories []DirectoryUsage `json:"directories"`
+	// The total size of the files directly within the directory.
+	Files int64 `json:"files"`
+	// Set if the scan was stopped before it finished, in which case the sizes are
+	// lower than the real usage.
+	Incomplete  bool      `json:"incomplete"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type diskUsageCache struct {
+	// Held while a directory is being scanned so that repeated requests wait for
+	// the first one to finish and use its result, rather than all scanning the
+	// disk at the same time.
+	mu      sync.Mutex
+	results map[string]*DiskUsageBreakdown
+}
+
+// DiskUsageBreakdown returns the disk space used by each directory directly
+// within the given directory, allowing users to find out what is using up the
+// space on their server. Only one level is broken down, the directories within
+// each directory are included in its size.
+//
+// Working this out means walking everything within the directory, so results
+// are cached for a short time and the scan is stopped after a timeout, or once
+// the context is cancelled. A breakdown that was stopped early is returned with
+// what was found so far and is not cached.
+func (fs *Filesystem) DiskUsageBreakdown(ctx context.Context, dir string) (*DiskUsageBreakdown, error) {
+	d, err := fs.SafePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	// A file has no directories within it, so it is treated the same as a missing
+	// directory.
+	st, err := os.Stat(d)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WithStackIf(err)
+	}
+	if err != nil || !st.IsDir() {
+		return nil, newFilesystemError(ErrNotExist, err)
+	}
+
+	fs.usage.mu.Lock()
+	defer fs.usage.mu.Unlock()
+	if v, ok := fs.usage.results[d]; ok && time.Since(v.GeneratedAt) < diskUsageCacheTTL {
+		return v, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diskUsageTimeout)
+	defer cancel()
+
+	entries, err := os.ReadDir(d)
+	if err != nil {
+		return nil, errors.Wrap(err, "server/filesystem: diskusage: failed to read directory")
+	}
+	rel, _ := filepath.Rel(fs.Path(), d)
+	usage := &DiskUsageBreakdown{Directory: filepath.Join("/", rel), Directories: []DirectoryUsage{}}
+	for _, e := range entries {
+		if !e.IsDir() {
+			if info, err := e.Info(); err == nil {
+				usage.Files += info.Size()
+			}
+			continue
+		}
+		size, err := fs.directorySize(ctx, filepath.Join(d, e.Name()))
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		usage.Directories = append(usage.Directories, DirectoryUsage{Name: e.Name(), Size: size})
+		if ctx.Err() != nil {
+			usage.Incomplete = true
+			break
+		}
+	}
+	sort.SliceStable(usage.Directories, func(i, j int) bool {
+		return usage.Directories[i].Size > usage.Directories[j].Size
+	})
+	usage.GeneratedAt = time.Now()
+
+	if !usage.Incomplete {
+		if fs.usage.results == nil {
+			fs.usage.results = make(map[string]*DiskUsageBreakdown)
+		}
+		// Remove anything that has expired so the cache does not keep growing as
+		// different directories are looked at.
+		for k, v := range fs.usage.results {
+			if time.Since(v.GeneratedAt) >= diskUsageCacheTTL {
+				delete(fs.usage.results, k)
+			}
+		}
+		fs.usage.results[d] = usage
+	}
+	return usage, nil
+}
+
+// directorySize returns the size of everything within a directory, which must
+// be a path that has already been resolved using SafePath. The walk is stopped
+// if the context is cancelled, returning the size found so far.
+func (fs *Filesystem) directorySize(ctx context.Context, d string) (int64, error) {
+	var size int64
+	var st syscall.Stat_t
+
+	err := godirwalk.Walk(d, &godirwalk.Options{
+		Unsorted: true,
+		Callback: func(p string, e *godirwalk.Dirent) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			// If this is a symlink then resolve the final destination of it before trying to continue walking
+			// over its contents. If it resolves outside the server data directory just skip everything else for
+			// it. Otherwise, allow it to continue.
+			if e.IsSymlink() {
+				if _, err := fs.SafePath(p); err != nil {
+					if IsErrorCode(err, ErrCodePathResolution) {
+						return godirwalk.SkipThis
+					}
+
+					return err
+				}
+			}
+
+			if !e.IsDir() {
+				_ = syscall.Lstat(p, &st)
+				atomic.AddInt64(&size, st.Size)
+			}
+
+			return nil
+		},
+	})
+
+	return size, errors.WrapIf(err, "server/filesystem: directorysize: failed to walk directory")
+}
@@ -0,0 +1,103 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestFilesystem_DiskUsageBreakdown(t *testing.T) {
+	g := Goblin(t)
+	fs, rfs := NewFs()
+
+	g.Describe("DiskUsageBreakdown", func() {
+		g.BeforeEach(func() {
+			rfs.reset()
+			g.Assert(os.MkdirAll(filepath.Join(rfs.root, "/server/worlds/nether"), 0o755)).IsNil()
+			g.Assert(os.MkdirAll(filepath.Join(rfs.root, "/server/plugins"), 0o755)).IsNil()
+			g.Assert(rfs.CreateServerFile("worlds/level.dat", make([]byte, 100))).IsNil()
+			g.Assert(rfs.CreateServerFile("worlds/nether/region.dat", make([]byte, 300))).IsNil()
+			g.Assert(rfs.CreateServerFile("plugins/plugin.jar", make([]byte, 50))).IsNil()
+			g.Assert(rfs.CreateServerFile("server.jar", make([]byte, 20))).IsNil()
+			fs.usage.results = nil
+		})
+
+		g.It("returns the size of each directory, largest first", func() {
+			usage, err := fs.DiskUsageBreakdown(context.Background(), "/")
+			g.Assert(err).IsNil()
+			g.Assert(usage.Directory).Equal("/")
+			g.Assert(usage.Directories).Equal([]DirectoryUsage{{Name: "worlds", Size: 400}, {Name: "plugins", Size: 50}})
+			g.Assert(usage.Files).Equal(int64(20))
+			g.Assert(usage.Incomplete).IsFalse()
+		})
+
+		g.It("breaks down a subdirectory", func() {
+			usage, err := fs.DiskUsageBreakdown(context.Background(), "/worlds")
+			g.Assert(err).IsNil()
+			g.Assert(usage.Directory).Equal("/worlds")
+			g.Assert(usage.Directories).Equal([]DirectoryUsage{{Name: "nether", Size: 300}})
+			g.Assert(usage.Files).Equal(int64(100))
+		})
+
+		g.It("caches the result", func() {
+			_, err := fs.DiskUsageBreakdown(context.Background(), "/")
+			g.Assert(err).IsNil()
+			g.Assert(rfs.CreateServerFile("plugins/other.jar", make([]byte, 500))).IsNil()
+
+			usage, err := fs.DiskUsageBreakdown(context.Background(), "/")
+			g.Assert(err).IsNil()
+			g.Assert(usage.Directories[1]).Equal(DirectoryUsage{Name: "plugins", Size: 50})
+		})
+
+		g.It("does not cache a scan that was stopped early", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			usage, err := fs.DiskUsageBreakdown(ctx, "/")
+			g.Assert(err).IsNil()
+			g.Assert(usage.Incomplete).IsTrue()
+			g.Assert(len(fs.usage.results)).Equal(0)
+		})
+
+		g.It("does not allow paths outside the server root", func() {
+			_, err := fs.DiskUsageBreakdown(context.Background(), "/../../")
+			g.Assert(err).IsNotNil()
+			g.Assert(IsErrorCode(err, ErrCodePathResolution)).IsTrue()
+		})
+
+		g.It("returns an error for a file", func() {
+			_, err := fs.DiskUsageBreakdown(context.Background(), "/server.jar")
+			g.Assert(IsErrorCode(err, ErrNotExist)).IsTrue()
+		})
+	})
+}
+
+func TestFilesystem_OnDiskUsageCalculated(t *testing.T) {
+	g := Goblin(t)
+	fs, rfs := NewFs()
+
+	g.Describe("OnDiskUsageCalculated", func() {
+		g.BeforeEach(func() {
+			rfs.reset()
+			g.Assert(rfs.CreateServerFile("server.jar", make([]byte, 20))).IsNil()
+		})
+
+		g.It("is called with the usage each time it is calculated", func() {
+			var calls []int64
+			var calculatedAt time.Time
+			fs.OnDiskUsageCalculated(func(size int64, at time.Time) {
+				calls = append(calls, size)
+				calculatedAt = at
+			})
+			g.Assert(fs.LastCalculated().IsZero()).IsTrue()
+
+			size, err := fs.updateCachedDiskUsage()
+			g.Assert(err).IsNil()
+			g.Assert(calls).Equal([]int64{size})
+			g.Assert(fs.LastCalculated()).Equal(calculatedAt)
+		})
+	})
+}
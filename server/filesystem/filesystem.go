@@ -30,6 +30,7 @@ type Filesystem struct {
 	diskUsed          int64
 	diskCheckInterval time.Duration
 	denylist          *ignore.GitIgnore
+	usage             diskUsageCache
 
 	// The maximum amount of disk space (in bytes) that this Filesystem instance can use.
 	diskLimit int64
@@ -37,6 +38,9 @@ type Filesystem struct {
 	// The root data directory path for this Filesystem instance.
 	root string
 
+	// Called each time the disk usage is calculated.
+	onDiskUsage func(size int64, at time.Time)
+
 	isTest bool
 }
 
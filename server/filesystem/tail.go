@@ -0,0 +1,145 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+
+	"emperror.dev/errors"
+)
+
+// The first two bytes of any gzip compressed file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// The size of the chunks read from the end of a file while looking for lines.
+const tailChunkSize = 8192
+
+// The longest line that will be returned when tailing a file, anything longer
+// than this causes an error.
+const tailMaxLineSize = 1024 * 1024
+
+// Tail returns up to the last n lines of the file at the given path. This is
+// intended for reading log files, so gzip compressed files (such as rotated
+// logs) are detected and decompressed transparently.
+//
+// Uncompressed files are read backwards from the end so that only the lines
+// being returned are read. Compressed files cannot be read from the end, so
+// they are decompressed as a stream and only the last n lines are kept in
+// memory while doing so.
+func (fs *Filesystem) Tail(p string, n int) ([]string, error) {
+	f, st, err := fs.File(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return tail(f, st, n)
+}
+
+// TailFile returns up to the last n lines of a file outside of any server's data
+// directory, such as a log file written by Wings itself. This works in the same
+// way as Filesystem.Tail, but the path is not checked in any way, so it must not
+// come from user input.
+func TailFile(name string, n int) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return tail(f, st, n)
+}
+
+// tail returns the last n lines of an open file.
+func tail(f *os.File, st os.FileInfo, n int) ([]string, error) {
+	// Reading from a named pipe would block until something writes to it.
+	if st.Mode()&os.ModeNamedPipe != 0 {
+		return nil, newFilesystemError(ErrCodeUnknownError, errors.New("filesystem: cannot tail a named pipe"))
+	}
+
+	if n <= 0 {
+		return []string{}, nil
+	}
+
+	magic := make([]byte, len(gzipMagic))
+	if _, err := f.ReadAt(magic, 0); err == nil && bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "filesystem: failed to open gzip file")
+		}
+		defer gz.Close()
+		return tailStream(gz, n)
+	}
+
+	return tailFile(f, st.Size(), n)
+}
+
+// tailStream reads every line from the reader and returns the last n of them.
+func tailStream(r io.Reader, n int) ([]string, error) {
+	lines := make([]string, 0, n)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), tailMaxLineSize)
+	for scanner.Scan() {
+		if len(lines) == n {
+			copy(lines, lines[1:])
+			lines = lines[:n-1]
+		}
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return lines, nil
+}
+
+// tailFile returns the last n lines of a file by reading it backwards from the
+// end until enough lines have been found.
+func tailFile(r io.ReaderAt, size int64, n int) ([]string, error) {
+	// A trailing newline at the end of the file does not begin a new line.
+	end := size
+	if size > 0 {
+		last := make([]byte, 1)
+		if _, err := r.ReadAt(last, size-1); err != nil && err != io.EOF {
+			return nil, errors.WithStack(err)
+		}
+		if last[0] == '\n' {
+			end = size - 1
+		}
+	}
+
+	buf := make([]byte, tailChunkSize)
+	offset := end
+	start := int64(0)
+	found := 0
+
+search:
+	for offset > 0 {
+		read := int64(tailChunkSize)
+		if offset < read {
+			read = offset
+		}
+		offset -= read
+		if _, err := r.ReadAt(buf[:read], offset); err != nil && err != io.EOF {
+			return nil, errors.WithStack(err)
+		}
+		for i := read - 1; i >= 0; i-- {
+			if buf[i] != '\n' {
+				continue
+			}
+			found++
+			if found == n {
+				start = offset + i + 1
+				break search
+			}
+		}
+	}
+
+	return tailStream(io.NewSectionReader(r, start, size-start), n)
+}
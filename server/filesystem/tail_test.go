@@ -0,0 +1,90 @@
+package filesystem
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+)
+
+func TestFilesystem_Tail(t *testing.T) {
+	g := Goblin(t)
+	fs, rfs := NewFs()
+
+	g.Describe("Tail", func() {
+		g.AfterEach(func() {
+			rfs.reset()
+		})
+
+		g.It("returns the last lines of a file", func() {
+			_ = rfs.CreateServerFileFromString("latest.log", "one\ntwo\nthree\nfour\n")
+
+			lines, err := fs.Tail("latest.log", 2)
+			g.Assert(err).IsNil()
+			g.Assert(lines).Equal([]string{"three", "four"})
+		})
+
+		g.It("returns the whole file when it has fewer lines", func() {
+			_ = rfs.CreateServerFileFromString("latest.log", "one\ntwo")
+
+			lines, err := fs.Tail("latest.log", 10)
+			g.Assert(err).IsNil()
+			g.Assert(lines).Equal([]string{"one", "two"})
+		})
+
+		g.It("reads lines spanning multiple chunks", func() {
+			var b strings.Builder
+			for i := 0; i < 5000; i++ {
+				b.WriteString(fmt.Sprintf("line %d\n", i))
+			}
+			_ = rfs.CreateServerFileFromString("latest.log", b.String())
+
+			lines, err := fs.Tail("latest.log", 3)
+			g.Assert(err).IsNil()
+			g.Assert(lines).Equal([]string{"line 4997", "line 4998", "line 4999"})
+		})
+
+		g.It("decompresses gzip files", func() {
+			var buf bytes.Buffer
+			w := gzip.NewWriter(&buf)
+			_, _ = w.Write([]byte("one\ntwo\nthree\n"))
+			_ = w.Close()
+			_ = rfs.CreateServerFile("2022-01-01-1.log.gz", buf.Bytes())
+
+			lines, err := fs.Tail("2022-01-01-1.log.gz", 2)
+			g.Assert(err).IsNil()
+			g.Assert(lines).Equal([]string{"two", "three"})
+		})
+
+		g.It("returns an error for a missing file", func() {
+			_, err := fs.Tail("missing.log", 2)
+			g.Assert(IsErrorCode(err, ErrNotExist)).IsTrue()
+		})
+	})
+}
+
+func TestTailFile(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("TailFile", func() {
+		g.It("returns the last lines of a file outside the data directory", func() {
+			p := filepath.Join(t.TempDir(), "install.log")
+			_ = os.WriteFile(p, []byte("one\ntwo\nthree\n"), 0o600)
+
+			lines, err := TailFile(p, 2)
+			g.Assert(err).IsNil()
+			g.Assert(lines).Equal([]string{"two", "three"})
+		})
+
+		g.It("returns an error for a missing file", func() {
+			_, err := TailFile(filepath.Join(t.TempDir(), "missing.log"), 2)
+			g.Assert(errors.Is(err, os.ErrNotExist)).IsTrue()
+		})
+	})
+}
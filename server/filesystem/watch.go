@@ -0,0 +1,44 @@
+package filesystem
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+)
+
+// The largest number of directories that will be watched for a single call to
+// Watch. Every directory uses an inotify watch, which are limited per user on
+// the host, so very large servers only have their upper directories watched.
+const maxWatchedDirectories = 4096
+
+var ErrWatchUnsupported = errors.New("filesystem: watching for changes is not supported on this platform")
+
+// ChangeOp is the type of change made to a file.
+type ChangeOp string
+
+const (
+	ChangeCreated  ChangeOp = "created"
+	ChangeModified ChangeOp = "modified"
+	ChangeDeleted  ChangeOp = "deleted"
+)
+
+// Change is a single change made to a file or directory within the server root.
+type Change struct {
+	// The path of the file relative to the server root.
+	Path string   `json:"path"`
+	Op   ChangeOp `json:"op"`
+}
+
+// Watch streams the changes made to files within the given directory, and every
+// directory beneath it, until the context is cancelled. The directory must be
+// within the server root and the returned paths are relative to the root.
+//
+// The returned channel is closed once the context is cancelled or an error is
+// encountered while watching.
+func (fs *Filesystem) Watch(ctx context.Context, dir string) (<-chan Change, error) {
+	p, err := fs.SafePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	return watch(ctx, fs.root, p)
+}
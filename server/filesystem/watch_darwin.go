@@ -0,0 +1,9 @@
+package filesystem
+
+import (
+	"context"
+)
+
+func watch(_ context.Context, _, _ string) (<-chan Change, error) {
+	return nil, ErrWatchUnsupported
+}
@@ -0,0 +1,143 @@
+package filesystem
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"emperror.dev/errors"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DONT_FOLLOW | syscall.IN_ONLYDIR
+
+// Stops walking the directory tree once too many directories are being watched.
+var errWatchLimit = errors.Sentinel("filesystem: watch limit reached")
+
+// inotifyWatcher watches a tree of directories using inotify.
+type inotifyWatcher struct {
+	fd   int
+	f    *os.File
+	root string
+	// The directory being watched for each watch descriptor.
+	dirs map[int]string
+}
+
+// watch uses inotify to watch the directory at p, and every directory beneath
+// it, for changes.
+func watch(ctx context.Context, root, p string) (<-chan Change, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, errors.Wrap(err, "filesystem: failed to initialize inotify")
+	}
+	// The file descriptor is non-blocking, so reads go through the runtime poller
+	// and are interrupted when the file is closed.
+	w := &inotifyWatcher{fd: fd, f: os.NewFile(uintptr(fd), "inotify"), root: root, dirs: make(map[int]string)}
+	if err := w.addTree(p); err != nil {
+		_ = w.f.Close()
+		return nil, err
+	}
+
+	out := make(chan Change, 64)
+	go func() {
+		<-ctx.Done()
+		_ = w.f.Close()
+	}()
+	go w.run(ctx, out)
+
+	return out, nil
+}
+
+// addTree adds a watch for the directory and every directory beneath it. Symlinks
+// are never followed.
+func (w *inotifyWatcher) addTree(p string) error {
+	err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// The directory might have been removed since it was found, which is fine.
+			if path != p {
+				return nil
+			}
+			return errors.WithStack(err)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if len(w.dirs) >= maxWatchedDirectories {
+			return errWatchLimit
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+		if err != nil {
+			if path == p {
+				return errors.Wrap(err, "filesystem: failed to watch directory")
+			}
+			return filepath.SkipDir
+		}
+		w.dirs[wd] = path
+		return nil
+	})
+	if errors.Is(err, errWatchLimit) {
+		return nil
+	}
+	return err
+}
+
+// run reads events from inotify and sends them to the channel until the file is
+// closed.
+func (w *inotifyWatcher) run(ctx context.Context, out chan<- Change) {
+	defer close(out)
+
+	buf := make([]byte, (syscall.SizeofInotifyEvent+syscall.NAME_MAX+1)*16)
+	for {
+		n, err := w.f.Read(buf)
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			name := strings.TrimRight(string(buf[offset+syscall.SizeofInotifyEvent:offset+syscall.SizeofInotifyEvent+int(ev.Len)]), "\x00")
+			offset += syscall.SizeofInotifyEvent + int(ev.Len)
+
+			dir, ok := w.dirs[int(ev.Wd)]
+			if !ok {
+				continue
+			}
+			if ev.Mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, int(ev.Wd))
+				continue
+			}
+			if name == "" {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+			var op ChangeOp
+			switch {
+			case ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+				op = ChangeCreated
+				if ev.Mask&syscall.IN_ISDIR != 0 {
+					_ = w.addTree(path)
+				}
+			case ev.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+				op = ChangeDeleted
+			case ev.Mask&syscall.IN_MODIFY != 0:
+				op = ChangeModified
+			default:
+				continue
+			}
+
+			rel, err := filepath.Rel(w.root, path)
+			if err != nil {
+				continue
+			}
+			select {
+			case out <- Change{Path: filepath.ToSlash(rel), Op: op}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package filesystem
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestFilesystem_Watch(t *testing.T) {
+	g := Goblin(t)
+	fs, rfs := NewFs()
+
+	g.Describe("Watch", func() {
+		g.AfterEach(func() {
+			rfs.reset()
+		})
+
+		next := func(changes <-chan Change) Change {
+			select {
+			case c := <-changes:
+				return c
+			case <-time.After(time.Second * 2):
+				return Change{}
+			}
+		}
+
+		g.It("streams changes to files", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			changes, err := fs.Watch(ctx, "/")
+			g.Assert(err).IsNil()
+
+			_ = rfs.CreateServerFileFromString("server.properties", "motd=hello")
+			g.Assert(next(changes)).Equal(Change{Path: "server.properties", Op: ChangeCreated})
+			g.Assert(next(changes)).Equal(Change{Path: "server.properties", Op: ChangeModified})
+
+			_ = os.Remove(filepath.Join(rfs.root, "/server/server.properties"))
+			g.Assert(next(changes)).Equal(Change{Path: "server.properties", Op: ChangeDeleted})
+		})
+
+		g.It("watches directories created after it started", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			changes, err := fs.Watch(ctx, "/")
+			g.Assert(err).IsNil()
+
+			_ = os.Mkdir(filepath.Join(rfs.root, "/server/config"), 0o755)
+			g.Assert(next(changes)).Equal(Change{Path: "config", Op: ChangeCreated})
+
+			_ = rfs.CreateServerFileFromString("config/server.yml", "")
+			g.Assert(next(changes)).Equal(Change{Path: "config/server.yml", Op: ChangeCreated})
+		})
+
+		g.It("closes the channel when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			changes, err := fs.Watch(ctx, "/")
+			g.Assert(err).IsNil()
+
+			cancel()
+			select {
+			case _, ok := <-changes:
+				g.Assert(ok).IsFalse()
+			case <-time.After(time.Second * 2):
+				g.Fail("channel was not closed")
+			}
+		})
+
+		g.It("cannot watch outside the server root", func() {
+			_, err := fs.Watch(context.Background(), "../../etc")
+			g.Assert(IsErrorCode(err, ErrCodePathResolution)).IsTrue()
+		})
+	})
+}
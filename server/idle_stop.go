@@ -0,0 +1,165 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+// The network traffic in bytes per second below which a server is considered
+// idle when the server does not set its own threshold. This allows for the
+// small amount of traffic most game servers send even with no one connected.
+const defaultIdleThreshold = 1024
+
+// IdleStopConfiguration stops a running server once it has been idle, which is
+// when it has been sending and receiving less network traffic than the
+// threshold, for longer than the timeout.
+type IdleStopConfiguration struct {
+	// The number of seconds a running server can be idle before it is stopped.
+	// Set to 0 to never stop the server for being idle.
+	Timeout int `json:"timeout"`
+
+	// The network traffic in bytes per second, sent and received combined, below
+	// which the server is considered idle. The default is used when set to 0.
+	Threshold int64 `json:"threshold"`
+}
+
+// IdleStatus is how long a server has left before it is stopped for being idle.
+type IdleStatus struct {
+	Enabled bool `json:"enabled"`
+	Idle    bool `json:"idle"`
+	// The number of seconds the server can be idle for before it is stopped.
+	Timeout int `json:"timeout"`
+	// When the server became idle, only set while it is idle.
+	IdleSince *time.Time `json:"idle_since,omitempty"`
+	// The number of seconds until the server is stopped if it remains idle. This
+	// is the full timeout while the server is active.
+	Remaining int `json:"remaining"`
+}
+
+// idleTracker tracks how long a running server has been idle for, based on the
+// change in its network traffic between each stats sample.
+type idleTracker struct {
+	mu      sync.Mutex
+	since   time.Time
+	sampled time.Time
+	traffic uint64
+	stopped bool
+}
+
+// sample records the total network traffic of the server at the given time and
+// returns how long the server has been idle for, which is zero if it is active.
+// The first sample after the tracker is reset only sets the baseline traffic.
+func (t *idleTracker) sample(now time.Time, traffic uint64, threshold int64) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.sampled.IsZero() {
+		elapsed := now.Sub(t.sampled).Seconds()
+		// The counters go back to zero when the container is restarted.
+		var delta uint64
+		if traffic > t.traffic {
+			delta = traffic - t.traffic
+		}
+		if elapsed > 0 && float64(delta)/elapsed < float64(threshold) {
+			if t.since.IsZero() {
+				t.since = now
+			}
+		} else {
+			t.since = time.Time{}
+			t.stopped = false
+		}
+	}
+	t.sampled = now
+	t.traffic = traffic
+
+	if t.since.IsZero() {
+		return 0
+	}
+	return now.Sub(t.since)
+}
+
+// status returns the idle status of the server at the given time.
+func (t *idleTracker) status(now time.Time, timeout int) IdleStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st := IdleStatus{Enabled: timeout > 0, Timeout: timeout, Remaining: timeout}
+	if !st.Enabled {
+		st.Remaining = 0
+		return st
+	}
+	if !t.since.IsZero() {
+		since := t.since
+		st.Idle = true
+		st.IdleSince = &since
+		if st.Remaining = timeout - int(now.Sub(since).Seconds()); st.Remaining < 0 {
+			st.Remaining = 0
+		}
+	}
+	return st
+}
+
+// markStopped returns true the first time it is called while the server is
+// idle, so that a server is only stopped once each time it becomes idle.
+func (t *idleTracker) markStopped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return false
+	}
+	t.stopped = true
+	return true
+}
+
+func (t *idleTracker) reset() {
+	t.mu.Lock()
+	t.since = time.Time{}
+	t.sampled = time.Time{}
+	t.traffic = 0
+	t.stopped = false
+	t.mu.Unlock()
+}
+
+// IdleStatus returns how long the server has left before it is stopped for being
+// idle, and if that is enabled for the server. The idle time is reset whenever
+// the server changes state, so a server that is not running is never idle.
+func (s *Server) IdleStatus() IdleStatus {
+	return s.idle.status(time.Now(), s.Config().IdleStop.Timeout)
+}
+
+// checkIdle records the network traffic of the server from its latest stats and
+// stops the server if it has been idle for longer than its timeout.
+func (s *Server) checkIdle(network environment.NetworkStats) {
+	cfg := s.Config().IdleStop
+	if cfg.Timeout <= 0 || s.Environment.State() != environment.ProcessRunningState {
+		return
+	}
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = defaultIdleThreshold
+	}
+
+	idle := s.idle.sample(time.Now(), network.RxBytes+network.TxBytes, threshold)
+	if idle < time.Duration(cfg.Timeout)*time.Second || !s.idle.markStopped() {
+		return
+	}
+
+	s.Log().WithField("timeout", cfg.Timeout).Info("stopping server after it was idle for longer than its timeout")
+	s.Events().Publish(IdleStoppedEvent, strconv.Itoa(cfg.Timeout))
+	s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Server has been idle for %d seconds, stopping the server.", cfg.Timeout))
+	go func() {
+		if err := s.HandlePowerAction(PowerActionStop); err != nil {
+			s.Log().WithField("error", err).Error("failed to stop server after being idle")
+		}
+	}()
+}
+
+// resetIdle starts tracking the idle time of the server from scratch, this is
+// done whenever the server changes state.
+func (s *Server) resetIdle() {
+	s.idle.reset()
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestIdleTracker(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("idleTracker", func() {
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		g.It("counts the time the traffic stays below the threshold", func() {
+			var tr idleTracker
+			g.Assert(tr.sample(start, 1000, 100)).Equal(time.Duration(0))
+			g.Assert(tr.sample(start.Add(time.Second), 1050, 100)).Equal(time.Duration(0))
+			g.Assert(tr.sample(start.Add(time.Minute), 1100, 100)).Equal(time.Minute - time.Second)
+
+			st := tr.status(start.Add(time.Minute), 300)
+			g.Assert(st.Enabled).IsTrue()
+			g.Assert(st.Idle).IsTrue()
+			g.Assert(st.IdleSince.Equal(start.Add(time.Second))).IsTrue()
+			g.Assert(st.Remaining).Equal(241)
+		})
+
+		g.It("resets once the server is active again", func() {
+			var tr idleTracker
+			tr.sample(start, 0, 100)
+			tr.sample(start.Add(time.Second), 0, 100)
+			g.Assert(tr.markStopped()).IsTrue()
+			g.Assert(tr.markStopped()).IsFalse()
+
+			g.Assert(tr.sample(start.Add(time.Second*2), 1000, 100)).Equal(time.Duration(0))
+			g.Assert(tr.markStopped()).IsTrue()
+
+			st := tr.status(start.Add(time.Second*2), 300)
+			g.Assert(st.Idle).IsFalse()
+			g.Assert(st.Remaining).Equal(300)
+		})
+
+		g.It("reports that idle stop is disabled without a timeout", func() {
+			var tr idleTracker
+			st := tr.status(start, 0)
+			g.Assert(st.Enabled).IsFalse()
+			g.Assert(st.Remaining).Equal(0)
+		})
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/environment"
 	"github.com/pterodactyl/wings/remote"
+	"github.com/pterodactyl/wings/server/filesystem"
 	"github.com/pterodactyl/wings/system"
 )
 
@@ -190,6 +191,12 @@ func (ip *InstallationProcess) Run() error {
 		ip.Server.installing.Store(false)
 	}()
 
+	// Remove the log from the last installation so that it isn't mistaken for the
+	// log of this one while it is still running.
+	if err := os.Remove(ip.GetLogPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		ip.Server.Log().WithField("error", err).Warn("failed to remove previous installation log")
+	}
+
 	if err := ip.BeforeExecute(); err != nil {
 		return err
 	}
@@ -335,7 +342,28 @@ func (ip *InstallationProcess) BeforeExecute() error {
 
 // GetLogPath returns the log path for the installation process.
 func (ip *InstallationProcess) GetLogPath() string {
-	return filepath.Join(config.Get().System.LogDirectory, "/install", ip.Server.ID()+".log")
+	return ip.Server.installLogPath()
+}
+
+// installLogPath returns the path the log of the last installation process for
+// the server is written to.
+func (s *Server) installLogPath() string {
+	return filepath.Join(config.Get().System.LogDirectory, "/install", s.ID()+".log")
+}
+
+// InstallLog returns up to the last n lines of the log from the last time the
+// server was installed. The log is written once the installation process is
+// completed, so this returns nothing while the server is installing, or if the
+// server has not been installed since this node started keeping install logs.
+func (s *Server) InstallLog(n int) ([]string, error) {
+	lines, err := filesystem.TailFile(s.installLogPath(), n)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	return lines, nil
 }
 
 // AfterExecute cleans up after the execution of the installation process.
@@ -421,7 +449,7 @@ func (ip *InstallationProcess) Execute() (string, error) {
 		Env:          ip.Server.GetEnvironmentVariables(),
 		Labels: map[string]string{
 			"Service":       "Pterodactyl",
-			"ContainerType": "server_installer",
+			"ContainerType": environment.ContainerTypeInstaller,
 		},
 	}
 
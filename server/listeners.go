@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"fmt"
 	"regexp"
 	"strconv"
 	"sync"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/apex/log"
 
+	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/events"
 	"github.com/pterodactyl/wings/system"
 
@@ -20,6 +22,8 @@ var dockerEvents = []string{
 	environment.DockerImagePullStatus,
 	environment.DockerImagePullStarted,
 	environment.DockerImagePullCompleted,
+	environment.DockerImagePullProgress,
+	environment.DockerImagePullFailed,
 }
 
 type diskSpaceLimiter struct {
@@ -47,6 +51,8 @@ func (dsl *diskSpaceLimiter) Reset() {
 func (dsl *diskSpaceLimiter) Trigger() {
 	dsl.o.Do(func() {
 		dsl.server.PublishConsoleOutputFromDaemon("Server is exceeding the assigned disk space limit, stopping process now.")
+		dsl.server.stopReason.Store(OfflineReasonDiskLimit)
+		dsl.server.publishResourceLimit(ResourceDisk, ResourceLimitStopped, dsl.server.Filesystem().CachedUsage(), dsl.server.DiskSpace())
 		if err := dsl.server.Environment.WaitForStop(dsl.server.Context(), time.Minute, true); err != nil {
 			dsl.server.Log().WithField("error", err).Error("failed to stop server after exceeding space limit!")
 		}
@@ -58,11 +64,12 @@ func (dsl *diskSpaceLimiter) Trigger() {
 // does not cause negative effects to the system. This will also monitor the
 // output lines to determine if the server is started yet, and if the output is
 // not being throttled, will send the data over to the websocket.
-func (s *Server) processConsoleOutputEvent(v []byte) {
+func (s *Server) processConsoleOutputEvent(v []byte, stream environment.OutputStream) {
 	// Always process the console output, but do this in a seperate thread since we
 	// don't really care about side-effects from this call, and don't want it to block
 	// the console sending logic.
 	go s.onConsoleOutput(v)
+	s.resetOutputWatchdog()
 
 	// If the console is being throttled, do nothing else with it, we don't want
 	// to waste time. This code previously terminated server instances after violating
@@ -75,6 +82,12 @@ func (s *Server) processConsoleOutputEvent(v []byte) {
 		return
 	}
 
+	v = MaskSecrets(v)
+	s.ConsoleBacklog().Push(v)
+	if stream == environment.Stderr {
+		s.Sink(system.ErrorSink).Push(v)
+		return
+	}
 	s.Sink(system.LogSink).Push(v)
 }
 
@@ -115,8 +128,30 @@ func (s *Server) StartEventListeners() {
 							if !s.Filesystem().HasSpaceAvailable(true) {
 								limit.Trigger()
 							}
+							s.recordStats()
+							s.checkIdle(stats.Data.Network)
 							s.Events().Publish(StatsEvent, s.Proc())
 						}
+					case environment.ZombieProcessEvent:
+						{
+							count, ok := e.Data.(float64)
+							if !ok {
+								return
+							}
+							s.resources.SetZombieProcesses(int(count))
+							if threshold := config.Get().System.ZombieDetection.Threshold; threshold > 0 && int(count) >= threshold {
+								s.Log().WithField("zombie_processes", int(count)).Warn("server container has exceeded the zombie process threshold")
+								s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Detected %d zombie processes in the server container, a restart may be required.", int(count)))
+							}
+						}
+					case environment.HealthChangeEvent:
+						{
+							if s.Environment.State() != environment.ProcessOfflineState {
+								s.publishStatus(s.StatusArgs())
+							}
+						}
+					case environment.KillFailedEvent:
+						s.PublishConsoleOutputFromDaemon("Failed to kill the server process, it is still running.")
 					case environment.StateChangeEvent:
 						{
 							// Reset the throttler when the process is started.
@@ -132,6 +167,10 @@ func (s *Server) StartEventListeners() {
 						s.PublishConsoleOutputFromDaemon("Pulling Docker container image, this could take a few minutes to complete...")
 					case environment.DockerImagePullCompleted:
 						s.PublishConsoleOutputFromDaemon("Finished pulling Docker container image")
+					case environment.DockerImagePullProgress:
+						s.Events().Publish(ImagePullProgressEvent, e.Data)
+					case environment.DockerImagePullFailed:
+						s.Events().Publish(ImagePullFailedEvent, e.Data)
 					default:
 					}
 				}(v, limit)
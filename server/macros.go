@@ -0,0 +1,30 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/apex/log"
+)
+
+// Macro returns the commands for the console macro with the given name, and
+// false if the server has no macro with that name.
+func (s *Server) Macro(name string) ([]string, bool) {
+	s.cfg.mu.RLock()
+	defer s.cfg.mu.RUnlock()
+	commands, ok := s.cfg.Macros[strings.TrimSpace(name)]
+	if !ok || len(commands) == 0 {
+		return nil, false
+	}
+	return append([]string{}, commands...), true
+}
+
+// SendCommands sends each of the commands to the server process in order. A
+// command that fails to send is logged and skipped so that the rest are still
+// sent.
+func (s *Server) SendCommands(commands []string) {
+	for _, command := range commands {
+		if err := s.Environment.SendCommand(command); err != nil {
+			s.Log().WithFields(log.Fields{"command": command, "error": err}).Warn("failed to send command to server instance")
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+)
+
+func TestServer_Macro(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#Macro", func() {
+		s := &Server{}
+		s.cfg.Macros = map[string][]string{
+			"backup": {"save-off", "save-all", "save-on"},
+			"empty":  {},
+		}
+
+		g.It("returns the commands for a macro", func() {
+			commands, ok := s.Macro("backup")
+			g.Assert(ok).IsTrue()
+			g.Assert(commands).Equal([]string{"save-off", "save-all", "save-on"})
+		})
+
+		g.It("ignores surrounding whitespace", func() {
+			_, ok := s.Macro("  backup ")
+			g.Assert(ok).IsTrue()
+		})
+
+		g.It("does not match unknown or empty macros", func() {
+			_, ok := s.Macro("say hello")
+			g.Assert(ok).IsFalse()
+
+			_, ok = s.Macro("empty")
+			g.Assert(ok).IsFalse()
+		})
+	})
+}
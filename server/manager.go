@@ -14,6 +14,7 @@ import (
 	"github.com/apex/log"
 	"github.com/gammazero/workerpool"
 	"github.com/goccy/go-json"
+	"github.com/gorilla/websocket"
 
 	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/environment"
@@ -26,6 +27,10 @@ type Manager struct {
 	mu      sync.RWMutex
 	client  remote.Client
 	servers []*Server
+
+	// Tracks the node being drained of websocket connections.
+	drainMu sync.Mutex
+	drain   *drain
 }
 
 // NewManager returns a new server manager instance. This will boot up all the
@@ -160,6 +165,99 @@ func (m *Manager) PersistStates() error {
 	return nil
 }
 
+// Shutdown applies the configured shutdown behavior to every server on the node
+// and then disconnects all of their websocket clients. This blocks until all
+// servers have been handled.
+func (m *Manager) Shutdown() {
+	behavior := config.Get().System.ShutdownBehavior
+	log.WithField("behavior", behavior).Info("applying shutdown behavior to servers")
+
+	var action PowerAction
+	switch behavior {
+	case config.ShutdownGracefulStop:
+		action = PowerActionStop
+	case config.ShutdownKill:
+		action = PowerActionTerminate
+	case config.ShutdownKeepRunning:
+	default:
+		log.WithField("behavior", behavior).Warn("unknown shutdown behavior configured, leaving servers running")
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range m.All() {
+		wg.Add(1)
+		go func(s *Server) {
+			defer wg.Done()
+			if action != "" && s.Environment.State() != environment.ProcessOfflineState {
+				// Wait for any in-progress power action rather than failing out, this
+				// is the last chance to stop the server.
+				if err := s.HandlePowerAction(action, 30); err != nil {
+					s.Log().WithField("error", err).Warn("failed to stop server during shutdown")
+				}
+			}
+			s.Websockets().CloseAll(websocket.CloseServiceRestart, NewCloseReason(CloseReasonShutdown, "wings is shutting down").String())
+		}(s)
+	}
+	wg.Wait()
+}
+
+// SetBackendAvailable notifies every server on the node that the Docker daemon
+// has become unreachable, or that it can be reached again. This is passed along
+// to any connected websocket clients so that they can show why nothing works.
+func (m *Manager) SetBackendAvailable(available bool) {
+	status := BackendAvailable
+	if !available {
+		status = BackendUnavailable
+	}
+	for _, s := range m.All() {
+		s.Events().Publish(BackendStatusEvent, status)
+	}
+}
+
+// OrphanedContainer is a container created by Wings that is no longer needed.
+type OrphanedContainer struct {
+	environment.ManagedContainer
+	Server string `json:"server"`
+	Reason string `json:"reason"`
+}
+
+// The reasons a container can be reported as orphaned.
+const (
+	// OrphanReasonUnknownServer is used for containers belonging to a server that
+	// is not on this node, such as one that was deleted while Wings was offline.
+	OrphanReasonUnknownServer = "unknown_server"
+	// OrphanReasonStaleInstaller is used for installer containers left behind by
+	// an installation that is no longer running.
+	OrphanReasonStaleInstaller = "stale_installer"
+)
+
+// OrphanedContainers returns the containers in Docker that were created by
+// Wings, but do not belong to any of the servers on this node or are left over
+// from an installation that is not running. Nothing is removed, this is only to
+// help operators clean up.
+func (m *Manager) OrphanedContainers(ctx context.Context) ([]OrphanedContainer, error) {
+	containers, err := environment.ListManagedContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.findOrphans(containers), nil
+}
+
+func (m *Manager) findOrphans(containers []environment.ManagedContainer) []OrphanedContainer {
+	out := []OrphanedContainer{}
+	for _, c := range containers {
+		id := c.ServerUuid()
+		s, ok := m.Get(id)
+		switch {
+		case !ok:
+			out = append(out, OrphanedContainer{ManagedContainer: c, Server: id, Reason: OrphanReasonUnknownServer})
+		case c.Type == environment.ContainerTypeInstaller && !s.IsInstalling():
+			out = append(out, OrphanedContainer{ManagedContainer: c, Server: id, Reason: OrphanReasonStaleInstaller})
+		}
+	}
+	return out
+}
+
 // ReadStates returns the state of the servers.
 func (m *Manager) ReadStates() (map[string]string, error) {
 	f, err := os.OpenFile(config.Get().System.GetStatesPath(), os.O_RDONLY|os.O_CREATE, 0o644)
@@ -197,6 +295,7 @@ func (m *Manager) InitServer(data remote.ServerConfigurationResponse) (*Server,
 	}
 
 	s.fs = filesystem.New(filepath.Join(config.Get().System.Data, s.ID()), s.DiskSpace(), s.Config().Egg.FileDenylist)
+	s.fs.OnDiskUsageCalculated(s.publishDiskUsageCalculated)
 
 	// Right now we only support a Docker based environment, so I'm going to hard code
 	// this logic in. When we're ready to support other environment we'll need to make
@@ -220,6 +319,10 @@ func (m *Manager) InitServer(data remote.ServerConfigurationResponse) (*Server,
 		s.StartEventListeners()
 	}
 
+	// Load the recent commands for the server now, if the audit log is persisted
+	// this reads back the commands sent before Wings was restarted.
+	s.CommandAudit()
+
 	// If the server's data directory exists, force disk usage calculation.
 	if _, err := os.Stat(s.Filesystem().Path()); err == nil {
 		s.Filesystem().HasSpaceAvailable(true)
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/system"
+)
+
+func TestManager_FindOrphans(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Manager#findOrphans", func() {
+		newServer := func(uuid string, installing bool) *Server {
+			s := &Server{installing: system.NewAtomicBool(installing)}
+			s.cfg.Uuid = uuid
+			return s
+		}
+
+		g.It("reports containers that do not belong to a server on the node", func() {
+			m := NewEmptyManager(nil)
+			m.Add(newServer("known", false))
+
+			orphans := m.findOrphans([]environment.ManagedContainer{
+				{ID: "1", Name: "known", Type: environment.ContainerTypeServer},
+				{ID: "2", Name: "deleted", Type: environment.ContainerTypeServer},
+				{ID: "3", Name: "deleted_installer", Type: environment.ContainerTypeInstaller},
+			})
+			g.Assert(len(orphans)).Equal(2)
+			g.Assert(orphans[0].ID).Equal("2")
+			g.Assert(orphans[0].Server).Equal("deleted")
+			g.Assert(orphans[0].Reason).Equal(OrphanReasonUnknownServer)
+			g.Assert(orphans[1].Server).Equal("deleted")
+		})
+
+		g.It("reports installer containers only when the server is not installing", func() {
+			m := NewEmptyManager(nil)
+			m.Add(newServer("idle", false))
+			m.Add(newServer("installing", true))
+
+			orphans := m.findOrphans([]environment.ManagedContainer{
+				{ID: "1", Name: "idle_installer", Type: environment.ContainerTypeInstaller},
+				{ID: "2", Name: "installing_installer", Type: environment.ContainerTypeInstaller},
+			})
+			g.Assert(len(orphans)).Equal(1)
+			g.Assert(orphans[0].ID).Equal("1")
+			g.Assert(orphans[0].Reason).Equal(OrphanReasonStaleInstaller)
+		})
+	})
+}
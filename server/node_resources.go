@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// How long the memory and disk usage read from the host are reused for before
+// they are read again.
+const hostMetricsTTL = time.Second * 5
+
+// NodeResource is the availability of a single resource on the node. Total and
+// Used are read from the host, Allocated is the sum of the limits of every
+// server on the node, and can be larger than the total if the node is
+// overallocated.
+type NodeResource struct {
+	Total     int64 `json:"total"`
+	Allocated int64 `json:"allocated"`
+	Used      int64 `json:"used"`
+	Free      int64 `json:"free"`
+	// The number of servers that have no limit set for the resource, and so are
+	// not included in the allocated amount.
+	Unlimited int `json:"unlimited"`
+}
+
+// NodeResources is the availability of resources across the whole node. Memory
+// and disk are in bytes, while CPU is a percentage where each thread on the node
+// is 100%, the same as the CPU limit of a server.
+type NodeResources struct {
+	Memory NodeResource `json:"memory"`
+	Cpu    NodeResource `json:"cpu"`
+	Disk   NodeResource `json:"disk"`
+}
+
+type hostMetrics struct {
+	memoryTotal     int64
+	memoryAvailable int64
+	diskTotal       int64
+	diskFree        int64
+	diskAvailable   int64
+}
+
+var hostMetricsCache struct {
+	mu        sync.Mutex
+	metrics   hostMetrics
+	collected time.Time
+}
+
+// NodeResources returns the total, allocated and used resources on the node.
+// The host memory and disk usage are cached for a few seconds so that this is
+// cheap to call often, the CPU usage is the sum of the most recent stats for
+// every server.
+func (m *Manager) NodeResources() (NodeResources, error) {
+	host, err := readHostMetrics()
+	if err != nil {
+		return NodeResources{}, err
+	}
+
+	res := NodeResources{
+		Memory: NodeResource{
+			Total: host.memoryTotal,
+			Used:  host.memoryTotal - host.memoryAvailable,
+			Free:  host.memoryAvailable,
+		},
+		Cpu: NodeResource{Total: int64(runtime.NumCPU()) * 100},
+		Disk: NodeResource{
+			Total: host.diskTotal,
+			Used:  host.diskTotal - host.diskFree,
+			Free:  host.diskAvailable,
+		},
+	}
+
+	var cpuUsed float64
+	for _, s := range m.All() {
+		build := s.Config().Build
+		allocate(&res.Memory, build.MemoryLimit*1024*1024)
+		allocate(&res.Cpu, build.CpuLimit)
+		allocate(&res.Disk, build.DiskSpace*1024*1024)
+		cpuUsed += s.resources.Snapshot().CpuAbsolute
+	}
+	res.Cpu.Used = int64(cpuUsed)
+	if res.Cpu.Free = res.Cpu.Total - res.Cpu.Used; res.Cpu.Free < 0 {
+		res.Cpu.Free = 0
+	}
+
+	return res, nil
+}
+
+func allocate(r *NodeResource, limit int64) {
+	if limit <= 0 {
+		r.Unlimited++
+		return
+	}
+	r.Allocated += limit
+}
+
+// readHostMetrics returns the memory and disk usage of the host, reading them
+// again only if the cached values are too old.
+func readHostMetrics() (hostMetrics, error) {
+	hostMetricsCache.mu.Lock()
+	defer hostMetricsCache.mu.Unlock()
+
+	if time.Since(hostMetricsCache.collected) < hostMetricsTTL {
+		return hostMetricsCache.metrics, nil
+	}
+
+	var h hostMetrics
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return h, errors.Wrap(err, "server: failed to read host memory")
+	}
+	h.memoryTotal, h.memoryAvailable, err = parseMeminfo(f)
+	f.Close()
+	if err != nil {
+		return h, err
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(config.Get().System.Data, &st); err != nil {
+		return h, errors.Wrap(err, "server: failed to read host disk usage")
+	}
+	h.diskTotal = int64(st.Blocks) * int64(st.Bsize)
+	h.diskFree = int64(st.Bfree) * int64(st.Bsize)
+	h.diskAvailable = int64(st.Bavail) * int64(st.Bsize)
+
+	hostMetricsCache.metrics = h
+	hostMetricsCache.collected = time.Now()
+	return h, nil
+}
+
+// parseMeminfo returns the total and available memory in bytes from the
+// contents of /proc/meminfo.
+func parseMeminfo(r io.Reader) (total int64, available int64, err error) {
+	var foundTotal, foundAvailable bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		var dst *int64
+		switch fields[0] {
+		case "MemTotal:":
+			dst, foundTotal = &total, true
+		case "MemAvailable:":
+			dst, foundAvailable = &available, true
+		default:
+			continue
+		}
+		v, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "server: failed to parse host memory")
+		}
+		// The values are always reported in kibibytes.
+		*dst = v * 1024
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, errors.Wrap(err, "server: failed to read host memory")
+	}
+	if !foundTotal || !foundAvailable {
+		return 0, 0, errors.New("server: host memory is missing from /proc/meminfo")
+	}
+	return total, available, nil
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/franela/goblin"
+)
+
+func TestNodeResources(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("parseMeminfo", func() {
+		g.It("returns the total and available memory in bytes", func() {
+			total, available, err := parseMeminfo(strings.NewReader("MemTotal:       16384 kB\nMemFree:         1024 kB\nMemAvailable:    8192 kB\n"))
+			g.Assert(err).IsNil()
+			g.Assert(total).Equal(int64(16384 * 1024))
+			g.Assert(available).Equal(int64(8192 * 1024))
+		})
+
+		g.It("returns an error if the available memory is missing", func() {
+			_, _, err := parseMeminfo(strings.NewReader("MemTotal:       16384 kB\n"))
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+
+	g.Describe("allocate", func() {
+		g.It("sums the limits and counts servers without one", func() {
+			var r NodeResource
+			allocate(&r, 1024)
+			allocate(&r, 0)
+			allocate(&r, 2048)
+			g.Assert(r.Allocated).Equal(int64(3072))
+			g.Assert(r.Unlimited).Equal(1)
+		})
+	})
+}
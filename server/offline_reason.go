@@ -0,0 +1,78 @@
+package server
+
+import (
+	"github.com/pterodactyl/wings/environment"
+)
+
+// The reasons a server can enter the offline state, sent along with the status
+// event when a server goes offline.
+const (
+	// OfflineReasonStopped is used when the server was stopped by Wings, either
+	// from a user request or automatically.
+	OfflineReasonStopped = "stopped"
+	// OfflineReasonKilled is used when the server process was killed by Wings.
+	OfflineReasonKilled = "killed"
+	// OfflineReasonCrashed is used when the server process exited on its own with
+	// a non-zero exit code.
+	OfflineReasonCrashed = "crashed"
+	// OfflineReasonOOM is used when the server process was killed for running out
+	// of memory.
+	OfflineReasonOOM = "oom"
+	// OfflineReasonDiskLimit is used when Wings stopped the server because it was
+	// using more disk space than it is allowed.
+	OfflineReasonDiskLimit = "disk_limit"
+	// OfflineReasonStartupTimeout is used when Wings killed the server because it
+	// did not finish starting within its startup timeout.
+	OfflineReasonStartupTimeout = "startup_timeout"
+	// OfflineReasonExited is used when the server process exited cleanly without
+	// Wings being asked to stop it.
+	OfflineReasonExited = "exited"
+)
+
+// OfflineReason returns the reason the server last entered the offline state. If
+// the server has not gone offline since Wings booted this is an empty string.
+func (s *Server) OfflineReason() string {
+	return s.offlineReason.Load()
+}
+
+// setStopReason records the reason Wings is about to stop the server, this is
+// used as the offline reason once the server stops.
+func (s *Server) setStopReason(action PowerAction) {
+	switch action {
+	case PowerActionTerminate:
+		s.stopReason.Store(OfflineReasonKilled)
+	case PowerActionStop, PowerActionRestart:
+		s.stopReason.Store(OfflineReasonStopped)
+	}
+}
+
+// classifyOffline determines why the server just entered the offline state. If
+// Wings was stopping the server the reason given when the stop was requested is
+// used, otherwise the container exit state is checked to determine if the server
+// crashed.
+func (s *Server) classifyOffline(prevState string) string {
+	reason := s.stopReason.Load()
+	s.stopReason.Store("")
+
+	if prevState == environment.ProcessStoppingState {
+		if reason == "" {
+			reason = OfflineReasonStopped
+		}
+		return reason
+	}
+
+	exitCode, oomKilled, err := s.Environment.ExitState()
+	if err != nil {
+		s.Log().WithField("error", err).Warn("failed to determine exit state of server process")
+		return OfflineReasonCrashed
+	}
+	if oomKilled {
+		usage := s.resources.Snapshot()
+		s.publishResourceLimit(ResourceMemory, ResourceLimitStopped, int64(usage.Memory), int64(usage.MemoryLimit))
+		return OfflineReasonOOM
+	}
+	if exitCode != 0 {
+		return OfflineReasonCrashed
+	}
+	return OfflineReasonExited
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+// OutputWatchdogConfiguration controls the watchdog that looks for servers that
+// have stopped producing any console output while running.
+type OutputWatchdogConfiguration struct {
+	// The number of seconds a running server can go without any console output
+	// before it is considered stalled. Set to 0 to disable the watchdog.
+	Timeout int `json:"timeout"`
+
+	// Restarts the server once it is considered stalled, rather than only
+	// emitting a warning.
+	Restart bool `json:"restart"`
+}
+
+// outputWatchdog tracks the time since a running server last sent any console
+// output.
+type outputWatchdog struct {
+	mu    sync.Mutex
+	timer *time.Timer
+	after time.Duration
+}
+
+// watchOutput starts or stops the output watchdog for the server based on the
+// state it just entered. The watchdog only runs while the server is running.
+func (s *Server) watchOutput(state string) {
+	s.watchdog.mu.Lock()
+	defer s.watchdog.mu.Unlock()
+
+	if s.watchdog.timer != nil {
+		s.watchdog.timer.Stop()
+		s.watchdog.timer = nil
+	}
+
+	cfg := s.Config().OutputWatchdog
+	if state != environment.ProcessRunningState || cfg.Timeout <= 0 {
+		return
+	}
+
+	var timer *time.Timer
+	s.watchdog.after = time.Duration(cfg.Timeout) * time.Second
+	timer = time.AfterFunc(s.watchdog.after, func() {
+		s.watchdog.mu.Lock()
+		// Don't do anything if the watchdog was stopped while this was firing.
+		current := s.watchdog.timer == timer
+		s.watchdog.mu.Unlock()
+		if current && s.Environment.State() == environment.ProcessRunningState {
+			s.handleOutputStalled(cfg)
+		}
+	})
+	s.watchdog.timer = timer
+}
+
+// resetOutputWatchdog restarts the countdown for the output watchdog, if it is
+// running. This is called for every line of console output, including after the
+// watchdog has fired so that it is armed again once the server recovers.
+func (s *Server) resetOutputWatchdog() {
+	s.watchdog.mu.Lock()
+	defer s.watchdog.mu.Unlock()
+
+	if s.watchdog.timer != nil {
+		s.watchdog.timer.Reset(s.watchdog.after)
+	}
+}
+
+// handleOutputStalled warns about a server that has not sent any console output
+// within the configured timeout, restarting it if configured to do so.
+func (s *Server) handleOutputStalled(cfg OutputWatchdogConfiguration) {
+	s.Log().WithField("timeout", cfg.Timeout).WithField("restart", cfg.Restart).Warn("server has not sent any console output within the watchdog timeout")
+	s.Events().Publish(OutputStalledEvent, strconv.Itoa(cfg.Timeout))
+
+	if !cfg.Restart {
+		s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Server has not sent any output in %d seconds and may have stopped responding.", cfg.Timeout))
+		return
+	}
+
+	s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Server has not sent any output in %d seconds, restarting the server.", cfg.Timeout))
+	if err := s.HandlePowerAction(PowerActionRestart); err != nil {
+		s.Log().WithField("error", err).Error("failed to restart server after output stalled")
+	}
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// PermissionOverrides holds the permissions the Panel has sent for users of a
+// server since their tokens were issued. A token issued before the permissions
+// of its user were updated still carries the old permissions, so these replace
+// them everywhere a token for the server is used.
+type PermissionOverrides struct {
+	mu    sync.RWMutex
+	users map[string]permissionOverride
+}
+
+type permissionOverride struct {
+	permissions []string
+	updatedAt   time.Time
+}
+
+// Set replaces the permissions of the user for any token issued before now.
+func (o *PermissionOverrides) Set(user string, permissions []string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.users == nil {
+		o.users = make(map[string]permissionOverride)
+	}
+	o.users[user] = permissionOverride{
+		permissions: append([]string{}, permissions...),
+		updatedAt:   time.Now(),
+	}
+}
+
+// Get returns the permissions that replace the ones in a token for the user that
+// was issued at the given time. False is returned if the token was issued after
+// the permissions of the user were last updated, in which case the permissions
+// in the token are current.
+func (o *PermissionOverrides) Get(user string, issuedAt time.Time) ([]string, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	p, ok := o.users[user]
+	if !ok || !issuedAt.Before(p.updatedAt) {
+		return nil, false
+	}
+	return append([]string{}, p.permissions...), true
+}
+
+// PermissionOverrides returns the permissions sent by the Panel for the users of
+// the server since their tokens were issued.
+func (s *Server) PermissionOverrides() *PermissionOverrides {
+	return &s.permissionOverrides
+}
+
+// UpdateUserPermissions replaces the permissions of the user for every token
+// issued before now, including the tokens used by the user's open websocket
+// connections. This returns the number of open connections that were updated.
+func (s *Server) UpdateUserPermissions(user string, permissions []string) int {
+	s.permissionOverrides.Set(user, permissions)
+	return s.Websockets().UpdateUserPermissions(user, permissions)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/apex/log"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+)
+
+// PersistedState is the state of a server that is written to the disk so that
+// it is not lost when Wings restarts.
+type PersistedState struct {
+	// The state of the server process when this was written.
+	State string `json:"state"`
+	// The last time the server was restarted after crashing, used to stop a
+	// server that keeps crashing from being restarted forever.
+	LastCrash time.Time     `json:"last_crash"`
+	Crash     *CrashDetails `json:"crash,omitempty"`
+	Restarts  int           `json:"restarts"`
+	SavedAt   time.Time     `json:"saved_at"`
+}
+
+// PersistedState returns the state of the server that is kept across restarts
+// of Wings.
+func (s *Server) PersistedState() PersistedState {
+	s.crasher.mu.RLock()
+	defer s.crasher.mu.RUnlock()
+
+	p := PersistedState{
+		State:     s.Environment.State(),
+		LastCrash: s.crasher.lastCrash,
+		Restarts:  s.crasher.restarts,
+		SavedAt:   time.Now(),
+	}
+	if s.crasher.details != nil {
+		d := *s.crasher.details
+		p.Crash = &d
+	}
+	return p
+}
+
+// RestoreState restores the crash history of a server from the state written
+// to the disk before Wings last stopped. This is called once the server has been
+// reconciled with Docker, and running is whether its container was found to be
+// running.
+//
+// The crash history is always restored, but if the server is no longer in the
+// state it was in when Wings stopped something happened to it while Wings was
+// offline that the crash handler never saw. A server that stopped cannot be
+// assumed to have crashed, since the host may simply have been rebooted, so
+// the difference is logged and the server is left as Docker reports it.
+func (s *Server) RestoreState(p PersistedState, running bool) {
+	s.crasher.mu.Lock()
+	s.crasher.lastCrash = p.LastCrash
+	s.crasher.details = p.Crash
+	s.crasher.restarts = p.Restarts
+	s.crasher.mu.Unlock()
+
+	wasRunning := p.State == environment.ProcessRunningState || p.State == environment.ProcessStartingState
+	l := s.Log().WithFields(log.Fields{"persisted_state": p.State, "saved_at": p.SavedAt})
+	switch {
+	case wasRunning && !running:
+		l.Warn("server stopped while wings was offline, the reason it stopped is unknown")
+	case !wasRunning && running:
+		l.Info("server was started while wings was offline")
+	}
+
+	// The details are normally cleared once the server has stayed up for long
+	// enough after being started, which still applies to a restored server.
+	if running {
+		s.clearCrashDetailsWhenStable()
+	}
+}
+
+// PersistServerState writes the state of every server to the disk, this is a
+// no-op unless PersistState is enabled.
+func (m *Manager) PersistServerState() error {
+	if !config.Get().System.PersistState {
+		return nil
+	}
+	states := map[string]PersistedState{}
+	for _, s := range m.All() {
+		states[s.ID()] = s.PersistedState()
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(config.Get().System.GetServerStatePath(), data, 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ReadServerState returns the state of the servers written to the disk before
+// Wings last stopped. Nothing is returned unless PersistState is enabled.
+func (m *Manager) ReadServerState() (map[string]PersistedState, error) {
+	out := make(map[string]PersistedState)
+	if !config.Get().System.PersistState {
+		return out, nil
+	}
+	f, err := os.Open(config.Get().System.GetServerStatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return out, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var states map[string]PersistedState
+	if err := json.NewDecoder(f).Decode(&states); err != nil && err != io.EOF {
+		return nil, errors.WithStack(err)
+	}
+	// Only return states for servers that we're currently tracking in the system.
+	for id, state := range states {
+		if _, ok := m.Get(id); ok {
+			out[id] = state
+		}
+	}
+	return out, nil
+}
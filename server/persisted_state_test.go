@@ -0,0 +1,47 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+func TestPersistedState(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#RestoreState", func() {
+		g.It("restores the crash history of the server", func() {
+			crashed := time.Now().Add(-time.Minute)
+			s := &Server{}
+			s.RestoreState(PersistedState{
+				State:     environment.ProcessOfflineState,
+				LastCrash: crashed,
+				Crash:     &CrashDetails{ExitCode: 1, Timestamp: crashed},
+				Restarts:  3,
+			}, false)
+
+			g.Assert(s.crasher.LastCrashTime().Equal(crashed)).IsTrue()
+			d := s.LastCrash()
+			g.Assert(d == nil).IsFalse()
+			g.Assert(d.ExitCode).Equal(uint32(1))
+			g.Assert(d.Restarts).Equal(3)
+		})
+	})
+
+	g.Describe("CrashHandler", func() {
+		g.It("resets the restart count when the details are cleared", func() {
+			var cd CrashHandler
+			cd.setLastCrashDetails(CrashDetails{Timestamp: time.Now().Add(-time.Minute)})
+			cd.countRestart()
+			cd.countRestart()
+			g.Assert(cd.LastCrash().Restarts).Equal(2)
+
+			cd.clearLastCrashDetailsBefore(time.Now())
+			g.Assert(cd.LastCrash() == nil).IsTrue()
+			g.Assert(cd.restarts).Equal(0)
+		})
+	})
+}
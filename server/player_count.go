@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// The capture ID used when sending the player count command, this uses the
+// reserved prefix so that a client cannot start a capture with the same ID.
+const playerCountCaptureID = ReservedCaptureIDPrefix + "player-count"
+
+const (
+	defaultPlayerCountTimeout = 5
+	defaultPlayerCountCache   = 30
+)
+
+var (
+	ErrPlayerCountDisabled    = errors.New("player counts are not configured for this server")
+	ErrPlayerCountUnavailable = errors.New("the server did not respond with a player count")
+)
+
+// PlayerCountConfiguration configures how the number of players connected to a
+// server is found. A command is sent to the server and the first line of console
+// output matching the pattern is used for the count.
+type PlayerCountConfiguration struct {
+	// The console command that makes the server print the number of players, such
+	// as "list". Player counts are disabled if this is empty.
+	Command string `json:"command"`
+
+	// The regular expression matching the line with the player count. The count is
+	// taken from the group named "online", or the first group in the pattern. The
+	// maximum number of players is taken from a group named "max" if there is one.
+	Pattern string `json:"pattern"`
+
+	// The number of seconds to wait for the server to respond to the command. The
+	// default is used when set to 0.
+	Timeout int `json:"timeout"`
+
+	// The number of seconds a player count is reused for before the command is
+	// sent again, so that clients asking often do not flood the console. The
+	// default is used when set to 0.
+	CacheFor int `json:"cache_for"`
+
+	// Includes the last player count in the resource usage of the server, which is
+	// sent with stats events and returned by the API.
+	InStats bool `json:"in_stats"`
+}
+
+// PlayerCount is the number of players connected to a server.
+type PlayerCount struct {
+	Online int `json:"online"`
+	// The most players that can connect, only set if the pattern matches it.
+	Max       *int      `json:"max,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// playerCounter caches the last player count for a server. The check lock is
+// held while the command is sent so that only one is ever waiting on the server,
+// without blocking anything reading the cached count.
+type playerCounter struct {
+	check   sync.Mutex
+	source  string
+	pattern *regexp.Regexp
+
+	mu   sync.Mutex
+	last *PlayerCount
+}
+
+// cached returns the last player count for the server, without sending the
+// command if there is not one.
+func (p *playerCounter) cached() *PlayerCount {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.last == nil {
+		return nil
+	}
+	c := *p.last
+	return &c
+}
+
+func (p *playerCounter) store(count *PlayerCount) {
+	p.mu.Lock()
+	p.last = count
+	p.mu.Unlock()
+}
+
+// compile returns the compiled pattern, only compiling it again if it changes.
+func (p *playerCounter) compile(source string) (*regexp.Regexp, error) {
+	if p.pattern != nil && p.source == source {
+		return p.pattern, nil
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		return nil, errors.Wrap(err, "server: invalid player count pattern")
+	}
+	if re.NumSubexp() == 0 {
+		return nil, errors.New("server: player count pattern must have a group matching the count")
+	}
+	p.source, p.pattern = source, re
+	return re, nil
+}
+
+// parsePlayerCount reads the player count from the groups matched by a pattern.
+func parsePlayerCount(re *regexp.Regexp, groups []string) (*PlayerCount, error) {
+	online, max := 0, -1
+	if i := re.SubexpIndex("online"); i > 0 {
+		online = i - 1
+	}
+	if i := re.SubexpIndex("max"); i > 0 {
+		max = i - 1
+	}
+	if online >= len(groups) {
+		return nil, ErrPlayerCountUnavailable
+	}
+	n, err := strconv.Atoi(groups[online])
+	if err != nil {
+		return nil, ErrPlayerCountUnavailable
+	}
+	count := &PlayerCount{Online: n}
+	if max >= 0 && max < len(groups) {
+		if m, err := strconv.Atoi(groups[max]); err == nil {
+			count.Max = &m
+		}
+	}
+	return count, nil
+}
+
+// PlayerCount returns the number of players connected to the server, sending the
+// configured command to the server if the last count is older than the cache
+// period. The server must be running for the count to be checked, and commands
+// must not be disabled for it.
+//
+// If check is not nil it is called with the command just before it is sent, and
+// the command is not sent if it returns an error. This lets the caller make the
+// same checks it makes for any other command sent on behalf of a user, which are
+// not needed when the count is already cached.
+//
+// The command is sent to the server in the same way as any other, so it and its
+// response appear in the console.
+func (s *Server) PlayerCount(ctx context.Context, check func(command string) error) (*PlayerCount, error) {
+	cfg := s.Config().PlayerCount
+	if cfg.Command == "" || cfg.Pattern == "" {
+		return nil, ErrPlayerCountDisabled
+	}
+	cache, timeout := cfg.CacheFor, cfg.Timeout
+	if cache <= 0 {
+		cache = defaultPlayerCountCache
+	}
+	if timeout <= 0 {
+		timeout = defaultPlayerCountTimeout
+	}
+
+	p := &s.players
+	p.check.Lock()
+	defer p.check.Unlock()
+	if last := p.cached(); last != nil && time.Since(last.CheckedAt) < time.Duration(cache)*time.Second {
+		return last, nil
+	}
+	if s.CommandsDisabled() {
+		return nil, ErrPlayerCountDisabled
+	}
+	if !s.IsRunning() {
+		return nil, ErrNotRunning
+	}
+	re, err := p.compile(cfg.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	if check != nil {
+		if err := check(cfg.Command); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := s.AwaitCommandResponse(ctx, playerCountCaptureID, cfg.Command, re, time.Duration(timeout)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Matched {
+		return nil, ErrPlayerCountUnavailable
+	}
+	count, err := parsePlayerCount(re, res.Groups)
+	if err != nil {
+		return nil, err
+	}
+	count.CheckedAt = time.Now().UTC()
+	p.store(count)
+	c := *count
+	return &c, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"regexp"
+	"testing"
+
+	. "github.com/franela/goblin"
+)
+
+func TestParsePlayerCount(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("parsePlayerCount", func() {
+		g.It("uses the first group for the count", func() {
+			re := regexp.MustCompile(`There are (\d+) of a max of (\d+) players online`)
+			count, err := parsePlayerCount(re, re.FindStringSubmatch("There are 3 of a max of 20 players online")[1:])
+			g.Assert(err).IsNil()
+			g.Assert(count.Online).Equal(3)
+			g.Assert(count.Max == nil).IsTrue()
+		})
+
+		g.It("uses the online and max groups when they are named", func() {
+			re := regexp.MustCompile(`players: (?P<max>\d+) max, (?P<online>\d+) online`)
+			count, err := parsePlayerCount(re, re.FindStringSubmatch("players: 32 max, 7 online")[1:])
+			g.Assert(err).IsNil()
+			g.Assert(count.Online).Equal(7)
+			g.Assert(*count.Max).Equal(32)
+		})
+
+		g.It("returns an error if the count is not a number", func() {
+			re := regexp.MustCompile(`online: (\S+)`)
+			_, err := parsePlayerCount(re, re.FindStringSubmatch("online: none")[1:])
+			g.Assert(err).Equal(ErrPlayerCountUnavailable)
+		})
+	})
+
+	g.Describe("playerCounter#compile", func() {
+		g.It("requires a group for the count", func() {
+			var p playerCounter
+			_, err := p.compile(`players online`)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("reuses the pattern until it changes", func() {
+			var p playerCounter
+			a, err := p.compile(`(\d+) online`)
+			g.Assert(err).IsNil()
+			b, _ := p.compile(`(\d+) online`)
+			g.Assert(a == b).IsTrue()
+			c, _ := p.compile(`(\d+) players`)
+			g.Assert(a == c).IsFalse()
+		})
+	})
+}
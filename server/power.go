@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"emperror.dev/errors"
@@ -11,6 +13,8 @@ import (
 
 	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/environment/docker"
+	"github.com/pterodactyl/wings/server/filesystem"
 )
 
 type PowerAction string
@@ -41,6 +45,35 @@ func (pa PowerAction) IsStart() bool {
 	return pa == PowerActionStart || pa == PowerActionRestart
 }
 
+// activePowerAction tracks the power action that currently holds the power lock
+// for a server, and when it was started.
+type activePowerAction struct {
+	mu      sync.Mutex
+	action  PowerAction
+	started time.Time
+}
+
+func (a *activePowerAction) set(action PowerAction) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.action = action
+	a.started = time.Now()
+}
+
+func (a *activePowerAction) clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.action = ""
+}
+
+// matches returns true if the given action is the active action and it was
+// started less than window ago.
+func (a *activePowerAction) matches(action PowerAction, window time.Duration) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.action != "" && a.action == action && time.Since(a.started) < window
+}
+
 // ExecutingPowerAction checks if there is currently a power action being
 // processed for the server.
 func (s *Server) ExecutingPowerAction() bool {
@@ -55,6 +88,19 @@ func (s *Server) ExecutingPowerAction() bool {
 // function rather than making direct calls to the start/stop/restart functions on the
 // environment struct.
 func (s *Server) HandlePowerAction(action PowerAction, waitSeconds ...int) error {
+	return s.handlePowerAction(action, false, waitSeconds...)
+}
+
+// RestartWithImagePull restarts the server the same as HandlePowerAction, but
+// pulls the latest version of the server's image first. The pull only happens
+// once the power lock is held and the restart has passed the duplicate action
+// and state checks, so concurrent requests cannot start more than one pull. The
+// server is not stopped if the pull fails.
+func (s *Server) RestartWithImagePull() error {
+	return s.handlePowerAction(PowerActionRestart, true)
+}
+
+func (s *Server) handlePowerAction(action PowerAction, pull bool, waitSeconds ...int) error {
 	if s.IsInstalling() || s.IsTransferring() || s.IsRestoring() {
 		if s.IsRestoring() {
 			return ErrServerIsRestoring
@@ -64,11 +110,20 @@ func (s *Server) HandlePowerAction(action PowerAction, waitSeconds ...int) error
 		return ErrServerIsInstalling
 	}
 
+	// Ignore the same action being sent again while it is still being processed,
+	// rather than having it wait on the lock and run a second time once the first
+	// one finishes.
+	window := time.Duration(config.Get().System.DuplicatePowerActionWindow) * time.Second
+	if window > 0 && s.powerLock.IsLocked() && s.activeAction.matches(action, window) {
+		return ErrPowerActionInProgress
+	}
+
 	lockId, _ := uuid.NewUUID()
 	log := s.Log().WithField("lock_id", lockId.String()).WithField("action", action)
 
 	cleanup := func() {
 		log.Info("releasing exclusive lock for power action")
+		s.activeAction.clear()
 		s.powerLock.Release()
 	}
 
@@ -105,6 +160,7 @@ func (s *Server) HandlePowerAction(action PowerAction, waitSeconds ...int) error
 		}
 
 		log.Info("acquired exclusive lock on power actions, processing event...")
+		s.activeAction.set(action)
 		defer cleanup()
 	} else {
 		// Still try to acquire the lock if terminating, and it is available, just so that
@@ -115,17 +171,28 @@ func (s *Server) HandlePowerAction(action PowerAction, waitSeconds ...int) error
 		// executiong the power actions.
 		if err := s.powerLock.Acquire(); err == nil {
 			log.Info("acquired exclusive lock on power actions, processing event...")
+			s.activeAction.set(action)
 			defer cleanup()
 		} else {
 			log.Warn("failed to acquire exclusive lock, ignoring failure for termination event")
 		}
 	}
 
-	switch action {
-	case PowerActionStart:
-		if s.Environment.State() != environment.ProcessOfflineState {
+	// Reject actions that make no sense for the current state, such as stopping a
+	// server that is already offline. This is checked once the lock is acquired
+	// since the state may have changed while waiting on it.
+	state := s.Environment.State()
+	if !canPerformPowerAction(state, action) {
+		if action == PowerActionStart {
 			return ErrIsRunning
 		}
+		return errors.WithMessagef(ErrInvalidPowerAction, "cannot %s a server that is %s", action, state)
+	}
+
+	s.setStopReason(action)
+
+	switch action {
+	case PowerActionStart:
 
 		// Run the pre-boot logic for the server before processing the environment start.
 		if err := s.onBeforeStart(); err != nil {
@@ -136,6 +203,12 @@ func (s *Server) HandlePowerAction(action PowerAction, waitSeconds ...int) error
 	case PowerActionStop:
 		fallthrough
 	case PowerActionRestart:
+		if pull {
+			if err := s.PullImage(); err != nil {
+				return err
+			}
+		}
+
 		// We're specifically waiting for the process to be stopped here, otherwise the lock is
 		// released too soon, and you can rack up all sorts of issues.
 		if err := s.Environment.WaitForStop(s.Context(), time.Minute*10, true); err != nil {
@@ -154,6 +227,10 @@ func (s *Server) HandlePowerAction(action PowerAction, waitSeconds ...int) error
 			return nil
 		}
 
+		if err := s.waitBeforeRestart(state != environment.ProcessOfflineState); err != nil {
+			return err
+		}
+
 		// Now actually try to start the process by executing the normal pre-boot logic.
 		if err := s.onBeforeStart(); err != nil {
 			return err
@@ -167,6 +244,25 @@ func (s *Server) HandlePowerAction(action PowerAction, waitSeconds ...int) error
 	return errors.New("attempting to handle unknown power action")
 }
 
+// PullImage syncs the server with the Panel and then pulls the latest version of
+// the server's container image. This does not affect a running server process,
+// the updated image is used the next time the server is started.
+//
+// This is generally done as part of RestartWithImagePull so that the server is
+// only stopped once the new image is ready to go, and a failed pull does not
+// leave the server offline.
+func (s *Server) PullImage() error {
+	if err := s.Sync(); err != nil {
+		return errors.WithMessage(err, "unable to sync server data from Panel instance")
+	}
+
+	e, ok := s.Environment.(*docker.Environment)
+	if !ok {
+		return errors.New("server: environment does not support pulling images")
+	}
+	return e.PullImage()
+}
+
 // Execute a few functions before actually calling the environment start commands. This ensures
 // that everything is ready to go for environment booting, and that the server can even be started.
 func (s *Server) onBeforeStart() error {
@@ -192,6 +288,9 @@ func (s *Server) onBeforeStart() error {
 	} else {
 		s.PublishConsoleOutputFromDaemon("Checking server disk space usage, this could take a few seconds...")
 		if err := s.Filesystem().HasSpaceErr(false); err != nil {
+			if filesystem.IsErrorCode(err, filesystem.ErrCodeDiskSpace) {
+				s.publishResourceLimit(ResourceDisk, ResourceLimitBlocked, s.Filesystem().CachedUsage(), s.DiskSpace())
+			}
 			return err
 		}
 	}
@@ -218,3 +317,25 @@ func (s *Server) onBeforeStart() error {
 	s.Log().Info("completed server preflight, starting boot process...")
 	return nil
 }
+
+// waitBeforeRestart pauses between a server stopping and being started again
+// during a restart for the configured delay, letting clients know that the
+// server is about to be started so that an offline server is not mistaken for
+// a failed restart. There is nothing to wait for if the server was already
+// offline before the restart, so it is started again straight away.
+func (s *Server) waitBeforeRestart(stopped bool) error {
+	delay := config.Get().System.RestartDelay
+	if !stopped || delay <= 0 {
+		return nil
+	}
+
+	s.Events().Publish(RestartPendingEvent, strconv.Itoa(delay))
+	s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Server stopped, starting again in %d seconds...", delay))
+
+	select {
+	case <-s.Context().Done():
+		return s.Context().Err()
+	case <-time.After(time.Duration(delay) * time.Second):
+		return nil
+	}
+}
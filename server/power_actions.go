@@ -0,0 +1,56 @@
+package server
+
+import (
+	"github.com/pterodactyl/wings/environment"
+)
+
+// powerTransitions defines the power actions that can be performed on a server
+// in each of its states. Any action not listed for a state is rejected rather
+// than being passed along to the environment.
+var powerTransitions = map[string][]PowerAction{
+	environment.ProcessOfflineState:  {PowerActionStart, PowerActionRestart},
+	environment.ProcessStartingState: {PowerActionStop, PowerActionRestart, PowerActionTerminate},
+	environment.ProcessRunningState:  {PowerActionStop, PowerActionRestart, PowerActionTerminate},
+	environment.ProcessStoppingState: {PowerActionTerminate},
+}
+
+// canPerformPowerAction returns true if the power action is valid for a server
+// in the given state.
+func canPerformPowerAction(state string, action PowerAction) bool {
+	for _, a := range powerTransitions[state] {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// AvailablePowerActions returns the power actions that can be performed on the
+// server in its current state, leaving out any that have been disabled for the
+// server. This does not take into account the permissions of whoever is asking.
+func (s *Server) AvailablePowerActions() []PowerAction {
+	busy := s.IsInstalling() || s.IsTransferring() || s.IsRestoring()
+	out := []PowerAction{}
+	for _, a := range availablePowerActions(s.Environment.State(), busy, s.IsSuspended()) {
+		if !s.PowerActionDisabled(a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// availablePowerActions returns the power actions that are valid for a server
+// in the given state. No actions are available while the server is busy being
+// installed, transferred, or restored, and a suspended server cannot be started.
+func availablePowerActions(state string, busy bool, suspended bool) []PowerAction {
+	out := []PowerAction{}
+	if busy {
+		return out
+	}
+	for _, a := range powerTransitions[state] {
+		if !suspended || !a.IsStart() {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+func TestCanPerformPowerAction(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("canPerformPowerAction", func() {
+		matrix := map[string]map[PowerAction]bool{
+			environment.ProcessOfflineState: {
+				PowerActionStart:     true,
+				PowerActionStop:      false,
+				PowerActionRestart:   true,
+				PowerActionTerminate: false,
+			},
+			environment.ProcessStartingState: {
+				PowerActionStart:     false,
+				PowerActionStop:      true,
+				PowerActionRestart:   true,
+				PowerActionTerminate: true,
+			},
+			environment.ProcessRunningState: {
+				PowerActionStart:     false,
+				PowerActionStop:      true,
+				PowerActionRestart:   true,
+				PowerActionTerminate: true,
+			},
+			environment.ProcessStoppingState: {
+				PowerActionStart:     false,
+				PowerActionStop:      false,
+				PowerActionRestart:   false,
+				PowerActionTerminate: true,
+			},
+		}
+
+		for state, actions := range matrix {
+			for action, valid := range actions {
+				state, action, valid := state, action, valid
+				g.It(fmt.Sprintf("%s a server that is %s: %t", action, state, valid), func() {
+					g.Assert(canPerformPowerAction(state, action)).Equal(valid)
+				})
+			}
+		}
+
+		g.It("rejects every action for an unknown state", func() {
+			g.Assert(canPerformPowerAction("unknown", PowerActionTerminate)).IsFalse()
+		})
+	})
+}
+
+func TestAvailablePowerActions(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("availablePowerActions", func() {
+		g.It("only allows starting an offline server", func() {
+			actions := availablePowerActions(environment.ProcessOfflineState, false, false)
+			g.Assert(actions).Equal([]PowerAction{PowerActionStart, PowerActionRestart})
+		})
+
+		g.It("allows stopping a running server", func() {
+			actions := availablePowerActions(environment.ProcessRunningState, false, false)
+			g.Assert(actions).Equal([]PowerAction{PowerActionStop, PowerActionRestart, PowerActionTerminate})
+		})
+
+		g.It("only allows killing a stopping server", func() {
+			actions := availablePowerActions(environment.ProcessStoppingState, false, false)
+			g.Assert(actions).Equal([]PowerAction{PowerActionTerminate})
+		})
+
+		g.It("does not allow starting a suspended server", func() {
+			g.Assert(availablePowerActions(environment.ProcessOfflineState, false, true)).Equal([]PowerAction{})
+
+			actions := availablePowerActions(environment.ProcessRunningState, false, true)
+			g.Assert(actions).Equal([]PowerAction{PowerActionStop, PowerActionTerminate})
+		})
+
+		g.It("does not allow any actions while the server is busy", func() {
+			g.Assert(availablePowerActions(environment.ProcessOfflineState, true, false)).Equal([]PowerAction{})
+		})
+	})
+
+	g.Describe("Server#PowerActionDisabled", func() {
+		g.It("returns true only for the disabled actions", func() {
+			s := &Server{}
+			s.cfg.DisabledPowerActions = []PowerAction{PowerActionTerminate}
+			g.Assert(s.PowerActionDisabled(PowerActionTerminate)).IsTrue()
+			g.Assert(s.PowerActionDisabled(PowerActionStop)).IsFalse()
+		})
+	})
+}
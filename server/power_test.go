@@ -2,9 +2,11 @@ package server
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/franela/goblin"
 
+	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/system"
 )
 
@@ -20,4 +22,50 @@ func TestPower(t *testing.T) {
 			g.Assert(s.ExecutingPowerAction()).IsTrue()
 		})
 	})
+
+	g.Describe("activePowerAction#matches", func() {
+		g.It("matches the same action within the window", func() {
+			var a activePowerAction
+			a.set(PowerActionRestart)
+			g.Assert(a.matches(PowerActionRestart, time.Minute)).IsTrue()
+			g.Assert(a.matches(PowerActionStop, time.Minute)).IsFalse()
+		})
+
+		g.It("does not match once the window has passed", func() {
+			a := activePowerAction{action: PowerActionRestart, started: time.Now().Add(-time.Minute)}
+			g.Assert(a.matches(PowerActionRestart, time.Second*10)).IsFalse()
+		})
+
+		g.It("does not match once the action has finished", func() {
+			var a activePowerAction
+			a.set(PowerActionRestart)
+			a.clear()
+			g.Assert(a.matches(PowerActionRestart, time.Minute)).IsFalse()
+		})
+	})
+
+	g.Describe("Server#waitBeforeRestart", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.RestartDelay = 2
+			config.Set(c)
+		})
+
+		g.It("does not wait if the server was already offline", func() {
+			s := &Server{}
+			ch := make(chan []byte, 1)
+			g.Assert(s.Events().On(ch)).IsNil()
+			defer s.Events().Off(ch)
+
+			start := time.Now()
+			g.Assert(s.waitBeforeRestart(false)).IsNil()
+			g.Assert(time.Since(start) < time.Second).IsTrue()
+
+			select {
+			case <-ch:
+				g.Fail("restart pending event should not be published")
+			default:
+			}
+		})
+	})
 }
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/environment/docker"
+)
+
+// PreflightBlocker is a single reason that a server would not be able to start
+// right now.
+type PreflightBlocker struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Preflight runs the same checks that are performed before a server is started
+// and returns anything that would stop the server from starting. If nothing is
+// returned the server should be able to start.
+//
+// None of these checks make any changes to the server, and unlike a real start
+// the server is not synced with the Panel first, so the checks are based on the
+// configuration Wings already has.
+func (s *Server) Preflight(ctx context.Context) []PreflightBlocker {
+	blockers := []PreflightBlocker{}
+	block := func(code string, err error) {
+		blockers = append(blockers, PreflightBlocker{Code: code, Message: err.Error()})
+	}
+
+	if s.IsInstalling() {
+		block("installing", ErrServerIsInstalling)
+	}
+	if s.IsTransferring() {
+		block("transferring", ErrServerIsTransferring)
+	}
+	if s.IsRestoring() {
+		block("restoring", ErrServerIsRestoring)
+	}
+	if s.IsSuspended() {
+		block("suspended", ErrSuspended)
+	}
+	if s.Environment.State() != environment.ProcessOfflineState {
+		block("running", ErrIsRunning)
+	}
+
+	// Use the cached disk usage here since calculating it can take quite a while
+	// for larger servers.
+	if s.DiskSpace() > 0 {
+		if err := s.Filesystem().HasSpaceErr(true); err != nil {
+			block("disk_space", err)
+		}
+	}
+
+	if err := s.checkMemoryHeadroom(); err != nil {
+		block("memory", err)
+	}
+
+	// Images prefixed with a "~" are local images that are never pulled, so the
+	// server cannot start if the image is missing. Any other image is pulled when
+	// the server starts.
+	if e, ok := s.Environment.(*docker.Environment); ok && strings.HasPrefix(s.Config().Container.Image, "~") {
+		if exists, err := e.ImageExists(ctx); err != nil {
+			block("image", err)
+		} else if !exists {
+			block("image", ErrImageMissing)
+		}
+	}
+
+	return blockers
+}
+
+// checkMemoryHeadroom returns an error if the memory limit for the server is
+// larger than the total memory on the node.
+func (s *Server) checkMemoryHeadroom() error {
+	limit := s.MemoryLimit() * 1024 * 1024
+	if limit <= 0 {
+		return nil
+	}
+
+	host, err := readHostMetrics()
+	if err != nil {
+		return err
+	}
+	if host.memoryTotal > 0 && limit > host.memoryTotal {
+		return ErrNotEnoughMemory
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/environment/docker"
+)
+
+// The longest command sent for a single process, anything after this is cut
+// off so that a process with a huge command line cannot bloat the listing.
+const maxProcessCommandLength = 512
+
+var ErrProcessesDisabled = errors.New("listing the processes in the container is disabled on this node")
+
+// ProcessList is the processes running in the server's container, ordered by
+// the CPU they are using.
+type ProcessList struct {
+	Processes []environment.Process `json:"processes"`
+	// Set if there were more processes than the configured limit and the ones
+	// using the least CPU were not sent.
+	Truncated bool `json:"truncated"`
+}
+
+// Processes returns the processes running in the server's container, with the
+// processes using the most CPU first. This is used to find out which process is
+// responsible when the stats for the server as a whole look high.
+func (s *Server) Processes(ctx context.Context) (*ProcessList, error) {
+	cfg := config.Get().System.Websocket.Processes
+	if !cfg.Enabled {
+		return nil, ErrProcessesDisabled
+	}
+	e, ok := s.Environment.(*docker.Environment)
+	if !ok {
+		return nil, errors.New("server: environment does not support listing processes")
+	}
+	if !s.IsRunning() {
+		return nil, ErrNotRunning
+	}
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+		defer cancel()
+	}
+	processes, err := e.Processes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newProcessList(processes, cfg.MaxProcesses), nil
+}
+
+// newProcessList sorts the processes by their CPU usage and keeps only the
+// first max of them.
+func newProcessList(processes []environment.Process, max int) *ProcessList {
+	sort.SliceStable(processes, func(i, j int) bool {
+		return processes[i].CPU > processes[j].CPU
+	})
+	list := &ProcessList{Processes: processes}
+	if max > 0 && len(processes) > max {
+		list.Processes = processes[:max]
+		list.Truncated = true
+	}
+	for i, p := range list.Processes {
+		if len(p.Command) > maxProcessCommandLength {
+			list.Processes[i].Command = p.Command[:maxProcessCommandLength]
+		}
+	}
+	return list
+}
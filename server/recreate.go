@@ -0,0 +1,96 @@
+package server
+
+import (
+	"os"
+	"time"
+
+	"emperror.dev/errors"
+
+	"github.com/pterodactyl/wings/environment"
+)
+
+// The stages of recreating a server's container, published with a
+// ContainerRecreateEvent as each of them starts.
+const (
+	RecreateStageStopping  = "stopping"
+	RecreateStageRemoving  = "removing"
+	RecreateStageCreating  = "creating"
+	RecreateStageCompleted = "completed"
+	RecreateStageFailed    = "failed"
+)
+
+var ErrDataDirectoryMissing = errors.New("server data directory is missing after the container was recreated")
+
+// RecreateResult is the outcome of recreating a server's container.
+type RecreateResult struct {
+	// Set if the server was running and had to be stopped first. The server is
+	// left offline once the container is recreated.
+	WasRunning bool `json:"was_running"`
+	// Set once the data directory of the server has been confirmed to still exist
+	// after the container was recreated.
+	DataPreserved bool `json:"data_preserved"`
+}
+
+// RecreateContainer removes the server's container and creates it again using
+// the latest configuration from the Panel, for when a container has ended up in
+// a bad state. The data directory is mounted into the container rather than
+// stored in it, so none of the server files are touched. A running server is
+// stopped first, and is left offline once it is done.
+//
+// Clients are sent a ContainerRecreateEvent as each stage starts, along with
+// the error if it fails.
+func (s *Server) RecreateContainer() (res *RecreateResult, err error) {
+	switch {
+	case s.IsInstalling():
+		return nil, ErrServerIsInstalling
+	case s.IsTransferring():
+		return nil, ErrServerIsTransferring
+	case s.IsRestoring():
+		return nil, ErrServerIsRestoring
+	}
+
+	// Hold the power lock for the duration so that the server cannot be started
+	// while it has no container.
+	if err := s.powerLock.Acquire(); err != nil {
+		return nil, ErrPowerActionInProgress
+	}
+	defer s.powerLock.Release()
+
+	defer func() {
+		if err != nil {
+			s.Events().Publish(ContainerRecreateEvent, []string{RecreateStageFailed, err.Error()})
+		}
+	}()
+
+	res = &RecreateResult{}
+	if s.Environment.State() != environment.ProcessOfflineState {
+		res.WasRunning = true
+		s.Events().Publish(ContainerRecreateEvent, []string{RecreateStageStopping})
+		if err := s.Environment.WaitForStop(s.Context(), time.Minute, true); err != nil {
+			return nil, errors.WrapIf(err, "server: failed to stop server before recreating container")
+		}
+	}
+
+	if err := s.Sync(); err != nil {
+		return nil, errors.WrapIf(err, "server: failed to sync server configuration with Panel")
+	}
+
+	s.Events().Publish(ContainerRecreateEvent, []string{RecreateStageRemoving})
+	if err := s.Environment.Destroy(); err != nil {
+		return nil, errors.WrapIf(err, "server: failed to remove container")
+	}
+
+	s.Events().Publish(ContainerRecreateEvent, []string{RecreateStageCreating})
+	if err := s.Environment.Create(); err != nil {
+		return nil, errors.WrapIf(err, "server: failed to create container")
+	}
+
+	if st, err := os.Stat(s.Filesystem().Path()); err != nil || !st.IsDir() {
+		return nil, ErrDataDirectoryMissing
+	}
+	res.DataPreserved = true
+
+	s.Log().WithField("was_running", res.WasRunning).Info("recreated server container")
+	s.Events().Publish(ContainerRecreateEvent, []string{RecreateStageCompleted})
+	return res, nil
+}
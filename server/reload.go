@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"emperror.dev/errors"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/remote"
+)
+
+var ErrInvalidConfiguration = errors.New("server configuration is not valid")
+
+// Reload fetches the latest configuration for the server from the Panel and
+// applies it to the server and its environment, so that changes to the limits,
+// startup command, and startup detection take effect without restarting Wings.
+//
+// Unlike Sync the new configuration is checked before anything is changed, and
+// if it cannot be applied to the environment the previous configuration is
+// restored. Connected clients are told once the new configuration is in use.
+func (s *Server) Reload(ctx context.Context) error {
+	cfg, err := s.client.GetServerConfiguration(ctx, s.ID())
+	if err != nil {
+		if err := remote.AsRequestError(err); err != nil && err.StatusCode() == http.StatusNotFound {
+			return &serverDoesNotExist{}
+		}
+		return errors.WithStackIf(err)
+	}
+	if err := s.validateConfiguration(cfg); err != nil {
+		return err
+	}
+
+	// Keep hold of the configuration currently in use so that it can be put back
+	// if the new one cannot be applied.
+	settings, err := json.Marshal(s.Config())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	previous := remote.ServerConfigurationResponse{Settings: settings, ProcessConfiguration: s.ProcessConfiguration()}
+
+	if err := s.applyConfiguration(cfg); err != nil {
+		if rerr := s.applyConfiguration(previous); rerr != nil {
+			s.Log().WithField("error", rerr).Error("failed to restore previous configuration after reload failed")
+		}
+		return errors.WrapIf(err, "server: failed to apply reloaded configuration")
+	}
+
+	s.Events().Publish(LimitsEvent, s.ResourceLimits())
+	s.Events().Publish(ConfigurationReloadedEvent, s.StartupCommand())
+
+	return nil
+}
+
+// applyConfiguration replaces the configuration of the server and updates the
+// environment to match it.
+func (s *Server) applyConfiguration(cfg remote.ServerConfigurationResponse) error {
+	if err := s.SyncWithConfiguration(cfg); err != nil {
+		return err
+	}
+	s.fs.SetDiskLimit(s.DiskSpace())
+	return s.syncWithEnvironment()
+}
+
+// validateConfiguration returns an error if the configuration returned by the
+// Panel cannot be used for this server.
+func (s *Server) validateConfiguration(cfg remote.ServerConfigurationResponse) error {
+	var c Configuration
+	if err := json.Unmarshal(cfg.Settings, &c); err != nil {
+		return errors.Wrap(ErrInvalidConfiguration, err.Error())
+	}
+	if c.Uuid != s.ID() {
+		return errors.WithMessage(ErrInvalidConfiguration, "uuid does not match the server")
+	}
+	if c.Invocation == "" {
+		return errors.WithMessage(ErrInvalidConfiguration, "startup command is empty")
+	}
+	if c.Build.MemoryLimit < 0 || c.Build.DiskSpace < 0 || c.Build.CpuLimit < 0 || c.Build.Swap < -1 {
+		return errors.WithMessage(ErrInvalidConfiguration, "resource limits cannot be negative")
+	}
+	if cfg.ProcessConfiguration == nil {
+		return errors.WithMessage(ErrInvalidConfiguration, "process configuration is missing")
+	}
+	return nil
+}
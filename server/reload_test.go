@@ -0,0 +1,51 @@
+package server
+
+import (
+	"testing"
+
+	"emperror.dev/errors"
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/remote"
+)
+
+func TestServer_ValidateConfiguration(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#validateConfiguration", func() {
+		s := &Server{}
+		s.cfg.Uuid = "uuid"
+
+		response := func(settings string) remote.ServerConfigurationResponse {
+			return remote.ServerConfigurationResponse{
+				Settings:             []byte(settings),
+				ProcessConfiguration: &remote.ProcessConfiguration{},
+			}
+		}
+
+		g.It("accepts a valid configuration", func() {
+			err := s.validateConfiguration(response(`{"uuid":"uuid","invocation":"java -jar server.jar","build":{"memory_limit":1024,"swap":-1}}`))
+			g.Assert(err).IsNil()
+		})
+
+		g.It("rejects a configuration for another server", func() {
+			err := s.validateConfiguration(response(`{"uuid":"other","invocation":"java -jar server.jar"}`))
+			g.Assert(errors.Is(err, ErrInvalidConfiguration)).IsTrue()
+		})
+
+		g.It("rejects a configuration without a startup command", func() {
+			err := s.validateConfiguration(response(`{"uuid":"uuid"}`))
+			g.Assert(errors.Is(err, ErrInvalidConfiguration)).IsTrue()
+		})
+
+		g.It("rejects negative limits", func() {
+			err := s.validateConfiguration(response(`{"uuid":"uuid","invocation":"start","build":{"disk_space":-1}}`))
+			g.Assert(errors.Is(err, ErrInvalidConfiguration)).IsTrue()
+		})
+
+		g.It("rejects settings that cannot be parsed", func() {
+			err := s.validateConfiguration(response(`{"uuid":`))
+			g.Assert(errors.Is(err, ErrInvalidConfiguration)).IsTrue()
+		})
+	})
+}
@@ -0,0 +1,41 @@
+package server
+
+// The resources that can cause Wings to stop a server, or stop it from starting.
+const (
+	ResourceDisk   = "disk"
+	ResourceMemory = "memory"
+)
+
+// What happened to the server when it exceeded one of its resource limits.
+const (
+	// ResourceLimitStopped is used when a running server was stopped.
+	ResourceLimitStopped = "stopped"
+	// ResourceLimitBlocked is used when the server was not allowed to start.
+	ResourceLimitBlocked = "blocked"
+)
+
+// ResourceLimitExceeded is sent with a ResourceLimitEvent to explain that Wings
+// stopped a server, or would not start it, because it exceeded one of its limits
+// rather than because someone asked it to.
+type ResourceLimitExceeded struct {
+	Resource   string `json:"resource"`
+	Action     string `json:"action"`
+	UsageBytes int64  `json:"usage_bytes"`
+	LimitBytes int64  `json:"limit_bytes"`
+	// A short summary of what happened, such as "stopped: disk limit exceeded".
+	Message string `json:"message"`
+}
+
+// publishResourceLimit tells any connected clients that the server was stopped,
+// or was not allowed to start, because it exceeded the limit for a resource.
+func (s *Server) publishResourceLimit(resource string, action string, usage int64, limit int64) {
+	e := ResourceLimitExceeded{
+		Resource:   resource,
+		Action:     action,
+		UsageBytes: usage,
+		LimitBytes: limit,
+		Message:    action + ": " + resource + " limit exceeded",
+	}
+	s.Log().WithField("resource", resource).WithField("action", action).Info("server exceeded resource limit")
+	s.Events().Publish(ResourceLimitEvent, e)
+}
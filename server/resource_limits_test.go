@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/events"
+)
+
+func TestServer_PublishResourceLimit(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#publishResourceLimit", func() {
+		g.BeforeEach(func() {
+			config.Set(&config.Configuration{AuthenticationToken: "test"})
+		})
+
+		g.It("publishes the resource, action, and values", func() {
+			s := &Server{}
+			s.cfg.Uuid = "uuid"
+			ch := make(chan []byte, 1)
+			g.Assert(s.Events().On(ch)).IsNil()
+			defer s.Events().Off(ch)
+
+			s.publishResourceLimit(ResourceDisk, ResourceLimitStopped, 2048, 1024)
+
+			var e events.Event
+			g.Assert(events.DecodeTo(<-ch, &e)).IsNil()
+			g.Assert(e.Topic).Equal(ResourceLimitEvent)
+
+			b, _ := json.Marshal(e.Data)
+			var data ResourceLimitExceeded
+			g.Assert(json.Unmarshal(b, &data)).IsNil()
+			g.Assert(data).Equal(ResourceLimitExceeded{
+				Resource:   ResourceDisk,
+				Action:     ResourceLimitStopped,
+				UsageBytes: 2048,
+				LimitBytes: 1024,
+				Message:    "stopped: disk limit exceeded",
+			})
+		})
+	})
+}
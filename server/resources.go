@@ -3,6 +3,7 @@ package server
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pterodactyl/wings/environment"
 	"github.com/pterodactyl/wings/system"
@@ -24,18 +25,63 @@ type ResourceUsage struct {
 	// at all times. It is "manually" set whenever server.Proc() is called. This is kind of just a
 	// hacky solution for now to avoid passing events all over the place.
 	Disk int64 `json:"disk_bytes"`
+
+	// The number of zombie processes found in the server container during the last
+	// inspection. This is only populated when zombie detection is enabled.
+	ZombieProcesses int `json:"zombie_processes"`
+
+	// When the disk usage was last calculated. The disk usage is only calculated
+	// every so often, so this lets clients show how current the value is.
+	DiskCalculatedAt *time.Time `json:"disk_calculated_at,omitempty"`
+
+	// The last number of players connected to the server, only set if the server
+	// is configured to include it. This is never checked just to fill this in.
+	Players *PlayerCount `json:"players,omitempty"`
 }
 
 // Proc returns the current resource usage stats for the server instance. This returns
 // a copy of the tracked resources, so making any changes to the response will not
 // have the desired outcome for you most likely.
 func (s *Server) Proc() ResourceUsage {
-	s.resources.mu.Lock()
-	defer s.resources.mu.Unlock()
 	// Store the updated disk usage when requesting process usage.
 	atomic.StoreInt64(&s.resources.Disk, s.Filesystem().CachedUsage())
-	//goland:noinspection GoVetCopyLock
-	return s.resources
+	usage := s.resources.Snapshot()
+	if t := s.Filesystem().LastCalculated(); !t.IsZero() {
+		t = t.UTC()
+		usage.DiskCalculatedAt = &t
+	}
+	if s.Config().PlayerCount.InStats {
+		usage.Players = s.players.cached()
+	}
+	return usage
+}
+
+// DiskUsageCalculated is sent to clients each time the disk usage of a server is
+// calculated.
+type DiskUsageCalculated struct {
+	Disk         int64     `json:"disk_bytes"`
+	CalculatedAt time.Time `json:"calculated_at"`
+}
+
+// publishDiskUsageCalculated lets anyone watching the server know that a fresh
+// disk usage figure is available.
+func (s *Server) publishDiskUsageCalculated(size int64, at time.Time) {
+	s.Events().Publish(DiskUsageCalculatedEvent, DiskUsageCalculated{Disk: size, CalculatedAt: at.UTC()})
+}
+
+// Snapshot returns a copy of the resource usage taken while holding the lock, so
+// that all the values in it were recorded at the same time. The copy has its own
+// lock and can be read freely while the stats continue to be updated.
+func (ru *ResourceUsage) Snapshot() ResourceUsage {
+	ru.mu.RLock()
+	defer ru.mu.RUnlock()
+
+	return ResourceUsage{
+		Stats:           ru.Stats,
+		State:           ru.State,
+		Disk:            atomic.LoadInt64(&ru.Disk),
+		ZombieProcesses: ru.ZombieProcesses,
+	}
 }
 
 // UpdateStats updates the current stats for the server's resource usage.
@@ -45,6 +91,13 @@ func (ru *ResourceUsage) UpdateStats(stats environment.Stats) {
 	ru.mu.Unlock()
 }
 
+// SetZombieProcesses updates the number of zombie processes found in the server.
+func (ru *ResourceUsage) SetZombieProcesses(count int) {
+	ru.mu.Lock()
+	ru.ZombieProcesses = count
+	ru.mu.Unlock()
+}
+
 // Reset resets the usages values to zero, used when a server is stopped to ensure we don't hold
 // onto any values incorrectly.
 func (ru *ResourceUsage) Reset() {
@@ -52,8 +105,12 @@ func (ru *ResourceUsage) Reset() {
 	defer ru.mu.Unlock()
 
 	ru.Memory = 0
+	ru.MemoryCache = 0
+	ru.MemoryRss = 0
+	ru.MemoryMapped = 0
 	ru.CpuAbsolute = 0
 	ru.Uptime = 0
 	ru.Network.TxBytes = 0
 	ru.Network.RxBytes = 0
+	ru.ZombieProcesses = 0
 }
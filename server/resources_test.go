@@ -0,0 +1,68 @@
+package server
+
+import (
+	"sync"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/system"
+)
+
+func TestResourceUsage_Snapshot(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("ResourceUsage#Snapshot", func() {
+		g.It("returns a copy of the current usage", func() {
+			ru := &ResourceUsage{State: system.NewAtomicString(environment.ProcessRunningState)}
+			ru.UpdateStats(environment.Stats{Memory: 1024, CpuAbsolute: 12.5})
+			ru.SetZombieProcesses(2)
+
+			snapshot := ru.Snapshot()
+			ru.Reset()
+
+			g.Assert(snapshot.Memory).Equal(uint64(1024))
+			g.Assert(snapshot.CpuAbsolute).Equal(12.5)
+			g.Assert(snapshot.ZombieProcesses).Equal(2)
+			g.Assert(snapshot.State.Load()).Equal(environment.ProcessRunningState)
+		})
+
+		// Run with -race to check that snapshots can be taken while the stats are
+		// being updated.
+		g.It("can be read while the usage is being updated", func() {
+			ru := &ResourceUsage{State: system.NewAtomicString(environment.ProcessRunningState)}
+
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					ru.UpdateStats(environment.Stats{Memory: uint64(i), Uptime: int64(i)})
+					ru.SetZombieProcesses(i)
+					if i%100 == 0 {
+						ru.Reset()
+					}
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					snapshot := ru.Snapshot()
+					// Memory and uptime are always updated together, so a consistent
+					// snapshot always has them matching.
+					if snapshot.Memory != uint64(snapshot.Uptime) {
+						t.Errorf("inconsistent snapshot: memory %d, uptime %d", snapshot.Memory, snapshot.Uptime)
+						return
+					}
+					if _, err := json.Marshal(&snapshot); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+			wg.Wait()
+		})
+	})
+}
@@ -0,0 +1,15 @@
+package server
+
+import (
+	"github.com/pterodactyl/wings/config"
+)
+
+// MaskSecrets replaces any configured secrets in a line of console output. The
+// line is returned as is when there is nothing to mask.
+func MaskSecrets(line []byte) []byte {
+	m := config.GetSecretMask()
+	if m.Pattern == nil {
+		return line
+	}
+	return m.Pattern.ReplaceAllLiteral(line, m.Replacement)
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("MaskSecrets", func() {
+		setMasking := func(patterns []string, values []string) {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.SecretMasking = config.SecretMasking{Patterns: patterns, Values: values, Replacement: "***"}
+			config.Set(c)
+		}
+
+		g.It("returns the line unchanged when nothing is configured", func() {
+			setMasking(nil, nil)
+			g.Assert(string(MaskSecrets([]byte("password=hunter2")))).Equal("password=hunter2")
+		})
+
+		g.It("masks anything matching a pattern", func() {
+			setMasking([]string{`sk_[a-z0-9]+`}, nil)
+			g.Assert(string(MaskSecrets([]byte("using key sk_abc123 and sk_def456")))).Equal("using key *** and ***")
+		})
+
+		g.It("masks known values as literal text", func() {
+			setMasking(nil, []string{"p4ss.word"})
+			g.Assert(string(MaskSecrets([]byte("rcon p4ss.word p4ssXword")))).Equal("rcon *** p4ssXword")
+		})
+
+		g.It("ignores patterns that cannot be compiled", func() {
+			setMasking([]string{`(unclosed`, `token=\S+`}, nil)
+			g.Assert(string(MaskSecrets([]byte("token=abc (unclosed")))).Equal("*** (unclosed")
+		})
+
+		g.It("uses the new configuration once it changes", func() {
+			setMasking(nil, []string{"one"})
+			g.Assert(string(MaskSecrets([]byte("one two")))).Equal("*** two")
+			setMasking(nil, []string{"two"})
+			g.Assert(string(MaskSecrets([]byte("one two")))).Equal("one ***")
+		})
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"emperror.dev/errors"
 	"github.com/apex/log"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/pterodactyl/wings/config"
 	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/environment/docker"
 	"github.com/pterodactyl/wings/events"
 	"github.com/pterodactyl/wings/remote"
 	"github.com/pterodactyl/wings/server/filesystem"
@@ -32,6 +34,8 @@ type Server struct {
 
 	emitterLock sync.Mutex
 	powerLock   *system.Locker
+	// The power action currently holding the power lock.
+	activeAction activePowerAction
 
 	// Maintains the configuration for the server. This is the data that gets returned by the Panel
 	// such as build settings and container images.
@@ -41,9 +45,66 @@ type Server struct {
 	// The crash handler for this server instance.
 	crasher CrashHandler
 
+	// Resolves the server if it never finishes starting.
+	startup startupTimer
+
+	// Warns when a running server stops sending console output.
+	watchdog outputWatchdog
+
+	// Stops the server once it has been idle for too long.
+	idle idleTracker
+
+	// The last number of players connected to the server.
+	players playerCounter
+
+	// The time in nanoseconds that debug logging for the server turns off, or 0
+	// if it is not turned on.
+	debugUntil int64
+
+	// The reason Wings is stopping the server, and the reason the server last
+	// went offline.
+	stopReason    *system.AtomicString
+	offlineReason *system.AtomicString
+
 	resources   ResourceUsage
 	Environment environment.ProcessEnvironment `json:"-"`
 
+	// Serializes taking stats snapshots, and when the last one was taken.
+	snapshotMu   sync.Mutex
+	lastSnapshot time.Time
+
+	// The most recent resource usage samples for the server.
+	statsHistory     *StatsHistory
+	statsHistoryOnce sync.Once
+
+	// The most recent lines of console output from the server.
+	backlog     *ConsoleBacklog
+	backlogOnce sync.Once
+
+	// The command rate limiters for each user connected to the server.
+	commandLimits CommandLimits
+
+	// The permissions of users that were updated after their tokens were issued.
+	permissionOverrides PermissionOverrides
+
+	// The IDs of the commands currently having their output captured.
+	captures   map[string]struct{}
+	capturesMu sync.Mutex
+
+	// Collapses rapid status changes into a single event.
+	statusDebounce statusDebouncer
+
+	// Commands waiting to be sent once the server is running.
+	queuedCommands   []string
+	queuedCommandsMu sync.Mutex
+
+	// The bytes sent over the server's websocket connections.
+	wsBandwidth WebsocketBandwidth
+
+	// The console commands recently sent to the server.
+	audit     *CommandAuditLog
+	auditOnce sync.Once
+
 	fs *filesystem.Filesystem
 
 	// Events emitted by the server instance.
@@ -70,6 +131,11 @@ type Server struct {
 	wsBag       *WebsocketBag
 	wsBagLocker sync.Mutex
 
+	// Cancels the backups that were started over the websocket, keyed by the
+	// UUID of the backup.
+	backups     map[string]context.CancelFunc
+	backupsLock sync.Mutex
+
 	sinks map[system.SinkName]*system.SinkPool
 
 	logSink     *system.SinkPool
@@ -81,16 +147,20 @@ type Server struct {
 func New(client remote.Client) (*Server, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	s := Server{
-		ctx:          ctx,
-		ctxCancel:    &cancel,
-		client:       client,
-		installing:   system.NewAtomicBool(false),
-		transferring: system.NewAtomicBool(false),
-		restoring:    system.NewAtomicBool(false),
-		powerLock:    system.NewLocker(),
+		ctx:           ctx,
+		ctxCancel:     &cancel,
+		client:        client,
+		installing:    system.NewAtomicBool(false),
+		transferring:  system.NewAtomicBool(false),
+		restoring:     system.NewAtomicBool(false),
+		powerLock:     system.NewLocker(),
+		stopReason:    system.NewAtomicString(""),
+		offlineReason: system.NewAtomicString(""),
+		backups:       make(map[string]context.CancelFunc),
 		sinks: map[system.SinkName]*system.SinkPool{
 			system.LogSink:     system.NewSinkPool(),
 			system.InstallSink: system.NewSinkPool(),
+			system.ErrorSink:   system.NewSinkPool(),
 		},
 	}
 	if err := defaults.Set(&s); err != nil {
@@ -99,6 +169,12 @@ func New(client remote.Client) (*Server, error) {
 	if err := defaults.Set(&s.cfg); err != nil {
 		return nil, errors.Wrap(err, "server: could not set defaults for server configuration")
 	}
+	for _, sink := range s.sinks {
+		sink.SetLimit(config.Get().System.Websocket.MaxListeners)
+		// Each reader buffers its own output, so a connection that cannot keep up
+		// only loses its own lines rather than holding up everyone else's.
+		sink.SetWait(0)
+	}
 	s.resources.State = system.NewAtomicString(environment.ProcessOfflineState)
 	return &s, nil
 }
@@ -110,6 +186,7 @@ func (s *Server) CleanupForDestroy() {
 	s.CtxCancel()
 	s.Events().Destroy()
 	s.DestroyAllSinks()
+	ConsoleBacklogBudget().Remove(s.ConsoleBacklog())
 	s.Websockets().CancelAll()
 	s.powerLock.Destroy()
 }
@@ -171,7 +248,7 @@ eloop:
 }
 
 func (s *Server) Log() *log.Entry {
-	return log.WithField("server", s.ID())
+	return s.logger().WithField("server", s.ID())
 }
 
 // Sync syncs the state of the server on the Panel with Wings. This ensures that
@@ -199,6 +276,10 @@ func (s *Server) Sync() error {
 
 	s.SyncWithEnvironment()
 
+	// Let any connected clients know about the current limits in case they were
+	// changed as part of this sync.
+	s.Events().Publish(LimitsEvent, s.ResourceLimits())
+
 	return nil
 }
 
@@ -287,6 +368,24 @@ func (s *Server) EnsureDataDirectoryExists() error {
 	return nil
 }
 
+// StatusArgs returns the current state of the server as it is sent in a status
+// event. For an offline server this includes the reason it went offline, and
+// for any other state the status of the container health check if it is being
+// reported.
+func (s *Server) StatusArgs() []string {
+	st := s.Environment.State()
+	if st == environment.ProcessOfflineState {
+		if reason := s.OfflineReason(); reason != "" {
+			return []string{st, reason}
+		}
+		return []string{st}
+	}
+	if e, ok := s.Environment.(*docker.Environment); ok && e.Health() != "" {
+		return []string{st, e.Health()}
+	}
+	return []string{st}
+}
+
 // OnStateChange sets the state of the server internally. This function handles crash detection as
 // well as reporting to event listeners for the server.
 func (s *Server) OnStateChange() {
@@ -299,13 +398,40 @@ func (s *Server) OnStateChange() {
 	// Emit the event to any listeners that are currently registered.
 	if prevState != s.Environment.State() {
 		s.Log().WithField("status", st).Debug("saw server status change event")
-		s.Events().Publish(StatusEvent, st)
+		if st == environment.ProcessOfflineState {
+			reason := s.classifyOffline(prevState)
+			s.offlineReason.Store(reason)
+			s.publishStatus([]string{st, reason})
+		} else {
+			s.publishStatus(s.StatusArgs())
+		}
+		s.watchStartup(st)
+		s.watchOutput(st)
+		s.resetIdle()
+
+		// The container is recreated every time the server starts, so the output
+		// from the last run is no longer in the Docker logs either.
+		if st == environment.ProcessStartingState {
+			s.ConsoleBacklog().Reset()
+		}
+		s.ConsoleBacklog().SetStarting(st == environment.ProcessStartingState)
+
+		if st == environment.ProcessRunningState {
+			s.clearCrashDetailsWhenStable()
+			s.sendQueuedCommands()
+		}
+		// Commands are only queued for the next time the server starts, if it fails
+		// to start they are dropped rather than being kept for the attempt after.
+		if st == environment.ProcessOfflineState {
+			s.clearQueuedCommands()
+		}
 	}
 
 	// Reset the resource usage to 0 when the process fully stops so that all the UI
 	// views in the Panel correctly display 0.
 	if st == environment.ProcessOfflineState {
 		s.resources.Reset()
+		s.players.store(nil)
 		s.Events().Publish(StatsEvent, s.Proc())
 	}
 
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The value used in place of any secret environment variables when displaying
+// the startup command for a server.
+const redactedValue = "[redacted]"
+
+// Matches the {{VARIABLE}} and ${VARIABLE} placeholders that can appear in a
+// server's startup command. These are replaced by the container entrypoint when
+// the server is started.
+var startupVariableRegex = regexp.MustCompile(`{{\s*([A-Za-z0-9_.]+)\s*}}|\${([A-Za-z0-9_]+)}`)
+
+// Environment variable names that are one of these words, or end in one of them
+// after an underscore, are considered to hold secret values and are never
+// displayed.
+var secretVariableWords = []string{"PASSWORD", "PASSWD", "PASS", "SECRET", "TOKEN", "KEY", "AUTH"}
+
+// isSecretVariable returns true if the given environment variable name looks
+// like it holds a secret value, such as RCON_PASSWORD or API_KEY. Names that
+// only contain one of the words, such as KEYBOARD_LAYOUT, are not secrets.
+func isSecretVariable(name string) bool {
+	name = strings.ToUpper(name)
+	for _, w := range secretVariableWords {
+		if name == w || strings.HasSuffix(name, "_"+w) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartupCommand returns the command that is used to start the server process
+// with all the variable placeholders replaced by their values. The placeholders
+// of any variables that look like secrets are redacted instead.
+func (s *Server) StartupCommand() string {
+	return resolveStartupCommand(s.Config().Invocation, s.GetEnvironmentVariables())
+}
+
+// resolveStartupCommand substitutes the given environment variables, in the
+// KEY=value format, into the invocation.
+func resolveStartupCommand(invocation string, env []string) string {
+	values := make(map[string]string, len(env))
+	for _, v := range env {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if isSecretVariable(parts[0]) {
+			values[parts[0]] = redactedValue
+			continue
+		}
+		values[parts[0]] = parts[1]
+	}
+
+	return startupVariableRegex.ReplaceAllStringFunc(invocation, func(match string) string {
+		m := startupVariableRegex.FindStringSubmatch(match)
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+		// Placeholders in the {{env.VARIABLE}} format are also supported by the
+		// entrypoint, so strip that prefix off when looking up the value.
+		name = strings.TrimPrefix(name, "env.")
+		if v, ok := values[strings.ToUpper(name)]; ok {
+			return v
+		}
+		return match
+	})
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+)
+
+func TestResolveStartupCommand(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("resolveStartupCommand", func() {
+		env := []string{"SERVER_MEMORY=1024", "SERVER_JAR=server.jar", "RCON_PASSWORD=hunter2"}
+
+		g.It("replaces variable placeholders", func() {
+			cmd := resolveStartupCommand("java -Xmx{{SERVER_MEMORY}}M -jar ${SERVER_JAR}", env)
+			g.Assert(cmd).Equal("java -Xmx1024M -jar server.jar")
+		})
+
+		g.It("supports the env prefix on placeholders", func() {
+			cmd := resolveStartupCommand("java -jar {{env.SERVER_JAR}}", env)
+			g.Assert(cmd).Equal("java -jar server.jar")
+		})
+
+		g.It("leaves unknown placeholders alone", func() {
+			cmd := resolveStartupCommand("./start {{UNKNOWN}}", env)
+			g.Assert(cmd).Equal("./start {{UNKNOWN}}")
+		})
+
+		g.It("redacts secret variables", func() {
+			cmd := resolveStartupCommand("./start --rcon {{RCON_PASSWORD}}", env)
+			g.Assert(cmd).Equal("./start --rcon " + redactedValue)
+		})
+
+		g.It("only redacts the placeholders of secret variables", func() {
+			cmd := resolveStartupCommand("./start --rcon hunter2 {{RCON_PASSWORD}}", env)
+			g.Assert(cmd).Equal("./start --rcon hunter2 " + redactedValue)
+		})
+
+		g.It("matches secret variable names exactly or by suffix", func() {
+			env := []string{"KEY=a", "API_KEY=b", "KEYBOARD_LAYOUT=c", "MONKEY=d", "PASSPORT=e"}
+			cmd := resolveStartupCommand("{{KEY}} {{API_KEY}} {{KEYBOARD_LAYOUT}} {{MONKEY}} {{PASSPORT}}", env)
+			g.Assert(cmd).Equal(redactedValue + " " + redactedValue + " c d e")
+		})
+	})
+}
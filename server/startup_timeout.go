@@ -0,0 +1,105 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+)
+
+// StartupTimeoutConfiguration overrides the startup timeout configured for the
+// node for a single server.
+type StartupTimeoutConfiguration struct {
+	// The number of seconds the server can remain in the starting state. Set to 0
+	// to use the timeout configured for the node, or -1 to allow the server to
+	// remain starting forever.
+	Timeout int `json:"timeout"`
+
+	// What is done to the server once it reaches the timeout, using the same
+	// values as the node configuration. The node's action is used when empty.
+	Action string `json:"action"`
+}
+
+// startupTimer tracks how long a server has been in the starting state so that
+// it can be resolved if it never finishes starting.
+type startupTimer struct {
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// startupTimeout returns the startup timeout in seconds and the action to take
+// once it is reached for the server, using the node's configuration for anything
+// the server does not override.
+func (s *Server) startupTimeout() (int, string) {
+	node := config.Get().System.StartupTimeout
+	cfg := s.Config().StartupTimeout
+
+	timeout, action := node.Timeout, node.Action
+	if cfg.Timeout != 0 {
+		timeout = cfg.Timeout
+	}
+	if cfg.Action != "" {
+		action = cfg.Action
+	}
+	return timeout, action
+}
+
+// watchStartup starts or stops the startup timer for the server based on the
+// state it just entered. The timer is only running while the server is starting.
+func (s *Server) watchStartup(state string) {
+	s.startup.mu.Lock()
+	defer s.startup.mu.Unlock()
+
+	if s.startup.timer != nil {
+		s.startup.timer.Stop()
+		s.startup.timer = nil
+	}
+
+	timeout, action := s.startupTimeout()
+	if state != environment.ProcessStartingState || timeout <= 0 {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(timeout)*time.Second, func() {
+		s.startup.mu.Lock()
+		// Don't do anything if the state changed while this was firing.
+		current := s.startup.timer == timer
+		s.startup.mu.Unlock()
+		if current && s.Environment.State() == environment.ProcessStartingState {
+			s.handleStartupTimeout(timeout, action)
+		}
+	})
+	s.startup.timer = timer
+}
+
+// handleStartupTimeout resolves a server that has been starting for longer than
+// the configured timeout.
+func (s *Server) handleStartupTimeout(timeout int, action string) {
+	s.Log().WithField("timeout", timeout).WithField("action", action).Warn("server did not finish starting before the startup timeout")
+	s.Events().Publish(StartupTimeoutEvent, action)
+
+	switch action {
+	case config.StartupTimeoutStop:
+		s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Server did not finish starting within %d seconds, stopping the server.", timeout))
+		if err := s.HandlePowerAction(PowerActionStop); err != nil {
+			s.Log().WithField("error", err).Error("failed to stop server after startup timeout")
+		}
+	case config.StartupTimeoutKill:
+		s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Server did not finish starting within %d seconds, killing the server.", timeout))
+		// Terminate the environment directly rather than sending a kill power action
+		// so that the offline reason is not replaced.
+		s.stopReason.Store(OfflineReasonStartupTimeout)
+		if err := s.Environment.Terminate(s.Context(), os.Kill); err != nil {
+			s.Log().WithField("error", err).Error("failed to kill server after startup timeout")
+		}
+	case config.StartupTimeoutWarn:
+		s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Server has not finished starting after %d seconds.", timeout))
+	default:
+		s.PublishConsoleOutputFromDaemon(fmt.Sprintf("Server did not finish starting within %d seconds, marking it as running.", timeout))
+		s.Environment.SetState(environment.ProcessRunningState)
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"testing"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+func TestServer_StartupTimeout(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#startupTimeout", func() {
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.StartupTimeout = config.StartupTimeout{Timeout: 900, Action: config.StartupTimeoutRunning}
+			config.Set(c)
+		})
+
+		g.It("uses the node configuration by default", func() {
+			timeout, action := (&Server{}).startupTimeout()
+			g.Assert(timeout).Equal(900)
+			g.Assert(action).Equal(config.StartupTimeoutRunning)
+		})
+
+		g.It("uses the timeout and action set for the server", func() {
+			s := &Server{}
+			s.cfg.StartupTimeout = StartupTimeoutConfiguration{Timeout: 60, Action: config.StartupTimeoutKill}
+			timeout, action := s.startupTimeout()
+			g.Assert(timeout).Equal(60)
+			g.Assert(action).Equal(config.StartupTimeoutKill)
+		})
+
+		g.It("allows the timeout to be disabled for the server", func() {
+			s := &Server{}
+			s.cfg.StartupTimeout = StartupTimeoutConfiguration{Timeout: -1}
+			timeout, action := s.startupTimeout()
+			g.Assert(timeout).Equal(-1)
+			g.Assert(action).Equal(config.StartupTimeoutRunning)
+		})
+	})
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// The maximum number of stats samples that will be kept for a server, regardless
+// of what is configured.
+const maxStatsHistory = 300
+
+// StatsSample is a single resource usage sample that was recorded for a server.
+type StatsSample struct {
+	Timestamp time.Time       `json:"timestamp"`
+	Usage     json.RawMessage `json:"usage"`
+}
+
+// StatsHistory is a fixed size ring buffer containing the most recent resource
+// usage samples for a server.
+type StatsHistory struct {
+	mu      sync.Mutex
+	samples []StatsSample
+	next    int
+	full    bool
+}
+
+// NewStatsHistory returns a buffer that holds up to size samples. The size is
+// capped at maxStatsHistory.
+func NewStatsHistory(size int) *StatsHistory {
+	if size > maxStatsHistory {
+		size = maxStatsHistory
+	}
+	if size < 0 {
+		size = 0
+	}
+	return &StatsHistory{samples: make([]StatsSample, size)}
+}
+
+// Push adds a sample to the buffer, replacing the oldest one if the buffer is
+// full.
+func (h *StatsHistory) Push(sample StatsSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) == 0 {
+		return
+	}
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Samples returns a copy of the samples in the buffer ordered from oldest to
+// newest.
+func (h *StatsHistory) Samples() []StatsSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]StatsSample{}, h.samples[:h.next]...)
+	}
+	out := make([]StatsSample, 0, len(h.samples))
+	out = append(out, h.samples[h.next:]...)
+	return append(out, h.samples[:h.next]...)
+}
+
+// StatsHistory returns the recent resource usage samples for the server.
+func (s *Server) StatsHistory() *StatsHistory {
+	s.statsHistoryOnce.Do(func() {
+		s.statsHistory = NewStatsHistory(config.Get().System.Websocket.StatsHistory)
+	})
+	return s.statsHistory
+}
+
+// recordStats stores the current resource usage of the server in its history.
+func (s *Server) recordStats() {
+	b, err := json.Marshal(s.Proc())
+	if err != nil {
+		return
+	}
+	s.StatsHistory().Push(StatsSample{Timestamp: time.Now(), Usage: b})
+}
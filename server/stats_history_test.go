@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestStatsHistory(t *testing.T) {
+	g := Goblin(t)
+
+	sample := func(i int) StatsSample {
+		return StatsSample{Timestamp: time.Unix(int64(i), 0)}
+	}
+	timestamps := func(samples []StatsSample) []int64 {
+		var out []int64
+		for _, s := range samples {
+			out = append(out, s.Timestamp.Unix())
+		}
+		return out
+	}
+
+	g.Describe("StatsHistory", func() {
+		g.It("returns samples in the order they were pushed", func() {
+			h := NewStatsHistory(3)
+			h.Push(sample(1))
+			h.Push(sample(2))
+
+			g.Assert(timestamps(h.Samples())).Equal([]int64{1, 2})
+		})
+
+		g.It("drops the oldest samples once full", func() {
+			h := NewStatsHistory(3)
+			for i := 1; i <= 5; i++ {
+				h.Push(sample(i))
+			}
+
+			g.Assert(timestamps(h.Samples())).Equal([]int64{3, 4, 5})
+		})
+
+		g.It("caps the size of the buffer", func() {
+			h := NewStatsHistory(maxStatsHistory + 100)
+			g.Assert(len(h.samples)).Equal(maxStatsHistory)
+		})
+
+		g.It("does nothing when disabled", func() {
+			h := NewStatsHistory(0)
+			h.Push(sample(1))
+
+			g.Assert(len(h.Samples())).Equal(0)
+		})
+	})
+}
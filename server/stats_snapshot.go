@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/pterodactyl/wings/environment"
+	"github.com/pterodactyl/wings/environment/docker"
+)
+
+// How long a stats snapshot is reused for before Docker is asked for a new one.
+const statsSnapshotDebounce = time.Second * 2
+
+// StatsSnapshot returns the resource usage of the server using a fresh reading
+// from Docker, rather than the last value from the resource polling. Readings
+// are reused for a couple of seconds so that a flood of requests does not cause
+// a flood of calls to Docker, and only one reading is taken at a time.
+//
+// The last polled value is returned if the server is offline or a new reading
+// cannot be taken.
+func (s *Server) StatsSnapshot(ctx context.Context) ResourceUsage {
+	e, ok := s.Environment.(*docker.Environment)
+	if !ok || s.Environment.State() == environment.ProcessOfflineState {
+		return s.Proc()
+	}
+
+	s.snapshotMu.Lock()
+	defer s.snapshotMu.Unlock()
+
+	if time.Since(s.lastSnapshot) >= statsSnapshotDebounce {
+		st, err := e.Stats(ctx)
+		if err != nil {
+			s.Log().WithField("error", err).Warn("failed to get stats snapshot for server")
+			return s.Proc()
+		}
+		s.resources.UpdateStats(st)
+		s.lastSnapshot = time.Now()
+	}
+	return s.Proc()
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"math"
+
+	"github.com/goccy/go-json"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/environment"
+)
+
+// Trend is the direction a resource usage value is moving in.
+type Trend string
+
+const (
+	TrendRising  Trend = "rising"
+	TrendStable  Trend = "stable"
+	TrendFalling Trend = "falling"
+)
+
+// ResourceTrend is the direction memory and CPU usage have been moving in across
+// the recent stats samples for a server. The rates are the percentage of the
+// average usage that the value is changing by each minute.
+type ResourceTrend struct {
+	Memory     Trend   `json:"memory"`
+	MemoryRate float64 `json:"memory_rate"`
+	Cpu        Trend   `json:"cpu"`
+	CpuRate    float64 `json:"cpu_rate"`
+	Samples    int     `json:"samples"`
+}
+
+// ResourceTrend returns the direction memory and CPU usage have been moving in
+// across the recent stats samples for the server, using a linear regression of
+// those samples.
+func (s *Server) ResourceTrend() ResourceTrend {
+	return resourceTrend(s.StatsHistory().Samples(), config.Get().System.Websocket.TrendSensitivity)
+}
+
+// resourceTrend calculates the trend for the samples. Values changing by less
+// than the sensitivity, as a percentage of the average per minute, are stable.
+func resourceTrend(samples []StatsSample, sensitivity float64) ResourceTrend {
+	var times, memory, cpu []float64
+	for _, sample := range samples {
+		var st environment.Stats
+		if err := json.Unmarshal(sample.Usage, &st); err != nil {
+			continue
+		}
+		times = append(times, float64(sample.Timestamp.UnixNano())/1e9)
+		memory = append(memory, float64(st.Memory))
+		cpu = append(cpu, st.CpuAbsolute)
+	}
+
+	t := ResourceTrend{Samples: len(times)}
+	t.MemoryRate = trendRate(times, memory)
+	t.Memory = classifyTrend(t.MemoryRate, sensitivity)
+	t.CpuRate = trendRate(times, cpu)
+	t.Cpu = classifyTrend(t.CpuRate, sensitivity)
+	return t
+}
+
+// trendRate returns the slope of the least squares line through the values, as
+// a percentage of their average per minute. Returns 0 if there are not enough
+// values to calculate a slope.
+func trendRate(times []float64, values []float64) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+
+	var sumT, sumV float64
+	for i := range values {
+		sumT += times[i]
+		sumV += values[i]
+	}
+	meanT, meanV := sumT/n, sumV/n
+	if meanV == 0 {
+		return 0
+	}
+
+	var num, den float64
+	for i := range values {
+		num += (times[i] - meanT) * (values[i] - meanV)
+		den += (times[i] - meanT) * (times[i] - meanT)
+	}
+	if den == 0 {
+		return 0
+	}
+
+	// The slope is per second, so convert it to a percentage of the average per
+	// minute to make it comparable between servers of different sizes.
+	return num / den * 60 / math.Abs(meanV) * 100
+}
+
+// classifyTrend returns the trend for a rate of change.
+func classifyTrend(rate float64, sensitivity float64) Trend {
+	switch {
+	case rate > sensitivity:
+		return TrendRising
+	case rate < -sensitivity:
+		return TrendFalling
+	}
+	return TrendStable
+}
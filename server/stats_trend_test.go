@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestResourceTrend(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("resourceTrend", func() {
+		samples := func(memory func(i int) uint64) []StatsSample {
+			start := time.Now()
+			out := make([]StatsSample, 0, 30)
+			for i := 0; i < 30; i++ {
+				out = append(out, StatsSample{
+					Timestamp: start.Add(time.Duration(i) * time.Second),
+					Usage:     []byte(fmt.Sprintf(`{"memory_bytes":%d,"cpu_absolute":50}`, memory(i))),
+				})
+			}
+			return out
+		}
+
+		g.It("detects rising usage", func() {
+			t := resourceTrend(samples(func(i int) uint64 { return 1000 + uint64(i)*10 }), 1)
+			g.Assert(t.Memory).Equal(TrendRising)
+			g.Assert(t.Cpu).Equal(TrendStable)
+			g.Assert(t.Samples).Equal(30)
+		})
+
+		g.It("detects falling usage", func() {
+			t := resourceTrend(samples(func(i int) uint64 { return 2000 - uint64(i)*10 }), 1)
+			g.Assert(t.Memory).Equal(TrendFalling)
+		})
+
+		g.It("treats small changes as stable", func() {
+			t := resourceTrend(samples(func(i int) uint64 { return 1000000 + uint64(i%2) }), 1)
+			g.Assert(t.Memory).Equal(TrendStable)
+		})
+
+		g.It("is stable without enough samples", func() {
+			t := resourceTrend(nil, 1)
+			g.Assert(t.Memory).Equal(TrendStable)
+			g.Assert(t.Samples).Equal(0)
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// StatusSummary is published with a StatusSummaryEvent when status changes were
+// collapsed into a single status event.
+type StatusSummary struct {
+	// Every state the server went through during the window, oldest first. This
+	// includes the state that was last sent and the final state.
+	States []string `json:"states"`
+	// The state the server ended up in, which is also sent as a status event.
+	Final string `json:"final"`
+}
+
+// statusDebouncer collapses status changes that happen within a short window of
+// each other so that a server in a crash loop does not flood clients with
+// events.
+type statusDebouncer struct {
+	mu sync.Mutex
+	// Set while a window is open, any status published is held until it ends.
+	timer *time.Timer
+	// The most recent status held during the current window, and every state seen
+	// during it including the one that opened the window.
+	pending []string
+	states  []string
+}
+
+// publishStatus publishes a status event for the server. If status debouncing is
+// enabled the first change is published straight away and any changes within
+// the window after it are held, with the last of them published once the window
+// ends so that clients always end up with the correct state.
+func (s *Server) publishStatus(args []string) {
+	window := time.Duration(config.Get().System.StatusDebounce) * time.Millisecond
+	if window <= 0 {
+		s.Events().Publish(StatusEvent, args)
+		return
+	}
+
+	d := &s.statusDebounce
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer != nil {
+		d.pending = args
+		if len(args) > 0 {
+			d.states = append(d.states, args[0])
+		}
+		return
+	}
+	s.Events().Publish(StatusEvent, args)
+	d.states = nil
+	if len(args) > 0 {
+		d.states = []string{args[0]}
+	}
+	d.timer = time.AfterFunc(window, func() {
+		s.flushStatus(window)
+	})
+}
+
+// flushStatus publishes the last status held during the window that just ended,
+// along with a summary of every state seen. If anything was held another window
+// is opened so that a server that keeps changing state continues to have its
+// changes collapsed.
+func (s *Server) flushStatus(window time.Duration) {
+	d := &s.statusDebounce
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pending == nil {
+		d.timer = nil
+		return
+	}
+	args, states := d.pending, d.states
+	d.pending, d.states = nil, nil
+
+	s.Events().Publish(StatusEvent, args)
+	// Only send a summary if there were states in between the first and last that
+	// clients never received a status event for.
+	if len(states) > 2 {
+		s.Events().Publish(StatusSummaryEvent, StatusSummary{States: states, Final: states[len(states)-1]})
+	}
+	if len(args) > 0 {
+		d.states = []string{args[0]}
+	}
+	d.timer = time.AfterFunc(window, func() {
+		s.flushStatus(window)
+	})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+
+	"github.com/pterodactyl/wings/config"
+	"github.com/pterodactyl/wings/events"
+)
+
+func TestStatusDebounce(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("Server#publishStatus", func() {
+		var s *Server
+		var ch chan []byte
+		g.BeforeEach(func() {
+			c := &config.Configuration{AuthenticationToken: "test"}
+			c.System.StatusDebounce = 50
+			config.Set(c)
+
+			s = &Server{}
+			ch = make(chan []byte, 16)
+			g.Assert(s.Events().On(ch)).IsNil()
+		})
+
+		next := func() events.Event {
+			select {
+			case b := <-ch:
+				var e events.Event
+				g.Assert(events.DecodeTo(b, &e)).IsNil()
+				return e
+			case <-time.After(time.Second):
+				g.Fail("no event was published")
+			}
+			return events.Event{}
+		}
+
+		g.It("publishes every status when disabled", func() {
+			config.Update(func(c *config.Configuration) {
+				c.System.StatusDebounce = 0
+			})
+			s.publishStatus([]string{"starting"})
+			s.publishStatus([]string{"running"})
+			g.Assert(next().Data).Equal([]interface{}{"starting"})
+			g.Assert(next().Data).Equal([]interface{}{"running"})
+		})
+
+		g.It("collapses rapid changes into the final state", func() {
+			s.publishStatus([]string{"starting"})
+			s.publishStatus([]string{"offline", "crashed"})
+			s.publishStatus([]string{"starting"})
+			s.publishStatus([]string{"running"})
+
+			e := next()
+			g.Assert(e.Topic).Equal(StatusEvent)
+			g.Assert(e.Data).Equal([]interface{}{"starting"})
+
+			e = next()
+			g.Assert(e.Topic).Equal(StatusEvent)
+			g.Assert(e.Data).Equal([]interface{}{"running"})
+
+			e = next()
+			g.Assert(e.Topic).Equal(StatusSummaryEvent)
+			g.Assert(e.Data).Equal(map[string]interface{}{
+				"states": []interface{}{"starting", "offline", "starting", "running"},
+				"final":  "running",
+			})
+		})
+
+		g.It("does not send a summary when nothing was skipped", func() {
+			s.publishStatus([]string{"starting"})
+			s.publishStatus([]string{"running"})
+			g.Assert(next().Data).Equal([]interface{}{"starting"})
+			g.Assert(next().Data).Equal([]interface{}{"running"})
+
+			select {
+			case <-ch:
+				g.Fail("unexpected event was published")
+			case <-time.After(time.Millisecond * 120):
+			}
+		})
+	})
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pterodactyl/wings/config"
+)
+
+// Console output is only sent while less than this share of a server's
+// websocket bandwidth has been used in the current second, so that there is
+// always room left for status and stats events.
+const consoleBandwidthShare = 0.9
+
+// WebsocketBandwidth tracks the bytes sent over all the websocket connections to
+// a server, and decides when console output needs to be dropped to keep them
+// under the configured limit.
+type WebsocketBandwidth struct {
+	mu     sync.Mutex
+	window int64
+	used   int64
+
+	sent    uint64
+	dropped uint64
+}
+
+// Record adds the size of a message sent to one of the server's connections to
+// the bytes sent in the current second.
+func (b *WebsocketBandwidth) Record(n int) {
+	atomic.AddUint64(&b.sent, uint64(n))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll(time.Now())
+	b.used += int64(n)
+}
+
+// AllowConsole returns true if a line of console output of the given size can be
+// sent without the server going over its bandwidth limit. The second the check
+// was made in is also returned so that a connection can tell when a new period
+// of throttling has started. Lines are always allowed if there is no limit.
+func (b *WebsocketBandwidth) AllowConsole(n int) (bool, int64) {
+	return b.allow(time.Now(), n, config.Get().System.Websocket.ServerBandwidth)
+}
+
+func (b *WebsocketBandwidth) allow(now time.Time, n int, limit int64) (bool, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll(now)
+	if limit <= 0 || float64(b.used+int64(n)) <= float64(limit)*consoleBandwidthShare {
+		return true, b.window
+	}
+	atomic.AddUint64(&b.dropped, 1)
+	return false, b.window
+}
+
+// roll starts counting from zero again once a new second has started.
+func (b *WebsocketBandwidth) roll(now time.Time) {
+	if w := now.Unix(); w != b.window {
+		b.window = w
+		b.used = 0
+	}
+}
+
+// BytesSent returns the total number of bytes sent over the server's websocket
+// connections since Wings started.
+func (b *WebsocketBandwidth) BytesSent() uint64 {
+	return atomic.LoadUint64(&b.sent)
+}
+
+// LinesDropped returns the number of lines of console output that were not sent
+// to a connection because the server was over its bandwidth limit.
+func (b *WebsocketBandwidth) LinesDropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// WebsocketBandwidth returns the bandwidth used by the server's websocket
+// connections.
+func (s *Server) WebsocketBandwidth() *WebsocketBandwidth {
+	return &s.wsBandwidth
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/franela/goblin"
+)
+
+func TestWebsocketBandwidth(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("WebsocketBandwidth", func() {
+		g.It("always allows console output without a limit", func() {
+			var b WebsocketBandwidth
+			b.Record(1 << 20)
+			ok, _ := b.allow(time.Now(), 100, 0)
+			g.Assert(ok).IsTrue()
+		})
+
+		g.It("drops console output once the limit is nearly used", func() {
+			var b WebsocketBandwidth
+			now := time.Now()
+			b.roll(now)
+			b.used = 850
+
+			ok, _ := b.allow(now, 40, 1000)
+			g.Assert(ok).IsTrue()
+			ok, _ = b.allow(now, 60, 1000)
+			g.Assert(ok).IsFalse()
+			g.Assert(b.LinesDropped()).Equal(uint64(1))
+		})
+
+		g.It("allows console output again in the next second", func() {
+			var b WebsocketBandwidth
+			now := time.Now()
+			b.roll(now)
+			b.used = 1000
+
+			ok, w1 := b.allow(now, 10, 1000)
+			g.Assert(ok).IsFalse()
+			ok, w2 := b.allow(now.Add(time.Second), 10, 1000)
+			g.Assert(ok).IsTrue()
+			g.Assert(w2 != w1).IsTrue()
+		})
+
+		g.It("tracks the total bytes sent", func() {
+			var b WebsocketBandwidth
+			b.Record(10)
+			b.Record(20)
+			g.Assert(b.BytesSent()).Equal(uint64(30))
+		})
+	})
+}
@@ -5,13 +5,33 @@ import (
 	"sync"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 )
 
 type WebsocketBag struct {
 	mu    sync.Mutex
-	conns map[uuid.UUID]*context.CancelFunc
+	conns map[uuid.UUID]*websocketConn
 }
 
+// websocketConn tracks a single open websocket connection, and the user that it
+// was authenticated as once that is known.
+type websocketConn struct {
+	cancel *context.CancelFunc
+	close  WebsocketCloser
+	user   string
+
+	updatePermissions WebsocketPermissionUpdater
+	echoCommand       func(command string)
+}
+
+// WebsocketCloser sends a close frame with the given code and reason to a
+// websocket client and then terminates the connection.
+type WebsocketCloser func(code int, reason string)
+
+// WebsocketPermissionUpdater replaces the permissions used by an open websocket
+// connection.
+type WebsocketPermissionUpdater func(permissions []string)
+
 // Websockets returns the websocket bag which contains all the currently open websocket connections
 // for the server instance.
 func (s *Server) Websockets() *WebsocketBag {
@@ -26,15 +46,143 @@ func (s *Server) Websockets() *WebsocketBag {
 }
 
 // Push adds a new websocket connection to the end of the stack.
-func (w *WebsocketBag) Push(u uuid.UUID, cancel *context.CancelFunc) {
+func (w *WebsocketBag) Push(u uuid.UUID, cancel *context.CancelFunc, close WebsocketCloser) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
 	if w.conns == nil {
-		w.conns = make(map[uuid.UUID]*context.CancelFunc)
+		w.conns = make(map[uuid.UUID]*websocketConn)
+	}
+
+	w.conns[u] = &websocketConn{cancel: cancel, close: close}
+}
+
+// SetUser associates an open connection with the user it was authenticated as.
+func (w *WebsocketBag) SetUser(u uuid.UUID, user string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if conn, ok := w.conns[u]; ok {
+		conn.user = user
+	}
+}
+
+// OnPermissionsUpdate sets the function used to change the permissions of an
+// open connection.
+func (w *WebsocketBag) OnPermissionsUpdate(u uuid.UUID, fn WebsocketPermissionUpdater) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if conn, ok := w.conns[u]; ok {
+		conn.updatePermissions = fn
+	}
+}
+
+// OnCommandEcho sets the function used to echo a command sent by the user of an
+// open connection from somewhere else back to that connection.
+func (w *WebsocketBag) OnCommandEcho(u uuid.UUID, fn func(command string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if conn, ok := w.conns[u]; ok {
+		conn.echoCommand = fn
+	}
+}
+
+// EchoCommand echoes a command sent by the user without a websocket connection
+// to each of the user's open connections, which only show it if they have turned
+// on command echo.
+func (w *WebsocketBag) EchoCommand(user string, command string) {
+	var echoes []func(string)
+	w.mu.Lock()
+	for _, conn := range w.conns {
+		if user != "" && conn.user == user && conn.echoCommand != nil {
+			echoes = append(echoes, conn.echoCommand)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, fn := range echoes {
+		fn(command)
+	}
+}
+
+// UpdateUserPermissions replaces the permissions of every open connection
+// authenticated as the given user and returns the number of connections that
+// were updated. This only applies to the connections that are open, use
+// Server.UpdateUserPermissions so that older tokens used later are covered too.
+func (w *WebsocketBag) UpdateUserPermissions(user string, permissions []string) int {
+	var updaters []WebsocketPermissionUpdater
+	w.mu.Lock()
+	for _, conn := range w.conns {
+		if user != "" && conn.user == user && conn.updatePermissions != nil {
+			updaters = append(updaters, conn.updatePermissions)
+		}
+	}
+	w.mu.Unlock()
+
+	// Call these outside of the lock since a connection that loses the permission
+	// to connect is closed, causing it to be removed from the bag.
+	for _, fn := range updaters {
+		fn(permissions)
 	}
+	return len(updaters)
+}
+
+// RevokeUser terminates every open connection authenticated as the given user
+// and returns the number of connections that were closed. This does not stop
+// the user from connecting again if they still have a valid token.
+func (w *WebsocketBag) RevokeUser(user string) int {
+	return w.closeWhere(websocket.ClosePolicyViolation, "session revoked", func(conn *websocketConn) bool {
+		return user != "" && conn.user == user
+	})
+}
 
-	w.conns[u] = cancel
+// CloseAll sends a close frame with the given code and reason to every open
+// connection and then terminates them.
+func (w *WebsocketBag) CloseAll(code int, reason string) {
+	w.closeWhere(code, reason, func(*websocketConn) bool {
+		return true
+	})
+}
+
+func (w *WebsocketBag) closeWhere(code int, reason string, match func(conn *websocketConn) bool) int {
+	var closers []WebsocketCloser
+	w.mu.Lock()
+	for _, conn := range w.conns {
+		if conn.close != nil && match(conn) {
+			closers = append(closers, conn.close)
+		}
+	}
+	w.mu.Unlock()
+
+	// Call these outside of the lock since closing a connection will cause it to
+	// be removed from the bag.
+	for _, fn := range closers {
+		fn(code, reason)
+	}
+	return len(closers)
+}
+
+// Closers returns the closer for every open connection.
+func (w *WebsocketBag) Closers() []WebsocketCloser {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	closers := make([]WebsocketCloser, 0, len(w.conns))
+	for _, conn := range w.conns {
+		if conn.close != nil {
+			closers = append(closers, conn.close)
+		}
+	}
+	return closers
+}
+
+// Len returns the number of open connections.
+func (w *WebsocketBag) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.conns)
 }
 
 // Remove removes a connection from the stack.
@@ -51,11 +199,11 @@ func (w *WebsocketBag) CancelAll() {
 	defer w.mu.Unlock()
 
 	if w.conns != nil {
-		for _, cancel := range w.conns {
-			(*cancel)()
+		for _, conn := range w.conns {
+			(*conn.cancel)()
 		}
 	}
 
 	// Reset the connections.
-	w.conns = make(map[uuid.UUID]*context.CancelFunc)
+	w.conns = make(map[uuid.UUID]*websocketConn)
 }
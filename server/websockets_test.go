@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/franela/goblin"
+	"github.com/google/uuid"
+)
+
+func TestWebsocketBag_RevokeUser(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("WebsocketBag#RevokeUser", func() {
+		g.It("only revokes connections for the given user", func() {
+			bag := &WebsocketBag{}
+			var revoked []string
+
+			for _, user := range []string{"a", "b", "a"} {
+				u := uuid.New()
+				_, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				user := user
+				bag.Push(u, &cancel, func(int, string) {
+					revoked = append(revoked, user)
+				})
+				bag.SetUser(u, user)
+			}
+
+			g.Assert(bag.RevokeUser("a")).Equal(2)
+			g.Assert(revoked).Equal([]string{"a", "a"})
+		})
+
+		g.It("does not revoke connections that have not authenticated", func() {
+			bag := &WebsocketBag{}
+			_, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			bag.Push(uuid.New(), &cancel, func(int, string) {})
+			g.Assert(bag.RevokeUser("")).Equal(0)
+		})
+	})
+}
+
+func TestWebsocketBag_UpdateUserPermissions(t *testing.T) {
+	g := Goblin(t)
+
+	g.Describe("WebsocketBag#UpdateUserPermissions", func() {
+		g.It("only updates connections for the given user", func() {
+			bag := &WebsocketBag{}
+			updated := map[string][]string{}
+
+			for _, user := range []string{"a", "b"} {
+				u := uuid.New()
+				_, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				user := user
+				bag.Push(u, &cancel, func(int, string) {})
+				bag.SetUser(u, user)
+				bag.OnPermissionsUpdate(u, func(permissions []string) {
+					updated[user] = permissions
+				})
+			}
+
+			g.Assert(bag.UpdateUserPermissions("a", []string{"websocket.connect"})).Equal(1)
+			g.Assert(updated["a"]).Equal([]string{"websocket.connect"})
+			_, ok := updated["b"]
+			g.Assert(ok).IsFalse()
+		})
+	})
+}
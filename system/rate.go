@@ -41,6 +41,21 @@ func (r *Rate) Try() bool {
 	return true
 }
 
+// SetLimit changes the limit and duration of the rate limiter. The count is
+// reset if either of them changed, so that items counted under the old limit do
+// not count towards the new one.
+func (r *Rate) SetLimit(limit uint64, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.limit == limit && r.duration == duration {
+		return
+	}
+	r.limit = limit
+	r.duration = duration
+	r.count = 0
+	r.last = time.Now()
+}
+
 // Reset resets the internal state of the rate limiter back to zero.
 func (r *Rate) Reset() {
 	r.mu.Lock()
@@ -3,8 +3,14 @@ package system
 import (
 	"sync"
 	"time"
+
+	"emperror.dev/errors"
 )
 
+// ErrSinkLimitReached is returned when attempting to add a channel to a sink
+// pool that already has the maximum number of channels registered.
+var ErrSinkLimitReached = errors.Sentinel("sink: maximum number of listeners reached")
+
 // SinkName represents one of the registered sinks for a server.
 type SinkName string
 
@@ -14,27 +20,86 @@ const (
 	LogSink SinkName = "log"
 	// InstallSink handles installation output for a server.
 	InstallSink SinkName = "install"
+	// ErrorSink handles console output that game servers write to stderr. This is
+	// only used when containers are run without a TTY, otherwise all the output
+	// is sent to the LogSink.
+	ErrorSink SinkName = "error"
 )
 
+// The default amount of time Push waits for a full channel to be read from.
+const defaultSinkWait = time.Millisecond * 10
+
 // SinkPool represents a pool with sinks.
 type SinkPool struct {
 	mu    sync.RWMutex
 	sinks []chan []byte
+	limit int
+	wait  time.Duration
+
+	// Set when each channel has its own queue, see SetQueue.
+	queueSize int
+	queues    map[chan []byte]*sinkQueue
 }
 
 // NewSinkPool returns a new empty SinkPool. A sink pool generally lives with a
 // server instance for its full lifetime.
 func NewSinkPool() *SinkPool {
-	return &SinkPool{}
+	return &SinkPool{wait: defaultSinkWait}
 }
 
-// On adds a channel to the sink pool instance.
-func (p *SinkPool) On(c chan []byte) {
+// SetLimit sets the maximum number of channels that can be registered with the
+// pool at once. A limit of zero or less removes the limit.
+func (p *SinkPool) SetLimit(limit int) {
 	p.mu.Lock()
-	p.sinks = append(p.sinks, c)
+	p.limit = limit
+	p.mu.Unlock()
+}
+
+// SetWait sets how long Push waits for a full channel to be read from before
+// dropping the oldest message in it. A wait of zero or less never waits, so a
+// channel that is not being read fast enough can never hold up delivery to the
+// other channels in the pool.
+func (p *SinkPool) SetWait(d time.Duration) {
+	p.mu.Lock()
+	p.wait = d
+	p.mu.Unlock()
+}
+
+// SetQueue gives each channel registered with the pool its own queue of up to
+// size messages, and a goroutine that delivers them. Push then only adds the
+// message to each queue and returns straight away, so a channel that is slow to
+// be read never holds up Push, and a burst of messages does not start a new
+// goroutine for every message and channel. The oldest message in a queue is
+// dropped once it is full. The wait set for the pool is not used.
+//
+// This only applies to channels registered after it is called, so it should be
+// called before the pool is used.
+func (p *SinkPool) SetQueue(size int) {
+	p.mu.Lock()
+	p.queueSize = size
 	p.mu.Unlock()
 }
 
+// On adds a channel to the sink pool instance. If the pool already has the
+// maximum number of channels registered an error is returned and the channel is
+// not added.
+func (p *SinkPool) On(c chan []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.limit > 0 && len(p.sinks) >= p.limit {
+		return ErrSinkLimitReached
+	}
+	p.sinks = append(p.sinks, c)
+	if p.queueSize > 0 && c != nil {
+		if p.queues == nil {
+			p.queues = make(map[chan []byte]*sinkQueue)
+		}
+		p.queues[c] = newSinkQueue(c, p.queueSize)
+	}
+	return nil
+}
+
 // Off removes a given channel from the sink pool. If no matching sink is found
 // this function is a no-op. If a matching channel is found, it will be removed.
 func (p *SinkPool) Off(c chan []byte) {
@@ -55,6 +120,12 @@ func (p *SinkPool) Off(c chan []byte) {
 		sinks = sinks[:len(sinks)-1]
 		p.sinks = sinks
 
+		// Stop delivering queued messages before the channel is closed.
+		if q, ok := p.queues[c]; ok {
+			q.close()
+			delete(p.queues, c)
+		}
+
 		// Avoid a panic if the sink channel is nil at this point.
 		if c != nil {
 			close(c)
@@ -70,6 +141,9 @@ func (p *SinkPool) Destroy() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	for _, q := range p.queues {
+		q.close()
+	}
 	for _, c := range p.sinks {
 		if c != nil {
 			close(c)
@@ -77,6 +151,7 @@ func (p *SinkPool) Destroy() {
 	}
 
 	p.sinks = nil
+	p.queues = nil
 }
 
 // Push sends a given message to each of the channels registered in the pool.
@@ -92,12 +167,30 @@ func (p *SinkPool) Destroy() {
 // There is a potential for data to be lost when passing it through this
 // function, but only in instances where the channel buffer is full and the
 // channel is not drained fast enough, in which case dropping messages is most
-// likely the best option anyways. This uses waitgroups to allow every channel
-// to attempt its send concurrently thus making the total blocking time of this
-// function "O(1)" instead of "O(n)".
+// likely the best option anyways.
+//
+// If the pool has a wait set, this uses waitgroups to allow every channel to
+// attempt its send concurrently for up to that long, making the total blocking
+// time of this function "O(1)" instead of "O(n)". Otherwise each channel acts as
+// its own buffer for the reader and Push never blocks, so a reader that falls
+// behind only loses its own messages and never delays any other reader.
 func (p *SinkPool) Push(data []byte) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
+	if p.queueSize > 0 {
+		for _, c := range p.sinks {
+			if q, ok := p.queues[c]; ok {
+				pushOrDrop(q.in, data)
+			}
+		}
+		return
+	}
+	if p.wait <= 0 {
+		for _, c := range p.sinks {
+			pushOrDrop(c, data)
+		}
+		return
+	}
 	var wg sync.WaitGroup
 	wg.Add(len(p.sinks))
 	for _, c := range p.sinks {
@@ -105,7 +198,7 @@ func (p *SinkPool) Push(data []byte) {
 			defer wg.Done()
 			select {
 			case c <- data:
-			case <-time.After(time.Millisecond * 10):
+			case <-time.After(p.wait):
 				// If there is nothing in the channel to read, but we also cannot write
 				// to the channel, just skip over sending data. If we don't do this you'll
 				// end up blocking the application on the channel read below.
@@ -119,3 +212,66 @@ func (p *SinkPool) Push(data []byte) {
 	}
 	wg.Wait()
 }
+
+// pushOrDrop sends data to the channel without blocking, removing the oldest
+// message from the channel to make room if it is full. If another push fills
+// the space first the message is dropped. Nothing is sent to an unbuffered
+// channel unless a reader is already waiting on it.
+func pushOrDrop(c chan []byte, data []byte) {
+	select {
+	case c <- data:
+		return
+	default:
+	}
+	select {
+	case <-c:
+	default:
+	}
+	select {
+	case c <- data:
+	default:
+	}
+}
+
+// sinkQueue holds the messages waiting to be sent to a single channel in a pool
+// that queues messages, and delivers them to the channel in order.
+type sinkQueue struct {
+	in   chan []byte
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newSinkQueue(c chan []byte, size int) *sinkQueue {
+	q := &sinkQueue{
+		in:   make(chan []byte, size),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go q.deliver(c)
+	return q
+}
+
+// deliver sends each queued message to the channel, waiting for as long as it
+// takes for the channel to be read, until the queue is closed.
+func (q *sinkQueue) deliver(c chan []byte) {
+	defer close(q.done)
+	for {
+		select {
+		case <-q.stop:
+			return
+		case data := <-q.in:
+			select {
+			case c <- data:
+			case <-q.stop:
+				return
+			}
+		}
+	}
+}
+
+// close stops delivering messages and waits for the delivery goroutine to
+// exit, after which nothing else is sent to the channel.
+func (q *sinkQueue) close() {
+	close(q.stop)
+	<-q.done
+}
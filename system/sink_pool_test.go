@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"emperror.dev/errors"
 	. "github.com/franela/goblin"
 )
 
@@ -42,6 +43,17 @@ func TestSink(t *testing.T) {
 			g.Assert(len(pool.sinks)).Equal(1)
 			g.Assert(MutexLocked(&pool.mu)).IsFalse()
 		})
+
+		g.It("returns an error when the limit is reached", func() {
+			pool := &SinkPool{}
+			pool.SetLimit(1)
+
+			g.Assert(pool.On(make(chan []byte, 1))).IsNil()
+
+			err := pool.On(make(chan []byte, 1))
+			g.Assert(errors.Is(err, ErrSinkLimitReached)).IsTrue()
+			g.Assert(len(pool.sinks)).Equal(1)
+		})
 	})
 
 	g.Describe("SinkPool#Off", func() {
@@ -108,7 +120,7 @@ func TestSink(t *testing.T) {
 	g.Describe("SinkPool#Push", func() {
 		var pool *SinkPool
 		g.BeforeEach(func() {
-			pool = &SinkPool{}
+			pool = NewSinkPool()
 		})
 
 		g.It("works when no sinks are registered", func() {
@@ -175,6 +187,60 @@ func TestSink(t *testing.T) {
 			g.Assert(<-ch2).Equal([]byte("testing 2"))
 		})
 
+		g.It("does not slow down other sinks when one is not being read", func() {
+			pool.SetWait(0)
+			slow := make(chan []byte, 2)
+			fast := make(chan []byte, 1)
+			pool.On(slow)
+			pool.On(fast)
+
+			received := make(chan []byte)
+			go func() {
+				for b := range fast {
+					received <- b
+				}
+			}()
+
+			g.Timeout(time.Second)
+			start := time.Now()
+			for i := 0; i < 100; i++ {
+				pool.Push([]byte(fmt.Sprintf("line %d", i)))
+				// Every line is delivered to the fast reader even though the slow one
+				// stopped reading after the first two.
+				g.Assert(<-received).Equal([]byte(fmt.Sprintf("line %d", i)))
+			}
+			// Waiting on the full sink for every line would have taken at least a
+			// second here.
+			g.Assert(time.Since(start) < time.Millisecond*250).IsTrue()
+
+			// The slow reader only lost its own oldest lines.
+			g.Assert(<-slow).Equal([]byte("line 98"))
+			g.Assert(<-slow).Equal([]byte("line 99"))
+			pool.Off(fast)
+		})
+
+		g.It("delivers queued messages without waiting on a sink that is not read", func() {
+			pool := NewSinkPool()
+			pool.SetQueue(4)
+			stalled := make(chan []byte)
+			ch := make(chan []byte)
+			pool.On(stalled)
+			pool.On(ch)
+
+			g.Timeout(time.Second)
+			for i := 0; i < 3; i++ {
+				pool.Push([]byte(fmt.Sprintf("line %d", i)))
+			}
+			for i := 0; i < 3; i++ {
+				g.Assert(<-ch).Equal([]byte(fmt.Sprintf("line %d", i)))
+			}
+
+			pool.Off(stalled)
+			_, ok := <-stalled
+			g.Assert(ok).IsFalse()
+			pool.Destroy()
+		})
+
 		g.It("can handle concurrent pushes FIFO", func() {
 			ch := make(chan []byte, 4)
 